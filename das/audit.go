@@ -0,0 +1,107 @@
+package das
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+// auditQueueSize is the amount of audit records queued for writing. Records are dropped once the
+// queue is full, so a slow writer never stalls sampling.
+const auditQueueSize = 1024
+
+const (
+	auditOutcomeSampled = "sampled"
+	auditOutcomeFailed  = "failed"
+)
+
+// auditRecord is a single line of the audit log. Records are written as JSON lines.
+type auditRecord struct {
+	Time   time.Time `json:"time"`
+	Height uint64    `json:"height"`
+	// Source is the type of job the height was sampled by, or the source of samples on demand
+	Source string `json:"source"`
+	// Root is the hex encoded data root. Empty if the header could not be retrieved.
+	Root       string `json:"root,omitempty"`
+	Outcome    string `json:"outcome"`
+	DurationNs int64  `json:"duration_ns"`
+	Err        string `json:"error,omitempty"`
+	// Metadata is the SampleMetadata of samples requested on demand.
+	Metadata SampleMetadata `json:"metadata,omitempty"`
+}
+
+// auditLog asynchronously writes a record for every sampling verdict to the io.Writer.
+type auditLog struct {
+	w     *bufio.Writer
+	queue chan auditRecord
+	done  chan struct{}
+	clock clock.Clock
+
+	// dropped counts records that were not written due to the full queue
+	dropped atomic.Uint64
+}
+
+func newAuditLog(w io.Writer) *auditLog {
+	return &auditLog{
+		w:     bufio.NewWriter(w),
+		queue: make(chan auditRecord, auditQueueSize),
+		done:  make(chan struct{}),
+		clock: clock.New(),
+	}
+}
+
+// run writes queued records until the audit log is closed, flushing the writer afterwards.
+func (a *auditLog) run() {
+	defer close(a.done)
+
+	enc := json.NewEncoder(a.w)
+	for rec := range a.queue {
+		if err := enc.Encode(rec); err != nil {
+			log.Errorw("writing audit record", "height", rec.Height, "err", err)
+		}
+	}
+
+	if err := a.w.Flush(); err != nil {
+		log.Errorw("flushing audit log", "err", err)
+	}
+}
+
+// observe queues a record for the sampling outcome without blocking.
+func (a *auditLog) observe(o sampleOutcome) {
+	rec := auditRecord{
+		Time:       a.clock.Now(),
+		Height:     o.height,
+		Source:     string(o.source),
+		Outcome:    auditOutcomeSampled,
+		DurationNs: o.duration.Nanoseconds(),
+		Metadata:   o.metadata,
+	}
+	if o.header != nil {
+		rec.Root = o.header.DataHash.String()
+	}
+	if o.err != nil {
+		rec.Outcome = auditOutcomeFailed
+		rec.Err = o.err.Error()
+	}
+
+	select {
+	case a.queue <- rec:
+	default:
+		a.dropped.Add(1)
+	}
+}
+
+// close stops accepting records and waits until all queued records are written and flushed. It
+// must be called only after all observers are done.
+func (a *auditLog) close() {
+	close(a.queue)
+	<-a.done
+
+	if dropped := a.dropped.Load(); dropped > 0 {
+		log.Warnw("audit records were dropped due to slow writer", "amount", dropped)
+	}
+}
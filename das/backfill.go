@@ -0,0 +1,42 @@
+package das
+
+import (
+	"context"
+	"errors"
+)
+
+// adaptiveBackfill limits the amount of parallel catchup and retry workers according to the
+// outcomes of their jobs. Sampling of old heights often times out when few peers still serve them,
+// so dispatching more workers for them only multiplies timeouts. The limit is halved on every job
+// with failed heights and is restored by one worker with every successful job.
+type adaptiveBackfill struct {
+	limit    int
+	maxLimit int
+}
+
+func newAdaptiveBackfill(maxLimit int) *adaptiveBackfill {
+	return &adaptiveBackfill{
+		limit:    maxLimit,
+		maxLimit: maxLimit,
+	}
+}
+
+// observe adjusts the limit according to the result of a catchup or retry job.
+func (b *adaptiveBackfill) observe(res result) {
+	switch {
+	case res.jobType == recentJob, errors.Is(res.err, context.Canceled), len(res.throttled) > 0:
+		// recent heights are not backfilled, while cancellations and backpressure say nothing about
+		// availability of old heights
+	case len(res.failed) > 0:
+		if b.limit > 1 {
+			b.limit /= 2
+			log.Warnw("sampling of old heights is failing, reducing amount of backfill workers",
+				"limit", b.limit)
+		}
+	case b.limit < b.maxLimit:
+		b.limit++
+		if b.limit == b.maxLimit {
+			log.Infow("sampling of old heights recovered, backfill workers are fully restored")
+		}
+	}
+}
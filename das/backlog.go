@@ -0,0 +1,79 @@
+package das
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+// backlogHistorySize is the maximum amount of the most recent points BacklogHistory retains.
+const backlogHistorySize = 1440
+
+// BacklogPoint is the catchup backlog, i.e. the amount of headers not yet sampled up to the
+// network head, recorded at the given time.
+type BacklogPoint struct {
+	At   time.Time `json:"at"`
+	Size uint64    `json:"size"`
+}
+
+// backlogHistory periodically records the catchup backlog into a ring of points.
+type backlogHistory struct {
+	lock   sync.Mutex
+	points []BacklogPoint
+	// next is the index the next point is written to, once the ring is full
+	next  int
+	clock clock.Clock
+}
+
+func newBacklogHistory() *backlogHistory {
+	return &backlogHistory{clock: clock.New()}
+}
+
+// run records the backlog reported by stats every interval until the context is done.
+func (b *backlogHistory) run(
+	ctx context.Context,
+	interval time.Duration,
+	stats func(context.Context) (SamplingStats, error),
+) {
+	ticker := b.clock.Ticker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s, err := stats(ctx)
+			if err != nil {
+				continue
+			}
+			b.add(BacklogPoint{At: b.clock.Now(), Size: heightsBetween(s.SampledChainHead, s.NetworkHead)})
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// add records the point, overwriting the oldest one once the history is full.
+func (b *backlogHistory) add(p BacklogPoint) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if len(b.points) < backlogHistorySize {
+		b.points = append(b.points, p)
+		return
+	}
+	b.points[b.next] = p
+	b.next = (b.next + 1) % backlogHistorySize
+}
+
+// get returns a copy of the recorded points, the oldest first.
+func (b *backlogHistory) get() []BacklogPoint {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if len(b.points) == 0 {
+		return nil
+	}
+	points := make([]BacklogPoint, 0, len(b.points))
+	points = append(points, b.points[b.next:]...)
+	return append(points, b.points[:b.next]...)
+}
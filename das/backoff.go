@@ -12,6 +12,9 @@ var (
 	// after defaultBackoffMaxRetryCount amount of attempts retry backoff interval will stop growing
 	// and each retry attempt will produce WARN log
 	defaultBackoffMaxRetryCount = 4
+	// heights not sampled due to backpressure from availability will be sampled again after
+	// defaultBackpressureDelay
+	defaultBackpressureDelay = 10 * time.Second
 )
 
 // retryStrategy defines a backoff for retries.
@@ -57,3 +60,41 @@ func exponentialBackoff(baseInterval time.Duration, multiplier, amount int) []ti
 	}
 	return backoff
 }
+
+// RetryOrder defines the order failed heights are retried in.
+type RetryOrder string
+
+const (
+	// RetryOldestFirst retries the lowest failed heights first.
+	RetryOldestFirst RetryOrder = "oldest-first"
+	// RetryNewestFirst retries the highest failed heights first.
+	RetryNewestFirst RetryOrder = "newest-first"
+	// RetryFewestAttemptsFirst retries heights with the fewest sampling attempts first. Heights with
+	// the same amount of attempts are retried oldest first.
+	RetryFewestAttemptsFirst RetryOrder = "fewest-attempts-first"
+)
+
+func (o RetryOrder) isValid() bool {
+	switch o {
+	case RetryOldestFirst, RetryNewestFirst, RetryFewestAttemptsFirst:
+		return true
+	default:
+		return false
+	}
+}
+
+// before reports whether height a with attempt aAttempt should be retried before height b with
+// attempt bAttempt.
+func (o RetryOrder) before(a uint64, aAttempt retryAttempt, b uint64, bAttempt retryAttempt) bool {
+	switch o {
+	case RetryNewestFirst:
+		return a > b
+	case RetryFewestAttemptsFirst:
+		if aAttempt.count != bAttempt.count {
+			return aAttempt.count < bAttempt.count
+		}
+		return a < b
+	default:
+		return a < b
+	}
+}
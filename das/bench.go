@@ -0,0 +1,117 @@
+package das
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	libhead "github.com/celestiaorg/go-header"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/celestiaorg/celestia-node/share/p2p/shrexsub"
+)
+
+// BenchHarness runs the sampling coordinator in memory, without a subscription, checkpoints or
+// metrics, to benchmark the coordinator together with the given Availability in a reproducible way.
+type BenchHarness struct {
+	avail  share.Availability
+	getter libhead.Getter[*header.ExtendedHeader]
+	params Parameters
+}
+
+// BenchResult is the outcome of a single BenchHarness run.
+type BenchResult struct {
+	// Heights is the amount of heights sampled
+	Heights uint64
+	// Failed is the amount of heights that failed to be sampled
+	Failed uint64
+	// Elapsed is the time it took to sample all heights once
+	Elapsed time.Duration
+	// Throughput is the amount of heights sampled per second
+	Throughput float64
+	// Latency are the durations of successful samples
+	Latency LatencyStats
+}
+
+// NewBenchHarness creates a BenchHarness sampling headers of the getter with the Availability.
+// Options configuring Parameters, e.g. WithConcurrencyLimit, are applied to the coordinator, while
+// all other options are ignored.
+func NewBenchHarness(
+	avail share.Availability,
+	getter libhead.Getter[*header.ExtendedHeader],
+	options ...Option,
+) (*BenchHarness, error) {
+	d := &DASer{params: DefaultParameters()}
+	for _, applyOpt := range options {
+		applyOpt(d)
+	}
+	if err := d.params.Validate(); err != nil {
+		return nil, err
+	}
+	return &BenchHarness{avail: avail, getter: getter, params: d.params}, nil
+}
+
+// Run samples the heights from 1 up to the given one once each and reports the results. Failed
+// heights are not retried. If the Availability implements share.Reverifier, availability is
+// verified again for every height, so getters serving the same header for all heights are sampled
+// for real instead of hitting the cache of the Availability.
+func (bh *BenchHarness) Run(ctx context.Context, heights uint64) (BenchResult, error) {
+	if err := validateHeight(heights); err != nil {
+		return BenchResult{}, err
+	}
+
+	var attempted, failed atomic.Uint64
+	allAttempted := make(chan struct{})
+	latencies := newLatencies()
+	sc := newSamplingCoordinator(bh.params, bh.getter, bh.sample, noopBroadcast)
+	sc.observers = append(sc.observers, latencies.observe, func(o sampleOutcome) {
+		if o.attempt != 1 || o.source != catchupJob {
+			return
+		}
+		if o.err != nil {
+			failed.Add(1)
+		}
+		if attempted.Add(1) == heights {
+			close(allAttempted)
+		}
+	})
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	start := time.Now()
+	go sc.run(runCtx, checkpoint{SampleFrom: 1, NetworkHead: heights})
+
+	select {
+	case <-allAttempted:
+	case <-ctx.Done():
+		return BenchResult{}, fmt.Errorf("das: bench harness: %w", ctx.Err())
+	}
+	elapsed := time.Since(start)
+	cancel()
+	if err := sc.wait(ctx); err != nil {
+		return BenchResult{}, err
+	}
+
+	return BenchResult{
+		Heights:    heights,
+		Failed:     failed.Load(),
+		Elapsed:    elapsed,
+		Throughput: float64(heights) / elapsed.Seconds(),
+		Latency:    latencies.get(),
+	}, nil
+}
+
+func (bh *BenchHarness) sample(ctx context.Context, h *header.ExtendedHeader) error {
+	if reverifier, ok := bh.avail.(share.Reverifier); ok {
+		return reverifier.ReverifyAvailable(ctx, h)
+	}
+	return bh.avail.SharesAvailable(ctx, h)
+}
+
+// noopBroadcast drops availability notifications of the harness, as they are only sent for recent
+// headers.
+func noopBroadcast(context.Context, shrexsub.Notification) error {
+	return nil
+}
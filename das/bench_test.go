@@ -0,0 +1,40 @@
+package das
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/celestia-node/header/headertest"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/celestiaorg/celestia-node/share/availability/light"
+	"github.com/celestiaorg/celestia-node/share/getters"
+	"github.com/celestiaorg/celestia-node/share/ipld"
+	"github.com/celestiaorg/celestia-node/share/sharetest"
+)
+
+func BenchmarkBenchHarness(b *testing.B) {
+	ctx := context.Background()
+
+	// every height serves the same 32x32 extended square
+	const odsWidth = 16
+	bServ := ipld.NewMemBlockservice()
+	eds, err := ipld.AddShares(ctx, sharetest.RandShares(b, odsWidth*odsWidth), bServ)
+	require.NoError(b, err)
+	root, err := share.NewRoot(eds)
+	require.NoError(b, err)
+	getter := benchGetterStub{header: headertest.RandExtendedHeaderWithRoot(b, root)}
+
+	harness, err := NewBenchHarness(light.TestAvailability(getters.NewIPLDGetter(bServ)), getter)
+	require.NoError(b, err)
+
+	b.ResetTimer()
+	res, err := harness.Run(ctx, uint64(b.N))
+	require.NoError(b, err)
+	b.StopTimer()
+
+	require.Zero(b, res.Failed)
+	b.ReportMetric(res.Throughput, "samples/sec")
+	b.ReportMetric(float64(res.Latency.P99.Microseconds()), "p99-us")
+}
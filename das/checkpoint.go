@@ -2,6 +2,7 @@ package das
 
 import (
 	"fmt"
+	"time"
 )
 
 type checkpoint struct {
@@ -9,15 +10,17 @@ type checkpoint struct {
 	NetworkHead uint64 `json:"network_head"`
 	// Failed heights will be retried
 	Failed map[uint64]int `json:"failed,omitempty"`
+	// Sampled heights ahead of SampleFrom will not be sampled again by catchup until they expire
+	Sampled map[uint64]time.Time `json:"sampled,omitempty"`
 	// Workers will resume on restart from previous state
 	Workers []workerCheckpoint `json:"workers,omitempty"`
 }
 
 // workerCheckpoint will be used to resume worker on restart
 type workerCheckpoint struct {
-	From    uint64  `json:"from"`
-	To      uint64  `json:"to"`
-	JobType jobType `json:"job_type"`
+	From    uint64       `json:"from"`
+	To      uint64       `json:"to"`
+	JobType SampleSource `json:"job_type"`
 }
 
 func newCheckpoint(stats SamplingStats) checkpoint {
@@ -37,10 +40,59 @@ func newCheckpoint(stats SamplingStats) checkpoint {
 		SampleFrom:  stats.CatchupHead + 1,
 		NetworkHead: stats.NetworkHead,
 		Failed:      stats.Failed,
+		Sampled:     stats.Sampled,
 		Workers:     workers,
 	}
 }
 
+// validate checks the heights of the checkpoint are within the range the DASer samples, so that
+// arithmetic on them neither underflows at genesis nor overflows near the maximum height.
+func (c checkpoint) validate() error {
+	// SampleFrom follows the last sampled height, which could be the maximum one
+	if c.SampleFrom == 0 || c.SampleFrom > maxHeight+1 {
+		return fmt.Errorf("%w: sample from %d", ErrHeightOutOfRange, c.SampleFrom)
+	}
+	if c.NetworkHead > maxHeight {
+		return fmt.Errorf("%w: network head %d", ErrHeightOutOfRange, c.NetworkHead)
+	}
+	for h := range c.Failed {
+		if err := validateHeight(h); err != nil {
+			return fmt.Errorf("failed height: %w", err)
+		}
+	}
+	for _, w := range c.Workers {
+		if err := validateHeight(w.To); err != nil || w.From == 0 || w.From > w.To+1 {
+			return fmt.Errorf("%w: worker range [%d:%d]", ErrHeightOutOfRange, w.From, w.To)
+		}
+	}
+	return nil
+}
+
+// clone returns a deep copy of the checkpoint.
+func (c checkpoint) clone() checkpoint {
+	cp := checkpoint{
+		SampleFrom:  c.SampleFrom,
+		NetworkHead: c.NetworkHead,
+	}
+	if c.Failed != nil {
+		cp.Failed = make(map[uint64]int, len(c.Failed))
+		for h, count := range c.Failed {
+			cp.Failed[h] = count
+		}
+	}
+	if c.Sampled != nil {
+		cp.Sampled = make(map[uint64]time.Time, len(c.Sampled))
+		for h, at := range c.Sampled {
+			cp.Sampled[h] = at
+		}
+	}
+	if c.Workers != nil {
+		cp.Workers = make([]workerCheckpoint, len(c.Workers))
+		copy(cp.Workers, c.Workers)
+	}
+	return cp
+}
+
 func (c checkpoint) String() string {
 	str := fmt.Sprintf("SampleFrom: %v, NetworkHead: %v", c.SampleFrom, c.NetworkHead)
 
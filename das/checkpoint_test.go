@@ -2,6 +2,8 @@ package das
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"testing"
 	"time"
 
@@ -9,6 +11,9 @@ import (
 	"github.com/ipfs/go-datastore/sync"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 )
 
 func TestCheckpointStore(t *testing.T) {
@@ -40,3 +45,225 @@ func TestCheckpointStore(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, cp, got)
 }
+
+func TestCheckpointStore_Codec(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+
+	cp := checkpoint{
+		SampleFrom:  10,
+		NetworkHead: 20,
+		Failed:      map[uint64]int{3: 1, 7: 2},
+		Workers: []workerCheckpoint{
+			{
+				From:    12,
+				To:      15,
+				JobType: catchupJob,
+			},
+		},
+	}
+
+	for _, codec := range []Codec{JSONCodec{}, BinaryCodec{}} {
+		t.Run(fmt.Sprintf("%T", codec), func(t *testing.T) {
+			ds := newCheckpointStore(sync.MutexWrap(datastore.NewMapDatastore()))
+			ds.codec = codec
+			require.NoError(t, ds.store(ctx, cp))
+			got, err := ds.load(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, cp, got)
+		})
+	}
+
+	t.Run("migration", func(t *testing.T) {
+		ds := newCheckpointStore(sync.MutexWrap(datastore.NewMapDatastore()))
+		ds.codec = BinaryCodec{}
+		require.NoError(t, ds.store(ctx, cp))
+
+		// load checkpoint stored in binary format with JSON codec
+		ds.codec = JSONCodec{}
+		got, err := ds.load(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, cp, got)
+	})
+}
+
+func TestCheckpointStore_Compression(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+
+	cp := checkpoint{
+		SampleFrom:  100_000,
+		NetworkHead: 100_000,
+		Failed:      make(map[uint64]int),
+	}
+	for h := uint64(1); h < cp.SampleFrom; h += 7 {
+		cp.Failed[h] = int(h % 5)
+	}
+
+	storedSize := func(ds *checkpointStore) int {
+		bs, err := ds.Get(ctx, checkpointKey)
+		require.NoError(t, err)
+		return len(bs)
+	}
+
+	plain := newCheckpointStore(sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, plain.store(ctx, cp))
+
+	for _, compression := range []Compression{GzipCompression{}, ZstdCompression{}} {
+		t.Run(fmt.Sprintf("%T", compression), func(t *testing.T) {
+			compressed := newCheckpointStore(sync.MutexWrap(datastore.NewMapDatastore()))
+			compressed.compression = compression
+			require.NoError(t, compressed.store(ctx, cp))
+			assert.Less(t, storedSize(&compressed), storedSize(&plain))
+
+			got, err := compressed.load(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, cp, got)
+
+			// compressed checkpoint is detected and decompressed once compression is disabled
+			compressed.compression = nil
+			got, err = compressed.load(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, cp, got)
+		})
+	}
+}
+
+func TestCheckpointStore_Truncated(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+
+	ds := newCheckpointStore(sync.MutexWrap(datastore.NewMapDatastore()))
+	prev := checkpoint{SampleFrom: 10, NetworkHead: 20, Failed: map[uint64]int{5: 1}}
+	require.NoError(t, ds.store(ctx, prev))
+	latest := checkpoint{SampleFrom: 21, NetworkHead: 30, Failed: map[uint64]int{5: 2}}
+	require.NoError(t, ds.store(ctx, latest))
+
+	// the temporary copy is removed once stored
+	has, err := ds.Has(ctx, checkpointTmpKey)
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	// simulate the primary checkpoint truncated by a crash mid-write
+	bs, err := ds.Get(ctx, checkpointKey)
+	require.NoError(t, err)
+	require.NoError(t, ds.Put(ctx, checkpointKey, bs[:len(bs)/2]))
+
+	got, err := ds.load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, prev, got)
+
+	// the complete copy of an interrupted store is preferred over the previous checkpoint
+	require.NoError(t, ds.Put(ctx, checkpointTmpKey, bs))
+	got, err = ds.load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, latest, got)
+
+	// the corrupted checkpoint doesn't replace the fallback on the next store
+	next := checkpoint{SampleFrom: 31, NetworkHead: 40}
+	require.NoError(t, ds.store(ctx, next))
+	got, err = ds.load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, next, got)
+	prevBs, err := ds.Get(ctx, checkpointPrevKey)
+	require.NoError(t, err)
+	got, err = ds.decode(prevBs)
+	require.NoError(t, err)
+	assert.Equal(t, prev, got)
+}
+
+func TestCheckpointStore_HeightsOutOfRange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+
+	ds := newCheckpointStore(sync.MutexWrap(datastore.NewMapDatastore()))
+	intact := checkpoint{SampleFrom: 10, NetworkHead: 20}
+	// stored twice, so the intact checkpoint is kept as the previous one as well
+	require.NoError(t, ds.store(ctx, intact))
+	require.NoError(t, ds.store(ctx, intact))
+
+	tests := map[string]checkpoint{
+		"genesis":    {SampleFrom: 0, NetworkHead: 20},
+		"max head":   {SampleFrom: 10, NetworkHead: math.MaxUint64},
+		"max failed": {SampleFrom: 10, NetworkHead: 20, Failed: map[uint64]int{math.MaxUint64: 1}},
+		"max worker": {SampleFrom: 10, NetworkHead: 20, Workers: []workerCheckpoint{{From: 1, To: math.MaxUint64}}},
+	}
+	for name, cp := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.ErrorIs(t, cp.validate(), ErrHeightOutOfRange)
+
+			// checkpoint with heights out of range is treated as corrupted in favor of the intact one
+			bs, err := ds.codec.Encode(cp)
+			require.NoError(t, err)
+			require.NoError(t, ds.Put(ctx, checkpointKey, bs))
+			got, err := ds.load(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, intact, got)
+		})
+	}
+
+	// the height following the maximum one is representable
+	require.NoError(t, checkpoint{SampleFrom: maxHeight + 1, NetworkHead: maxHeight}.validate())
+}
+
+func TestCheckpointStore_Metrics(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+	storeOpTime, err := meter.Float64Histogram("das_store_op_time_hist")
+	require.NoError(t, err)
+	storeOpErrors, err := meter.Int64Counter("das_store_op_errors_counter")
+	require.NoError(t, err)
+
+	delay := 50 * time.Millisecond
+	ds := newCheckpointStore(slowDatastore{
+		Datastore: sync.MutexWrap(datastore.NewMapDatastore()),
+		delay:     delay,
+	})
+	ds.metrics = &metrics{storeOpTime: storeOpTime, storeOpErrors: storeOpErrors}
+
+	require.NoError(t, ds.store(ctx, checkpoint{SampleFrom: 1, NetworkHead: 1}))
+	_, err = ds.load(ctx)
+	require.NoError(t, err)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	recorded := make(map[string]metricdata.HistogramDataPoint[float64])
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		hist, ok := m.Data.(metricdata.Histogram[float64])
+		if !ok {
+			continue
+		}
+		for _, dp := range hist.DataPoints {
+			op, _ := dp.Attributes.Value(attribute.Key(storeOpLabel))
+			recorded[op.AsString()] = dp
+		}
+	}
+
+	for _, op := range []string{storeOpStore, storeOpLoad} {
+		dp, ok := recorded[op]
+		require.True(t, ok, "%s is not recorded", op)
+		assert.EqualValues(t, 1, dp.Count)
+		assert.GreaterOrEqual(t, dp.Sum, delay.Seconds())
+	}
+}
+
+// slowDatastore delays reads and writes to the underlying datastore.
+type slowDatastore struct {
+	datastore.Datastore
+	delay time.Duration
+}
+
+func (s slowDatastore) Get(ctx context.Context, key datastore.Key) ([]byte, error) {
+	time.Sleep(s.delay)
+	return s.Datastore.Get(ctx, key)
+}
+
+func (s slowDatastore) Put(ctx context.Context, key datastore.Key, value []byte) error {
+	time.Sleep(s.delay)
+	return s.Datastore.Put(ctx, key, value)
+}
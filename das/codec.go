@@ -0,0 +1,89 @@
+package das
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// binaryCodecMagic prefixes checkpoints encoded with BinaryCodec to distinguish them on load.
+var binaryCodecMagic = []byte("das/bin\x00")
+
+// Codec encodes and decodes the DASer checkpoint for storage.
+type Codec interface {
+	// Encode serializes the given value.
+	Encode(v any) ([]byte, error)
+	// Decode deserializes data into the value pointed by v.
+	Decode(data []byte, v any) error
+}
+
+// JSONCodec encodes the checkpoint as JSON. It is the default Codec.
+type JSONCodec struct{}
+
+// Encode implements Codec.
+func (JSONCodec) Encode(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Decode implements Codec.
+func (JSONCodec) Decode(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// BinaryCodec encodes the checkpoint in compact binary format.
+type BinaryCodec struct{}
+
+// Encode implements Codec.
+func (BinaryCodec) Encode(v any) ([]byte, error) {
+	buf := bytes.NewBuffer(bytes.Clone(binaryCodecMagic))
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode implements Codec.
+func (BinaryCodec) Decode(data []byte, v any) error {
+	if !bytes.HasPrefix(data, binaryCodecMagic) {
+		return errors.New("das: data is not encoded with binary codec")
+	}
+	return gob.NewDecoder(bytes.NewReader(data[len(binaryCodecMagic):])).Decode(v)
+}
+
+// detectCodec returns the built-in Codec the data was encoded with, or nil if the format is
+// unknown.
+func detectCodec(data []byte) Codec {
+	switch {
+	case bytes.HasPrefix(data, binaryCodecMagic):
+		return BinaryCodec{}
+	case len(data) > 0 && data[0] == '{':
+		return JSONCodec{}
+	default:
+		return nil
+	}
+}
+
+// decodeCheckpoint decodes the checkpoint with the given Codec. If that fails, it falls back to
+// the detected built-in format, so the checkpoint could be migrated between formats.
+func decodeCheckpoint(codec Codec, data []byte) (checkpoint, error) {
+	cp := checkpoint{}
+	err := codec.Decode(data, &cp)
+	if err == nil {
+		return cp, nil
+	}
+
+	detected := detectCodec(data)
+	if detected == nil {
+		return checkpoint{}, fmt.Errorf("unmarshal checkpoint: %w", err)
+	}
+
+	cp = checkpoint{}
+	if err := detected.Decode(data, &cp); err != nil {
+		return checkpoint{}, fmt.Errorf("unmarshal checkpoint: %w", err)
+	}
+	log.Infow("loaded checkpoint stored in another format, it will be migrated on next store",
+		"format", fmt.Sprintf("%T", detected))
+	return cp, nil
+}
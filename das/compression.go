@@ -0,0 +1,110 @@
+package das
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	// gzipMagic is the header gzip compressed data starts with.
+	gzipMagic = []byte{0x1f, 0x8b}
+	// zstdMagic is the magic number zstd frames start with.
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Compression compresses the encoded checkpoint for storage.
+type Compression interface {
+	// Compress compresses the data.
+	Compress(data []byte) ([]byte, error)
+	// Decompress decompresses the data compressed with Compress.
+	Decompress(data []byte) ([]byte, error)
+	// Magic returns the bytes the compressed data starts with. It is used to detect compressed
+	// checkpoints on load.
+	Magic() []byte
+}
+
+// GzipCompression compresses the checkpoint with gzip.
+type GzipCompression struct{}
+
+// Compress implements Compression.
+func (GzipCompression) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress implements Compression.
+func (GzipCompression) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Magic implements Compression.
+func (GzipCompression) Magic() []byte {
+	return gzipMagic
+}
+
+// ZstdCompression compresses the checkpoint with zstd.
+type ZstdCompression struct{}
+
+// Compress implements Compression.
+func (ZstdCompression) Compress(data []byte) ([]byte, error) {
+	w, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Close()
+	return w.EncodeAll(data, nil), nil
+}
+
+// Decompress implements Compression.
+func (ZstdCompression) Decompress(data []byte) ([]byte, error) {
+	r, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return r.DecodeAll(data, nil)
+}
+
+// Magic implements Compression.
+func (ZstdCompression) Magic() []byte {
+	return zstdMagic
+}
+
+// decompressCheckpoint decompresses the stored checkpoint, if it is compressed with the given or
+// any of the built-in compressions. Uncompressed data is returned as is, so compression could be
+// enabled or disabled between restarts.
+func decompressCheckpoint(compression Compression, data []byte) ([]byte, error) {
+	var detected Compression
+	switch {
+	case compression != nil && bytes.HasPrefix(data, compression.Magic()):
+		detected = compression
+	case bytes.HasPrefix(data, gzipMagic):
+		detected = GzipCompression{}
+	case bytes.HasPrefix(data, zstdMagic):
+		detected = ZstdCompression{}
+	default:
+		return data, nil
+	}
+
+	data, err := detected.Decompress(data)
+	if err != nil {
+		return nil, fmt.Errorf("decompress checkpoint: %w", err)
+	}
+	return data, nil
+}
@@ -1,10 +1,15 @@
 package das
 
 import (
+	"bytes"
 	"context"
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/benbjohnson/clock"
+
 	libhead "github.com/celestiaorg/go-header"
 
 	"github.com/celestiaorg/celestia-node/header"
@@ -14,11 +19,29 @@ import (
 // samplingCoordinator runs and coordinates sampling workers and updates current sampling state
 type samplingCoordinator struct {
 	concurrencyLimit int
-	samplingTimeout  time.Duration
+	// samplingTimeout is the timeout of a single sample in nanoseconds. It is updated at runtime
+	// with setSamplingTimeout.
+	samplingTimeout atomic.Int64
+	// dispatchLimit is the current limit of parallel workers. It is lowered on backpressure from
+	// availability and gradually restored up to concurrencyLimit
+	dispatchLimit int
+	// workerSplit is the fraction of workers reserved for recent jobs. If set, recent headers are
+	// queued in pendingRecent until there is a worker available for them
+	workerSplit   float64
+	pendingRecent []*header.ExtendedHeader
+	// priority defines the order pending recent headers are dispatched in, if set
+	priority priorityFn
+	// backfill limits the amount of parallel catchup and retry workers, if adaptive backfill is
+	// enabled
+	backfill *adaptiveBackfill
 
 	getter      libhead.Getter[*header.ExtendedHeader]
 	sampleFn    sampleFn
 	broadcastFn shrexsub.BroadcastFn
+	// inflight deduplicates samples of heights dispatched by multiple jobs at once
+	inflight *inflightSamples
+	// skipCatchup skips sampling of catchup heights that don't need it, e.g. stored locally, if set
+	skipCatchup skipFn
 
 	state coordinatorState
 
@@ -29,16 +52,59 @@ type samplingCoordinator struct {
 	// waitCh signals to block coordinator for external access to state
 	waitCh chan *sync.WaitGroup
 
+	// pauseCh signals to pause or resume dispatching of jobs
+	pauseCh chan pauseRequest
+	// paused keeps reasons dispatching is currently paused for
+	paused map[PauseReason]struct{}
+	// pauses fans out transitions of pause reasons to subscribers
+	pauses *pauseFeed
+	// catchUpPaused indicates whether dispatching of catchup and retry jobs is paused, while recent
+	// jobs are still dispatched
+	catchUpPaused atomic.Bool
+	// wakeCh signals to dispatch jobs again after catchup was resumed
+	wakeCh chan struct{}
+
+	// recent keeps track of running recent jobs by height to cancel them on reorg
+	recent map[uint64]runningRecent
+
 	workersWg sync.WaitGroup
 	metrics   *metrics
+	clock     clock.Clock
+	// observers are notified about the outcome of every sampling attempt
+	observers []observeFn
 	done
 }
 
+// runningRecent is a recent job that is currently being sampled.
+type runningRecent struct {
+	id     int
+	header *header.ExtendedHeader
+	cancel context.CancelFunc
+}
+
+// PauseReason describes why dispatching of jobs is paused.
+type PauseReason string
+
+const (
+	// PauseManual pauses dispatching on request via DASer.Pause.
+	PauseManual PauseReason = "manual"
+	// PauseDiskGuard pauses dispatching while free disk space is low.
+	PauseDiskGuard PauseReason = "disk_guard"
+)
+
+// pauseRequest pauses or resumes dispatching of jobs for the reason.
+type pauseRequest struct {
+	reason PauseReason
+	pause  bool
+}
+
 // result will carry errors to coordinator after worker finishes the job
 type result struct {
 	job
 	failed map[uint64]int
-	err    error
+	// throttled contains heights that were not sampled due to backpressure from availability
+	throttled []uint64
+	err       error
 }
 
 func newSamplingCoordinator(
@@ -47,18 +113,42 @@ func newSamplingCoordinator(
 	sample sampleFn,
 	broadcast shrexsub.BroadcastFn,
 ) *samplingCoordinator {
-	return &samplingCoordinator{
+	inflight := newInflightSamples()
+	sc := &samplingCoordinator{
 		concurrencyLimit: params.ConcurrencyLimit,
-		samplingTimeout:  params.SampleTimeout,
+		dispatchLimit:    params.ConcurrencyLimit,
+		workerSplit:      params.WorkerSplit,
 		getter:           getter,
-		sampleFn:         sample,
+		sampleFn:         inflight.wrap(sample),
+		inflight:         inflight,
 		broadcastFn:      broadcast,
 		state:            newCoordinatorState(params),
 		resultCh:         make(chan result),
 		updHeadCh:        make(chan *header.ExtendedHeader),
 		waitCh:           make(chan *sync.WaitGroup),
+		pauseCh:          make(chan pauseRequest),
+		paused:           make(map[PauseReason]struct{}),
+		pauses:           newPauseFeed(),
+		wakeCh:           make(chan struct{}, 1),
+		recent:           make(map[uint64]runningRecent),
+		clock:            clock.New(),
 		done:             newDone("sampling coordinator"),
 	}
+	if params.AdaptiveBackfill {
+		sc.backfill = newAdaptiveBackfill(params.ConcurrencyLimit)
+	}
+	sc.samplingTimeout.Store(int64(params.SampleTimeout))
+	return sc
+}
+
+// setSamplingTimeout sets the timeout of samples started afterwards.
+func (sc *samplingCoordinator) setSamplingTimeout(timeout time.Duration) {
+	sc.samplingTimeout.Store(int64(timeout))
+}
+
+// sampleTimeout returns the current timeout of a single sample.
+func (sc *samplingCoordinator) sampleTimeout() time.Duration {
+	return time.Duration(sc.samplingTimeout.Load())
 }
 
 func (sc *samplingCoordinator) run(ctx context.Context, cp checkpoint) {
@@ -66,53 +156,264 @@ func (sc *samplingCoordinator) run(ctx context.Context, cp checkpoint) {
 
 	// resume workers
 	for _, wk := range cp.Workers {
-		sc.runWorker(ctx, sc.state.newJob(wk.JobType, wk.From, wk.To))
+		sc.runWorker(ctx, sc.state.resumeJob(wk))
 	}
 
 	for {
-		for !sc.concurrencyLimitReached() {
-			next, found := sc.state.nextJob()
-			if !found {
-				break
-			}
-			sc.runWorker(ctx, next)
-		}
+		sc.dispatch(ctx)
+		paceCh, stopPace := sc.paceTimer()
 
 		select {
 		case head := <-sc.updHeadCh:
 			if sc.state.isNewHead(head.Height()) {
-				if !sc.recentJobsLimitReached() {
+				if sc.workerSplit > 0 {
+					sc.enqueueRecent(head)
+				} else if !sc.isPaused() && !sc.recentJobsLimitReached() {
 					sc.runWorker(ctx, sc.state.recentJob(head))
 				}
 				sc.state.updateHead(head.Height())
 				// run worker without concurrency limit restrictions to reduced delay
 				sc.metrics.observeNewHead(ctx)
+			} else {
+				sc.resampleReorged(ctx, head)
 			}
 		case res := <-sc.resultCh:
+			sc.releaseRecent(res.job)
+			sc.adjustDispatchLimit(res)
+			if sc.backfill != nil {
+				sc.backfill.observe(res)
+			}
 			sc.state.handleResult(res)
 		case wg := <-sc.waitCh:
 			wg.Wait()
+		case req := <-sc.pauseCh:
+			sc.handlePause(ctx, req)
+		case <-sc.wakeCh:
+		case <-paceCh:
 		case <-ctx.Done():
+			stopPace()
 			sc.workersWg.Wait()
 			sc.indicateDone()
 			return
 		}
+		stopPace()
+	}
+}
+
+// dispatch runs workers for paced recent jobs that are due and for available jobs until the
+// concurrency limit is reached, unless dispatching is paused.
+func (sc *samplingCoordinator) dispatch(ctx context.Context) {
+	if sc.isPaused() {
+		return
+	}
+	for _, j := range sc.state.duePaced() {
+		sc.runWorker(ctx, j)
+	}
+	for !sc.concurrencyLimitReached() {
+		next, found := sc.nextJob()
+		if !found {
+			return
+		}
+		sc.runWorker(ctx, next)
 	}
 }
 
-// runWorker runs job in separate worker go-routine
+// nextJob returns the next job to run. If worker split is configured, workers are shared between
+// pending recent jobs and other jobs according to the split, while both have work.
+func (sc *samplingCoordinator) nextJob() (job, bool) {
+	if sc.workerSplit == 0 {
+		if sc.catchUpPaused.Load() || sc.backfillLimitReached() {
+			return job{}, false
+		}
+		return sc.state.nextJob()
+	}
+
+	sc.dropStalePending()
+	recentWork := len(sc.pendingRecent) > 0
+	otherWork := sc.state.hasNextJob() && !sc.catchUpPaused.Load() && !sc.backfillLimitReached()
+	recentRunning := len(sc.recent)
+	otherRunning := len(sc.state.inProgress) - recentRunning
+	recentShare := int(math.Round(sc.workerSplit * float64(sc.dispatchLimit)))
+
+	switch {
+	case recentWork && (recentRunning < recentShare || !otherWork):
+		idx := highestPriority(sc.pendingRecent, sc.priority)
+		h := sc.pendingRecent[idx]
+		sc.pendingRecent = append(sc.pendingRecent[:idx], sc.pendingRecent[idx+1:]...)
+		return sc.state.recentJob(h), true
+	case otherWork && (otherRunning < sc.dispatchLimit-recentShare || !recentWork):
+		return sc.state.nextJob()
+	default:
+		return job{}, false
+	}
+}
+
+// enqueueRecent queues the recent header until there is a worker available for it. Once the queue
+// is full, the oldest header with the lowest priority is dropped and left to be sampled by catchup.
+func (sc *samplingCoordinator) enqueueRecent(h *header.ExtendedHeader) {
+	sc.pendingRecent = append(sc.pendingRecent, h)
+	if len(sc.pendingRecent) > sc.concurrencyLimit {
+		idx := lowestPriority(sc.pendingRecent, sc.priority)
+		log.Debugw("recent jobs queue is full, header will be sampled by catchup",
+			"height", sc.pendingRecent[idx].Height())
+		sc.pendingRecent = append(sc.pendingRecent[:idx], sc.pendingRecent[idx+1:]...)
+	}
+}
+
+// dropStalePending removes pending recent headers that were already submitted to catchup.
+func (sc *samplingCoordinator) dropStalePending() {
+	pending := sc.pendingRecent[:0]
+	for _, h := range sc.pendingRecent {
+		if h.Height() >= sc.state.next {
+			pending = append(pending, h)
+		}
+	}
+	sc.pendingRecent = pending
+}
+
+// paceTimer returns a channel that fires once the earliest paced recent job is due, together with
+// a func to stop the timer. The channel is nil if there are no paced jobs or dispatching is paused.
+func (sc *samplingCoordinator) paceTimer() (<-chan time.Time, func() bool) {
+	if sc.isPaused() || len(sc.state.paced) == 0 {
+		return nil, func() bool { return false }
+	}
+	timer := sc.clock.Timer(sc.clock.Until(sc.state.paced[0].notBefore))
+	return timer.C, timer.Stop
+}
+
+// runWorker runs job in separate worker go-routine. Paced recent jobs are held back until their
+// start time instead, so they don't occupy a worker while waiting.
 func (sc *samplingCoordinator) runWorker(ctx context.Context, j job) {
-	w := newWorker(j, sc.getter, sc.sampleFn, sc.broadcastFn, sc.metrics)
+	if sc.clock.Now().Before(j.notBefore) {
+		sc.state.paced = append(sc.state.paced, j)
+		return
+	}
+	if j.jobType == recentJob {
+		// recent jobs could be canceled individually if the header gets reorged
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		sc.recent[j.from] = runningRecent{id: j.id, header: j.header, cancel: cancel}
+	}
+
+	w := newWorker(j, sc.getter, sc.sampleFn, sc.broadcastFn, sc.metrics, sc.observe, sc.clock)
+	if j.jobType == catchupJob {
+		w.skip = sc.skipCatchup
+	}
 	sc.state.putInProgress(j.id, w.getState)
 
 	// launch worker go-routine
 	sc.workersWg.Add(1)
 	go func() {
 		defer sc.workersWg.Done()
-		w.run(ctx, sc.samplingTimeout, sc.resultCh)
+		w.run(ctx, sc.sampleTimeout, sc.resultCh)
 	}()
 }
 
+// resampleReorged cancels a running recent job if the given header has the same height, but
+// different data root, and starts sampling the given header instead.
+func (sc *samplingCoordinator) resampleReorged(ctx context.Context, h *header.ExtendedHeader) {
+	for i, pending := range sc.pendingRecent {
+		if pending.Height() == h.Height() {
+			// not sampled yet, so just replace the header
+			sc.pendingRecent[i] = h
+			return
+		}
+	}
+	for i, paced := range sc.state.paced {
+		if paced.from == h.Height() {
+			sc.state.paced[i].header = h
+			return
+		}
+	}
+
+	running, ok := sc.recent[h.Height()]
+	if !ok || bytes.Equal(running.header.DataHash, h.DataHash) {
+		return
+	}
+
+	log.Warnw("received header with different data root for height being sampled, resampling",
+		"height", h.Height(),
+		"old data root", running.header.DataHash.String(),
+		"new data root", h.DataHash.String(),
+	)
+	running.cancel()
+	delete(sc.recent, h.Height())
+	sc.state.dropInProgress(running.id)
+
+	sc.runWorker(ctx, sc.state.recentJob(h))
+	sc.metrics.observeReorgResampled(ctx)
+}
+
+// releaseRecent cleans up tracking of the recent job once it is finished.
+func (sc *samplingCoordinator) releaseRecent(j job) {
+	if j.jobType != recentJob {
+		return
+	}
+	if running, ok := sc.recent[j.from]; ok && running.id == j.id {
+		running.cancel()
+		delete(sc.recent, j.from)
+	}
+}
+
+// pause pauses or resumes dispatching of new jobs for the reason. Running jobs are not
+// interrupted. Dispatching resumes once all reasons are resumed.
+func (sc *samplingCoordinator) pause(ctx context.Context, reason PauseReason, pause bool) {
+	select {
+	case sc.pauseCh <- pauseRequest{reason: reason, pause: pause}:
+	case <-ctx.Done():
+	}
+}
+
+func (sc *samplingCoordinator) handlePause(ctx context.Context, req pauseRequest) {
+	_, paused := sc.paused[req.reason]
+	switch {
+	case req.pause && !paused:
+		log.Warnw("pausing sampling", "reason", req.reason)
+		sc.paused[req.reason] = struct{}{}
+		sc.metrics.observePaused(ctx, req.reason)
+		sc.pauses.observe(req.reason, true, sc.clock.Now())
+	case !req.pause && paused:
+		log.Infow("resuming sampling", "reason", req.reason)
+		delete(sc.paused, req.reason)
+		sc.pauses.observe(req.reason, false, sc.clock.Now())
+	}
+}
+
+// pauseCatchUp pauses or resumes dispatching of catchup and retry jobs. Recent jobs are still
+// dispatched and running jobs are not interrupted.
+func (sc *samplingCoordinator) pauseCatchUp(pause bool) {
+	if sc.catchUpPaused.Swap(pause) == pause {
+		return
+	}
+	if pause {
+		log.Warn("pausing catchup, only recent headers are sampled")
+		return
+	}
+	log.Info("resuming catchup")
+	select {
+	case sc.wakeCh <- struct{}{}:
+	default:
+		// the coordinator is woken up already
+	}
+}
+
+func (sc *samplingCoordinator) isPaused() bool {
+	return len(sc.paused) > 0
+}
+
+// setClock sets the clock used by the coordinator, its state and workers.
+func (sc *samplingCoordinator) setClock(clk clock.Clock) {
+	sc.clock = clk
+	sc.state.clock = clk
+}
+
+// observe notifies all observers about the outcome of a sampling attempt.
+func (sc *samplingCoordinator) observe(o sampleOutcome) {
+	for _, observe := range sc.observers {
+		observe(o)
+	}
+}
+
 // listen notifies the coordinator about a new network head received via subscription.
 func (sc *samplingCoordinator) listen(ctx context.Context, h *header.ExtendedHeader) {
 	select {
@@ -129,6 +430,9 @@ func (sc *samplingCoordinator) stats(ctx context.Context) (SamplingStats, error)
 
 	select {
 	case sc.waitCh <- &wg:
+	case <-sc.finished:
+		// coordinator and its workers are stopped, e.g. sampling was halted, so state is no longer
+		// modified
 	case <-ctx.Done():
 		return SamplingStats{}, ctx.Err()
 	}
@@ -136,17 +440,83 @@ func (sc *samplingCoordinator) stats(ctx context.Context) (SamplingStats, error)
 	return sc.state.unsafeStats(), nil
 }
 
+// atHead reports whether every height up to the network head is sampled and no recent headers are
+// pending or being sampled. The state is read exclusively, so the head can't advance in between.
+func (sc *samplingCoordinator) atHead(ctx context.Context) (bool, error) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	defer wg.Done()
+
+	select {
+	case sc.waitCh <- &wg:
+	case <-sc.finished:
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+
+	stats := sc.state.unsafeStats()
+	cp := sc.state.checkpoint(stats)
+	return stats.NetworkHead > 0 && prevHeight(cp.SampleFrom) >= stats.NetworkHead &&
+		len(sc.pendingRecent) == 0 && len(sc.state.paced) == 0 && len(sc.recent) == 0, nil
+}
+
+// retryFailed makes all failed heights ready for retry immediately. It returns the amount of
+// attempts made so far for each of them.
+func (sc *samplingCoordinator) retryFailed(ctx context.Context) (map[uint64]int, error) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	// failed heights are dispatched for retry once the coordinator is released
+	defer wg.Done()
+
+	select {
+	case sc.waitCh <- &wg:
+	case <-sc.finished:
+		return nil, errSamplingStopped
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return sc.state.retryAllFailed(), nil
+}
+
 func (sc *samplingCoordinator) getCheckpoint(ctx context.Context) (checkpoint, error) {
 	stats, err := sc.stats(ctx)
 	if err != nil {
 		return checkpoint{}, err
 	}
-	return newCheckpoint(stats), nil
+	return sc.state.checkpoint(stats), nil
 }
 
 // concurrencyLimitReached indicates whether concurrencyLimit has been reached
 func (sc *samplingCoordinator) concurrencyLimitReached() bool {
-	return len(sc.state.inProgress) >= sc.concurrencyLimit
+	return len(sc.state.inProgress) >= sc.dispatchLimit
+}
+
+// adjustDispatchLimit halves the amount of parallel workers once availability signals backpressure
+// and restores it by one worker with every result without backpressure.
+func (sc *samplingCoordinator) adjustDispatchLimit(res result) {
+	if len(res.throttled) > 0 {
+		prev := sc.dispatchLimit
+		sc.dispatchLimit = max(1, sc.dispatchLimit/2)
+		log.Warnw("availability signaled backpressure, reducing amount of parallel workers",
+			"limit", sc.dispatchLimit)
+		if prev == sc.concurrencyLimit && sc.dispatchLimit < prev {
+			sc.pauses.observeThrottled(true, sc.clock.Now())
+		}
+		return
+	}
+	if sc.dispatchLimit < sc.concurrencyLimit {
+		sc.dispatchLimit++
+		if sc.dispatchLimit == sc.concurrencyLimit {
+			sc.pauses.observeThrottled(false, sc.clock.Now())
+		}
+	}
+}
+
+// backfillLimitReached indicates whether the limit of adaptive backfill has been reached by running
+// catchup and retry jobs.
+func (sc *samplingCoordinator) backfillLimitReached() bool {
+	return sc.backfill != nil && len(sc.state.inProgress)-len(sc.recent) >= sc.backfill.limit
 }
 
 // recentJobsLimitReached indicates whether concurrency limit for recent jobs has been reached
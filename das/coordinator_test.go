@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -299,6 +300,400 @@ func TestCoordinator(t *testing.T) {
 		st := coordinator.state.unsafeStats()
 		require.Equal(t, ch, newCheckpoint(st))
 	})
+
+	t.Run("failed should be parked after max retries", func(t *testing.T) {
+		const maxRetries, brokenHeight = 2, uint64(3)
+		testParams := defaultTestParams()
+		testParams.networkHead = 5
+		testParams.dasParams.MaxRetries = maxRetries
+		ctx, cancel := context.WithTimeout(context.Background(), testParams.timeoutDelay)
+		defer cancel()
+
+		getter := &countingGetterStub{
+			failingGetterStub: failingGetterStub{failing: map[uint64]bool{brokenHeight: true}},
+			attempts:          make(map[uint64]int),
+		}
+		sampler := newMockSampler(testParams.sampleFrom, testParams.networkHead)
+		coordinator := newSamplingCoordinator(testParams.dasParams, getter, sampler.sample, newBroadcastMock(1))
+		// retry without backoff
+		coordinator.state.retryStrategy = newRetryStrategy(nil)
+		go coordinator.run(ctx, sampler.checkpoint)
+
+		require.Eventually(t, func() bool {
+			st, err := coordinator.stats(ctx)
+			return err == nil && st.Failed[brokenHeight] == maxRetries+1 && len(st.Workers) == 0
+		}, testParams.timeoutDelay, 10*time.Millisecond)
+
+		// the parked height is not retried anymore
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, maxRetries+1, getter.attemptsOf(brokenHeight))
+		st, err := coordinator.stats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, map[uint64]int{brokenHeight: maxRetries + 1}, st.Failed)
+
+		cancel()
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), testParams.timeoutDelay)
+		defer stopCancel()
+		assert.NoError(t, coordinator.wait(stopCtx))
+	})
+
+	t.Run("backpressure should reduce dispatch rate", func(t *testing.T) {
+		testParams := defaultTestParams()
+		testParams.dasParams.ConcurrencyLimit = 4
+		testParams.dasParams.SamplingRange = 1
+		testParams.networkHead = 8
+		ctx, cancel := context.WithTimeout(context.Background(), testParams.timeoutDelay)
+		defer cancel()
+
+		var (
+			lk        sync.Mutex
+			inFlight  int
+			calls     []int // amount of samples in flight at each call
+			sampled   = make(map[uint64]int)
+			throttled = make(chan struct{})
+		)
+		sampleFn := func(ctx context.Context, h *header.ExtendedHeader) error {
+			lk.Lock()
+			inFlight++
+			calls = append(calls, inFlight)
+			call := len(calls)
+			sampled[h.Height()]++
+			if call == testParams.dasParams.ConcurrencyLimit {
+				close(throttled)
+			}
+			lk.Unlock()
+			defer func() {
+				lk.Lock()
+				inFlight--
+				lk.Unlock()
+			}()
+
+			if call <= testParams.dasParams.ConcurrencyLimit {
+				// fully loaded workers are throttled by availability
+				<-throttled
+				return fmt.Errorf("overloaded: %w", share.ErrBackpressure)
+			}
+			return nil
+		}
+
+		coordinator := newSamplingCoordinator(testParams.dasParams, getterStub{}, sampleFn, nil)
+		coordinator.state.backpressureDelay = 0
+		pauses := coordinator.pauses.subscribe(ctx)
+		go coordinator.run(ctx, checkpoint{SampleFrom: 1, NetworkHead: testParams.networkHead})
+
+		assert.NoError(t, coordinator.state.waitCatchUp(ctx))
+		assert.Emptyf(t, coordinator.state.failed, "failed list should be empty")
+		// backpressure is reported as throttling rather than a pause
+		ev := <-pauses
+		assert.Equal(t, Throttled, ev.State)
+		assert.Empty(t, ev.Reason)
+
+		cancel()
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), testParams.timeoutDelay)
+		defer stopCancel()
+		assert.NoError(t, coordinator.wait(stopCtx))
+
+		lk.Lock()
+		defer lk.Unlock()
+		// the first sample after backpressure should be the only one in flight
+		require.Greater(t, len(calls), testParams.dasParams.ConcurrencyLimit)
+		assert.Equal(t, 1, calls[testParams.dasParams.ConcurrencyLimit])
+		// throttled heights are sampled again
+		for h := uint64(1); h <= testParams.networkHead; h++ {
+			assert.NotZero(t, sampled[h], "height %d was not sampled", h)
+		}
+	})
+
+	t.Run("adaptive backfill should slow down on failures and recover", func(t *testing.T) {
+		testParams := defaultTestParams()
+		testParams.dasParams.ConcurrencyLimit = 4
+		testParams.dasParams.SamplingRange = 1
+		testParams.dasParams.AdaptiveBackfill = true
+		ctx, cancel := context.WithTimeout(context.Background(), testParams.timeoutDelay)
+		defer cancel()
+
+		var (
+			lk       sync.Mutex
+			inFlight int
+			calls    []int // amount of samples in flight at each call
+			failing  atomic.Bool
+		)
+		// old heights are not served by anyone at first
+		failing.Store(true)
+		sampleFn := func(ctx context.Context, h *header.ExtendedHeader) error {
+			lk.Lock()
+			inFlight++
+			calls = append(calls, inFlight)
+			lk.Unlock()
+			defer func() {
+				lk.Lock()
+				inFlight--
+				lk.Unlock()
+			}()
+
+			time.Sleep(5 * time.Millisecond)
+			if failing.Load() {
+				return errors.New("no providers")
+			}
+			return nil
+		}
+		callsSince := func(from int) []int {
+			lk.Lock()
+			defer lk.Unlock()
+			return append([]int(nil), calls[min(from, len(calls)):]...)
+		}
+
+		coordinator := newSamplingCoordinator(testParams.dasParams, getterStub{}, sampleFn, nil)
+		go coordinator.run(ctx, checkpoint{SampleFrom: 1, NetworkHead: testParams.networkHead})
+
+		// once failures pile up, a single worker is left for old heights
+		require.Eventually(t, func() bool { return len(callsSince(0)) >= 20 }, testParams.timeoutDelay, time.Millisecond)
+		mark := len(callsSince(0))
+		require.Eventually(t, func() bool { return len(callsSince(mark)) >= 10 }, testParams.timeoutDelay, time.Millisecond)
+		for _, n := range callsSince(mark) {
+			assert.Equal(t, 1, n)
+		}
+
+		// workers are restored once sampling succeeds again
+		failing.Store(false)
+		mark = len(callsSince(0))
+		require.Eventually(t, func() bool {
+			for _, n := range callsSince(mark) {
+				if n == testParams.dasParams.ConcurrencyLimit {
+					return true
+				}
+			}
+			return false
+		}, testParams.timeoutDelay, time.Millisecond)
+
+		cancel()
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), testParams.timeoutDelay)
+		defer stopCancel()
+		assert.NoError(t, coordinator.wait(stopCtx))
+	})
+
+	t.Run("workers should be split between recent and catchup", func(t *testing.T) {
+		testParams := defaultTestParams()
+		testParams.dasParams.ConcurrencyLimit = 10
+		testParams.dasParams.SamplingRange = 1
+		testParams.dasParams.WorkerSplit = 0.7
+
+		// count running workers by job type
+		runningByType := func(coordinator *samplingCoordinator) map[SampleSource]int {
+			running := make(map[SampleSource]int)
+			for _, getState := range coordinator.state.inProgress {
+				running[getState().jobType]++
+			}
+			return running
+		}
+		block := func(ctx context.Context, h *header.ExtendedHeader) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		newHeaders := func(from, to uint64) []*header.ExtendedHeader {
+			headers := make([]*header.ExtendedHeader, 0, to-from+1)
+			for h := from; h <= to; h++ {
+				headers = append(headers, &header.ExtendedHeader{
+					Commit:    &types.Commit{},
+					RawHeader: header.RawHeader{Height: int64(h)},
+					DAH:       &share.Root{RowRoots: make([][]byte, 0)},
+				})
+			}
+			return headers
+		}
+
+		tests := []struct {
+			name       string
+			cp         checkpoint
+			recent     []*header.ExtendedHeader
+			expRecent  int
+			expCatchup int
+		}{
+			{
+				name:       "both have work",
+				cp:         checkpoint{SampleFrom: 1, NetworkHead: 100},
+				recent:     newHeaders(101, 120),
+				expRecent:  7,
+				expCatchup: 3,
+			},
+			{
+				name:       "only recent has work",
+				cp:         checkpoint{SampleFrom: 101, NetworkHead: 100},
+				recent:     newHeaders(101, 120),
+				expRecent:  10,
+				expCatchup: 0,
+			},
+			{
+				name:       "only catchup has work",
+				cp:         checkpoint{SampleFrom: 1, NetworkHead: 100},
+				expRecent:  0,
+				expCatchup: 10,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctx, cancel := context.WithCancel(context.Background())
+				coordinator := newSamplingCoordinator(testParams.dasParams, getterStub{}, block, newBroadcastMock(100))
+				coordinator.state.resumeFromCheckpoint(tt.cp)
+				for _, h := range tt.recent {
+					coordinator.enqueueRecent(h)
+				}
+
+				coordinator.dispatch(ctx)
+				running := runningByType(coordinator)
+				assert.Equal(t, tt.expRecent, running[recentJob])
+				assert.Equal(t, tt.expCatchup, running[catchupJob])
+
+				cancel()
+				coordinator.workersWg.Wait()
+			})
+		}
+	})
+
+	t.Run("reorged recent header should be resampled", func(t *testing.T) {
+		testParams := defaultTestParams()
+		ctx, cancel := context.WithTimeout(context.Background(), testParams.timeoutDelay)
+		defer cancel()
+
+		height := uint64(11)
+		stale := &header.ExtendedHeader{
+			Commit:    &types.Commit{},
+			RawHeader: header.RawHeader{Height: int64(height), DataHash: []byte{1}},
+			DAH:       &share.Root{RowRoots: make([][]byte, 0)},
+		}
+		reorged := &header.ExtendedHeader{
+			Commit:    &types.Commit{},
+			RawHeader: header.RawHeader{Height: int64(height), DataHash: []byte{2}},
+			DAH:       &share.Root{RowRoots: make([][]byte, 0)},
+		}
+
+		staleStarted, staleCanceled := make(chan struct{}), make(chan struct{})
+		reorgedSampled := make(chan struct{})
+		sampleFn := func(ctx context.Context, h *header.ExtendedHeader) error {
+			if h == stale {
+				close(staleStarted)
+				<-ctx.Done()
+				close(staleCanceled)
+				return ctx.Err()
+			}
+			close(reorgedSampled)
+			return nil
+		}
+
+		coordinator := newSamplingCoordinator(testParams.dasParams, getterStub{}, sampleFn, newBroadcastMock(1))
+		go coordinator.run(ctx, checkpoint{SampleFrom: height, NetworkHead: height - 1})
+
+		coordinator.listen(ctx, stale)
+		select {
+		case <-staleStarted:
+		case <-ctx.Done():
+			t.Fatal("stale header was not sampled")
+		}
+
+		coordinator.listen(ctx, reorged)
+		for _, ch := range []chan struct{}{staleCanceled, reorgedSampled} {
+			select {
+			case <-ch:
+			case <-ctx.Done():
+				t.Fatal("reorged header was not resampled")
+			}
+		}
+
+		assert.NoError(t, coordinator.state.waitCatchUp(ctx))
+		assert.Emptyf(t, coordinator.state.failed, "failed list should be empty")
+
+		cancel()
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), testParams.timeoutDelay)
+		defer stopCancel()
+		assert.NoError(t, coordinator.wait(stopCtx))
+		assert.Equal(t, height+1, coordinator.state.next)
+	})
+
+	t.Run("height dispatched by recent and catchup is sampled once", func(t *testing.T) {
+		testParams := defaultTestParams()
+		testParams.networkHead = 5
+
+		ctx, cancel := context.WithTimeout(context.Background(), testParams.timeoutDelay)
+
+		sampler := newMockSampler(testParams.sampleFrom, testParams.networkHead)
+		// keep the recent job sampling until catchup reaches its height
+		discovered := testParams.networkHead + 2
+		lk := newLock(discovered, discovered)
+		coordinator := newSamplingCoordinator(testParams.dasParams, getterStub{}, lk.middleWare(sampler.sample),
+			newBroadcastMock(1))
+		var outcomes atomic.Int32
+		coordinator.observers = append(coordinator.observers, func(o sampleOutcome) {
+			if o.height == discovered {
+				outcomes.Add(1)
+			}
+		})
+		go coordinator.run(ctx, sampler.checkpoint)
+
+		// catchup of heights up to the discovered one is dispatched while its recent job is running
+		sampler.discover(ctx, discovered, coordinator.listen)
+		require.Eventually(t, func() bool {
+			return coordinator.inflight.waiting(discovered) == 1
+		}, testParams.timeoutDelay, time.Millisecond)
+		lk.release(discovered)
+
+		assert.NoError(t, sampler.finished(ctx), "not all headers were sampled")
+		assert.NoError(t, coordinator.state.waitCatchUp(ctx))
+		assert.Emptyf(t, coordinator.state.failed, "failed list should be empty")
+		sampler.lock.Lock()
+		assert.Equal(t, 1, sampler.done[discovered])
+		sampler.lock.Unlock()
+		assert.EqualValues(t, 1, outcomes.Load(), "deduplicated sample should be reported once")
+
+		cancel()
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), testParams.timeoutDelay)
+		defer stopCancel()
+		assert.NoError(t, coordinator.wait(stopCtx))
+	})
+
+	t.Run("catchup is dispatched in chunks", func(t *testing.T) {
+		testParams := defaultTestParams()
+		testParams.networkHead = 2000
+		testParams.dasParams.SamplingRange = 50
+		testParams.dasParams.ConcurrencyLimit = 8
+		testParams.dasParams.CatchupChunkSize = 120
+
+		ctx, cancel := context.WithTimeout(context.Background(), testParams.timeoutDelay)
+		sampler := newMockSampler(testParams.sampleFrom, testParams.networkHead)
+		coordinator := newSamplingCoordinator(testParams.dasParams, getterStub{}, sampler.sample, nil)
+		go coordinator.run(ctx, sampler.checkpoint)
+
+		// the amount of catchup heights in progress is read while the coordinator is paused
+		queued := func() uint64 {
+			var wg sync.WaitGroup
+			wg.Add(1)
+			defer wg.Done()
+			select {
+			case coordinator.waitCh <- &wg:
+			case <-ctx.Done():
+				t.Fatal(ctx.Err())
+			}
+			return coordinator.state.catchupQueued
+		}
+	loop:
+		for {
+			select {
+			case <-sampler.finishedCh:
+				break loop
+			default:
+			}
+			require.LessOrEqual(t, queued(), testParams.dasParams.CatchupChunkSize)
+		}
+
+		assert.NoError(t, sampler.finished(ctx), "not all headers were sampled")
+		assert.NoError(t, coordinator.state.waitCatchUp(ctx))
+		cancel()
+		stopCtx, cancel := context.WithTimeout(context.Background(), testParams.timeoutDelay)
+		defer cancel()
+		assert.NoError(t, coordinator.wait(stopCtx))
+		assert.Equal(t, sampler.finalState(), newCheckpoint(coordinator.state.unsafeStats()))
+		assert.Zero(t, coordinator.state.catchupQueued)
+	})
 }
 
 func BenchmarkCoordinator(b *testing.B) {
@@ -621,6 +1016,26 @@ func defaultTestParams() testParams {
 	}
 }
 
+// countingGetterStub counts attempts to get headers by height.
+type countingGetterStub struct {
+	failingGetterStub
+	lock     sync.Mutex
+	attempts map[uint64]int
+}
+
+func (m *countingGetterStub) GetByHeight(ctx context.Context, height uint64) (*header.ExtendedHeader, error) {
+	m.lock.Lock()
+	m.attempts[height]++
+	m.lock.Unlock()
+	return m.failingGetterStub.GetByHeight(ctx, height)
+}
+
+func (m *countingGetterStub) attemptsOf(height uint64) int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.attempts[height]
+}
+
 func newBroadcastMock(callLimit int) shrexsub.BroadcastFn {
 	var m sync.Mutex
 	return func(ctx context.Context, hash shrexsub.Notification) error {
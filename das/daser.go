@@ -1,14 +1,21 @@
 package das
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"hash"
+	"sort"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/ipfs/go-datastore"
 	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
 
 	"github.com/celestiaorg/go-fraud"
 	libhead "github.com/celestiaorg/go-header"
@@ -16,11 +23,29 @@ import (
 	"github.com/celestiaorg/celestia-node/header"
 	"github.com/celestiaorg/celestia-node/share"
 	"github.com/celestiaorg/celestia-node/share/eds/byzantine"
+	"github.com/celestiaorg/celestia-node/share/getters"
 	"github.com/celestiaorg/celestia-node/share/p2p/shrexsub"
 )
 
 var log = logging.Logger("das")
 
+// ErrRootMismatch is returned when a sampled header commits to a data root different from the
+// expected one. See WithExpectedRoots.
+var ErrRootMismatch = errors.New("das: data root mismatch")
+
+// ErrFraudDetected is the error sampling is halted with once a bad encoding fraud proof is
+// received.
+var ErrFraudDetected = errors.New("das: bad encoding fraud proof received")
+
+// ErrSelfTest is returned by SelfTest if the DASer is not able to sample.
+var ErrSelfTest = errors.New("das: self test failed")
+
+// errSamplingStopped is returned by operations waiting for sampling, once it is stopped.
+var errSamplingStopped = errors.New("das: sampling stopped")
+
+// ErrNoFraudProof is returned by VerifyFraud if there is no stored fraud proof to verify.
+var ErrNoFraudProof = errors.New("das: no fraud proof")
+
 // DASer continuously validates availability of data committed to headers.
 type DASer struct {
 	params Parameters
@@ -32,11 +57,92 @@ type DASer struct {
 
 	sampler    *samplingCoordinator
 	store      checkpointStore
-	subscriber subscriber
+	subscriber *subscriber
+
+	// namespaces verifies required namespaces of sampled headers, if configured
+	namespaces *namespaceChecker
+	// recentGetter and catchupGetter override the share.Getter of the Availability for samples of
+	// recent and catchup jobs respectively, if configured
+	recentGetter  share.Getter
+	catchupGetter share.Getter
+	// postVerify enables spot checks of squares reported available with postVerifyGetter
+	postVerify       bool
+	postVerifyGetter share.Getter
+	postVerifyHasher func() hash.Hash
+	postVerifier     *postVerifier
+	// failures notifies subscribers about failed sampling attempts
+	failures *failureFeed
+	// samples notifies subscribers about every sampling attempt
+	samples *sampleFeed
+	// equivocations detects headers with different data roots received for the same height
+	equivocations *equivocationDetector
+	// equivocationHaltPolicy decides whether to halt sampling on detected equivocation, if set
+	equivocationHaltPolicy EquivocationHaltPolicy
+	// rates tracks sampling success rates over sliding windows
+	rates *successRates
+	// throughput tracks the moving average of completed samples per second
+	throughput *throughput
+	// latencies keeps durations of successful samples to compute latency stats
+	latencies *latencies
+	// fetched accounts bytes fetched from the network per sampled height
+	fetched *fetchedBytes
+	// providers keeps peers that served shares per sampled height
+	providers *heightProviders
+	// partial keeps rows confirmed for partially available heights
+	partial *partialRows
+	// backlog records the catchup backlog over time
+	backlog *backlogHistory
+	// storage tracks the average size of sampled squares
+	storage *storageEstimator
+	// audit writes sampling verdicts to the audit log, if configured
+	audit *auditLog
+	// publisher publishes sampled heights to the message bus, if configured
+	publisher *samplePublisher
+	// onSampledDetailed is called with the detailed outcome of every sampling attempt, if set
+	onSampledDetailed func(SampleDetail)
+	// recentSampling indicates whether new headers from the subscription are sampled
+	recentSampling bool
+	// priority defines the order received headers are sampled in, if set
+	priority priorityFn
+	// selfTest indicates whether SelfTest is run on start
+	selfTest bool
+	// warmup indicates whether Warmup is run on start
+	warmup bool
+	// diskGuard pauses sampling on low disk space, if configured
+	diskGuard *diskGuard
+	// localStore is checked for squares available locally, so their heights are not sampled, if set
+	localStore LocalStore
+	// rootIndex indexes data roots of sampled heights, if configured
+	rootIndex *rootIndex
+	// headSource reports the network head catchup is bound by on start
+	headSource HeadSource
+	// expectedRoots are known data roots by height sampled headers are verified against
+	expectedRoots map[uint64]share.DataHash
+
+	// clock is used for all time-based logic, so it can be mocked in tests
+	clock clock.Clock
+
+	// befp is the last bad encoding fraud proof created while sampling
+	befp atomic.Pointer[fraud.Proof[*header.ExtendedHeader]]
+	// fraudValidation is the mode received fraud proofs are validated in
+	fraudValidation FraudValidation
+	// fraudHaltPolicy decides whether to halt sampling on a received fraud proof, if set
+	fraudHaltPolicy FraudHaltPolicy
+	// declinedFraud is the last received fraud proof fraudHaltPolicy declined halting on
+	declinedFraud atomic.Pointer[fraud.Proof[*header.ExtendedHeader]]
+
+	// onDemand tracks samples requested on demand
+	onDemand onDemandGroup
+
+	// haltErr is the error sampling was halted with, if any
+	haltErr  atomic.Pointer[error]
+	haltOnce sync.Once
 
 	cancel         context.CancelFunc
 	subscriberDone chan struct{}
 	running        int32
+	// startedAt is the unix nano time the DASer was started at
+	startedAt atomic.Int64
 }
 
 type listenFn func(context.Context, *header.ExtendedHeader)
@@ -59,8 +165,20 @@ func NewDASer(
 		hsub:           hsub,
 		getter:         getter,
 		store:          newCheckpointStore(dstore),
-		subscriber:     newSubscriber(),
+		failures:       newFailureFeed(),
+		samples:        newSampleFeed(),
+		equivocations:  newEquivocationDetector(),
+		rates:          newSuccessRates(),
+		throughput:     newThroughput(),
+		latencies:      newLatencies(),
+		fetched:        newFetchedBytes(),
+		providers:      newHeightProviders(),
+		partial:        newPartialRows(),
+		backlog:        newBacklogHistory(),
+		storage:        &storageEstimator{},
+		recentSampling: true,
 		subscriberDone: make(chan struct{}),
+		clock:          clock.New(),
 	}
 
 	for _, applyOpt := range options {
@@ -72,49 +190,268 @@ func NewDASer(
 		return nil, err
 	}
 
+	if d.store.codec == nil {
+		return nil, errInvalidOptionValue("CheckpointCodec", "nil")
+	}
+
+	if d.namespaces != nil && d.namespaces.getter == nil {
+		return nil, errInvalidOptionValue("RequiredNamespaces getter", "nil")
+	}
+
+	if d.postVerify {
+		if d.postVerifyGetter == nil {
+			return nil, errInvalidOptionValue("PostVerify getter", "nil")
+		}
+		if d.postVerifyHasher == nil {
+			d.postVerifyHasher = share.DefaultNMTHasher
+		}
+		d.postVerifier = newPostVerifier(d.postVerifyGetter, d.postVerifyHasher)
+	}
+
+	if d.clock == nil {
+		return nil, errInvalidOptionValue("Clock", "nil")
+	}
+
+	if d.fraudValidation > FraudValidationNone {
+		return nil, errInvalidOptionValue("FraudValidation", d.fraudValidation.String())
+	}
+
+	if d.publisher != nil && d.publisher.topic == "" {
+		return nil, errInvalidOptionValue("PublishTopic", "empty")
+	}
+
+	if d.headSource == nil {
+		d.headSource = d.getterHead
+	}
+	d.store.clock = d.clock
+	d.store.flushInterval = d.params.CheckpointFlushInterval
+	d.rates.clock = d.clock
+	d.throughput.clock = d.clock
+	d.backlog.clock = d.clock
+	if d.audit != nil {
+		d.audit.clock = d.clock
+	}
+	if d.diskGuard != nil {
+		d.diskGuard.clock = d.clock
+	}
+	if d.publisher != nil {
+		d.publisher.clock = d.clock
+	}
+
+	d.subscriber = newSubscriber(d.params.RecentBuffer, d.priority)
 	d.sampler = newSamplingCoordinator(d.params, getter, d.sample, shrexBroadcast)
+	d.sampler.priority = d.priority
+	if d.localStore != nil {
+		d.sampler.skipCatchup = d.skipStored
+	}
+	d.sampler.setClock(d.clock)
+	d.sampler.observers = append(d.sampler.observers, d.failures.observe, d.samples.observe, d.rates.observe,
+		d.throughput.observe, d.latencies.observe, d.storage.observe, d.observeHeader)
+	if d.audit != nil {
+		d.sampler.observers = append(d.sampler.observers, d.audit.observe)
+	}
+	if d.publisher != nil {
+		d.sampler.observers = append(d.sampler.observers, d.publisher.observe)
+	}
+	if d.onSampledDetailed != nil {
+		d.sampler.observers = append(d.sampler.observers, d.observeDetailed)
+	}
 	return d, nil
 }
 
 // Start initiates subscription for new ExtendedHeaders and spawns a sampling routine.
 func (d *DASer) Start(ctx context.Context) error {
+	return d.start(ctx, d.params.SampleFrom)
+}
+
+// StartFrom starts the DASer like Start, but without previous sampling progress it starts sampling
+// from the given trusted height instead of the configured SampleFrom, never sampling heights below
+// it. This is meant for nodes bootstrapping from a trusted snapshot. The trusted height only
+// initializes the checkpoint and is not kept otherwise, so once progress exists it is resumed as is.
+func (d *DASer) StartFrom(ctx context.Context, trustedHeight uint64) error {
+	if trustedHeight == 0 {
+		return fmt.Errorf("das: trusted height cannot be 0")
+	}
+	if err := validateHeight(trustedHeight); err != nil {
+		return fmt.Errorf("das: trusted height: %w", err)
+	}
+	return d.start(ctx, trustedHeight)
+}
+
+// start loads the checkpoint, initializing it at the given height if there is none, and spawns
+// sampling routines.
+func (d *DASer) start(ctx context.Context, sampleFrom uint64) error {
 	if !atomic.CompareAndSwapInt32(&d.running, 0, 1) {
 		return fmt.Errorf("da: DASer already started")
 	}
+	d.startedAt.Store(d.clock.Now().UnixNano())
 
-	sub, err := d.hsub.Subscribe()
-	if err != nil {
-		return err
+	if d.warmup {
+		// warmup only speeds up the first samples, so sampling is started regardless of its failure
+		if err := d.Warmup(ctx); err != nil {
+			log.Warnw("warming up", "err", err)
+		}
+	}
+	if d.selfTest {
+		if err := d.SelfTest(ctx); err != nil {
+			atomic.StoreInt32(&d.running, 0)
+			return err
+		}
+	}
+
+	var sub libhead.Subscription[*header.ExtendedHeader]
+	if d.recentSampling {
+		var err error
+		sub, err = d.hsub.Subscribe()
+		if err != nil {
+			return err
+		}
 	}
 
 	// load latest DASed checkpoint
 	cp, err := d.store.load(ctx)
 	if err != nil {
-		log.Warnw("checkpoint not found, initializing with height 1")
+		log.Warnw("checkpoint not found, initializing", "height", sampleFrom)
 
 		cp = checkpoint{
-			SampleFrom:  d.params.SampleFrom,
-			NetworkHead: d.params.SampleFrom,
+			SampleFrom:  sampleFrom,
+			NetworkHead: sampleFrom,
 		}
 
 		// attempt to get head info. No need to handle error, later DASer
 		// will be able to find new head from subscriber after it is started
-		if h, err := d.getter.Head(ctx); err == nil {
-			cp.NetworkHead = h.Height()
+		if head, ok := d.networkHead(ctx, cp); ok {
+			cp.NetworkHead = head
+		}
+	}
+
+	if !d.recentSampling {
+		// without subscription the network head is only known from the head source, so catch up to
+		// the head captured at start
+		if head, ok := d.networkHead(ctx, cp); ok && head > cp.NetworkHead {
+			cp.NetworkHead = head
 		}
 	}
 	log.Info("starting DASer from checkpoint: ", cp.String())
 
 	runCtx, cancel := context.WithCancel(context.Background())
 	d.cancel = cancel
+	if d.HaltErr() != nil {
+		// halted before sampling was started
+		cancel()
+	}
+	d.onDemand.open(runCtx)
 
+	if d.audit != nil {
+		go d.audit.run()
+	}
+	if d.publisher != nil {
+		d.publisher.start()
+	}
+	if d.diskGuard != nil {
+		// check before the sampler starts, so no jobs are dispatched on low disk space
+		low := d.diskGuard.isLow(false)
+		if low {
+			d.sampler.handlePause(ctx, pauseRequest{reason: PauseDiskGuard, pause: true})
+		}
+		go d.diskGuard.run(runCtx, low, d.sampler.pause)
+	}
 	go d.sampler.run(runCtx, cp)
-	go d.subscriber.run(runCtx, sub, d.sampler.listen)
+	if d.params.BacklogInterval > 0 {
+		go d.backlog.run(runCtx, d.params.BacklogInterval, d.sampler.stats)
+	}
+	if fsub, ok := d.bcast.(fraud.Subscriber[*header.ExtendedHeader]); ok {
+		fraudSub, err := fsub.Subscribe(byzantine.BadEncoding)
+		if err != nil {
+			log.Errorw("subscribing to fraud proofs", "err", err)
+		} else {
+			go d.awaitFraud(runCtx, fraudSub)
+		}
+	}
+	if d.recentSampling {
+		go d.subscriber.run(runCtx, sub, d.listen)
+	} else {
+		log.Info("recent sampling is disabled, DASer will only catch up to the network head: ", cp.NetworkHead)
+		d.subscriber.indicateDone()
+	}
 	go d.store.runBackgroundStore(runCtx, d.params.BackgroundStoreInterval, d.sampler.getCheckpoint)
 
 	return nil
 }
 
+// Warmup prepares the Availability for sampling, if it implements share.Warmer, e.g. by connecting
+// to known providers, so the first samples don't pay for connection setup.
+// It does not sample and does not affect the sampling progress.
+func (d *DASer) Warmup(ctx context.Context) error {
+	warmer, ok := d.da.(share.Warmer)
+	if !ok {
+		return nil
+	}
+	start := d.clock.Now()
+	if err := warmer.Warmup(ctx); err != nil {
+		return err
+	}
+	log.Infow("warmed up", "took", d.clock.Since(start))
+	return nil
+}
+
+// SelfTest verifies that the getter and the availability are functioning by validating
+// availability of data committed to the most recent header known to the getter. It samples
+// regardless of whether the DASer is running and does not affect the sampling progress.
+func (d *DASer) SelfTest(ctx context.Context) error {
+	h, err := d.getter.Head(ctx)
+	switch {
+	case err != nil:
+		return fmt.Errorf("%w: getting head: %w", ErrSelfTest, err)
+	case h == nil:
+		return fmt.Errorf("%w: getting head: %w", ErrSelfTest, ErrHeaderNotFound)
+	case h.DAH == nil:
+		return fmt.Errorf("%w: height %d: %w", ErrSelfTest, h.Height(), errNilDAH)
+	}
+
+	ctx, cancel := d.clock.WithTimeout(ctx, d.sampler.sampleTimeout())
+	defer cancel()
+	if err = d.sharesAvailable(ctx, h); err != nil {
+		return fmt.Errorf("%w: sampling height %d: %w", ErrSelfTest, h.Height(), err)
+	}
+	log.Infow("self test passed", "height", h.Height())
+	return nil
+}
+
+// networkHead returns the height of the network head reported by the head source. A head lower
+// than the last sampled height of the checkpoint means the source has rolled back, so it is ignored
+// rather than regressing sampling progress.
+func (d *DASer) networkHead(ctx context.Context, cp checkpoint) (uint64, bool) {
+	head, err := d.headSource(ctx)
+	if err == nil {
+		err = validateHeight(head)
+	}
+	if err != nil {
+		log.Debugw("getting network head", "err", err)
+		return 0, false
+	}
+	// everything below SampleFrom was sampled, so the head can only be lower once it is caught up
+	if head+1 < cp.SampleFrom {
+		log.Warnw("network head is lower than the checkpoint, ignoring",
+			"head", head, "sample_from", cp.SampleFrom)
+		d.sampler.metrics.observeHeadRollback(ctx)
+		return 0, false
+	}
+	return head, true
+}
+
+// getterHead returns the height of the getter's head. It is the default head source.
+func (d *DASer) getterHead(ctx context.Context) (uint64, error) {
+	h, err := d.getter.Head(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if h == nil {
+		return 0, ErrHeaderNotFound
+	}
+	return h.Height(), nil
+}
+
 // Stop stops sampling.
 func (d *DASer) Stop(ctx context.Context) error {
 	if !atomic.CompareAndSwapInt32(&d.running, 1, 0) {
@@ -135,16 +472,43 @@ func (d *DASer) Stop(ctx context.Context) error {
 	if err = d.sampler.wait(ctx); err != nil {
 		return fmt.Errorf("DASer force quit: %w", err)
 	}
+	if err = d.onDemand.close(ctx); err != nil {
+		return fmt.Errorf("DASer force quit: %w", err)
+	}
+	// workers and on demand samples are stopped, so no more outcomes could be reported
+	d.failures.close()
+	d.samples.close()
+	d.equivocations.close()
+	d.sampler.pauses.close()
+	if d.audit != nil {
+		d.audit.close()
+	}
+	if d.publisher != nil {
+		if err = d.publisher.close(ctx); err != nil {
+			return fmt.Errorf("DASer force quit: %w", err)
+		}
+	}
+
+	if err = d.subscriber.wait(ctx); err != nil {
+		return fmt.Errorf("DASer force quit: %w", err)
+	}
 
 	// save updated checkpoint after sampler and all workers are shut down
-	if err = d.store.store(ctx, newCheckpoint(d.sampler.state.unsafeStats())); err != nil {
+	cp = d.sampler.state.checkpoint(d.sampler.state.unsafeStats())
+	if cp.NetworkHead < d.subscriber.undelivered {
+		// headers received, but not yet passed to the sampler, are sampled by catchup after restart
+		log.Infow("extending checkpoint with headers received, but not sampled",
+			"from", cp.NetworkHead, "to", d.subscriber.undelivered)
+		cp.NetworkHead = d.subscriber.undelivered
+	}
+	if err = d.store.store(ctx, cp); err != nil {
 		log.Errorw("storing checkpoint to disk", "err", err)
 	}
 
 	if err = d.store.wait(ctx); err != nil {
 		return fmt.Errorf("DASer force quit with err: %w", err)
 	}
-	return d.subscriber.wait(ctx)
+	return nil
 }
 
 func (d *DASer) sample(ctx context.Context, h *header.ExtendedHeader) error {
@@ -154,35 +518,883 @@ func (d *DASer) sample(ctx context.Context, h *header.ExtendedHeader) error {
 		return nil
 	}
 
-	err := d.da.SharesAvailable(ctx, h)
+	// empty squares, e.g. at genesis, are trivially available
+	if isEmptySquare(h.DAH) {
+		return d.verifySampled(ctx, h)
+	}
+
+	var (
+		fetched   atomic.Uint64
+		providers getters.Providers
+	)
+	ctx = d.withShareGetter(ctx)
+	err := d.sharesAvailable(getters.WithProviders(getters.WithFetchedBytes(ctx, &fetched), &providers), h)
+	d.fetched.add(h.Height(), fetched.Load())
+	d.providers.add(h.Height(), providers.Peers())
 	if err != nil {
+		var partialErr *share.PartialAvailabilityError
+		if errors.As(err, &partialErr) {
+			d.partial.set(h.Height(), partialErr.ConfirmedRows)
+		}
+
 		var byzantineErr *byzantine.ErrByzantine
 		if errors.As(err, &byzantineErr) {
 			log.Warn("Propagating proof...")
-			sendErr := d.bcast.Broadcast(ctx, byzantine.CreateBadEncodingProof(h.Hash(), h.Height(), byzantineErr))
+			befp := byzantine.CreateBadEncodingProof(h.Hash(), h.Height(), byzantineErr)
+			d.befp.Store(&befp)
+			sendErr := d.bcast.Broadcast(ctx, befp)
 			if sendErr != nil {
 				log.Errorw("fraud proof propagating failed", "err", sendErr)
 			}
 		}
 		return err
 	}
+	d.partial.remove(h.Height())
+
+	if d.postVerifier != nil {
+		if err := d.postVerifier.verify(ctx, h); err != nil {
+			if errors.Is(err, ErrFaultyAvailability) {
+				log.Errorw("availability reported unverified square available", "height", h.Height(), "err", err)
+				d.sampler.metrics.observeFaultyAvailability(ctx)
+			}
+			return err
+		}
+	}
+
+	if d.namespaces != nil {
+		d.namespaces.check(ctx, h)
+	}
+	return d.verifySampled(ctx, h)
+}
+
+// verifySampled verifies the data root of the sampled header against the expected one and indexes
+// it. The root is indexed before the sample succeeds, so every height covered by the checkpoint
+// has its root indexed.
+func (d *DASer) verifySampled(ctx context.Context, h *header.ExtendedHeader) error {
+	if err := d.verifyExpectedRoot(h); err != nil {
+		return err
+	}
+	return d.rootIndex.put(ctx, h)
+}
+
+// skipStored marks the header sampled without sampling it, if its square is stored locally. Squares
+// stored locally were verified when stored, so sampling them again is pointless. It is used by the
+// coordinator for catchup heights only.
+func (d *DASer) skipStored(ctx context.Context, h *header.ExtendedHeader) (bool, error) {
+	if !d.isStoredLocally(ctx, h) {
+		return false, nil
+	}
+	return true, d.verifySampled(ctx, h)
+}
+
+// isStoredLocally reports whether the square of the header is available in the local store.
+func (d *DASer) isStoredLocally(ctx context.Context, h *header.ExtendedHeader) bool {
+	ok, err := d.localStore.Has(ctx, share.DataHash(h.DataHash))
+	if err != nil {
+		log.Debugw("checking local store", "height", h.Height(), "err", err)
+		return false
+	}
+	if ok {
+		log.Debugw("skipping sampling of locally stored square", "height", h.Height())
+	}
+	return ok
+}
+
+// verifyExpectedRoot halts sampling if the header commits to a data root different from the
+// expected one for its height.
+func (d *DASer) verifyExpectedRoot(h *header.ExtendedHeader) error {
+	expected, ok := d.expectedRoots[h.Height()]
+	if !ok || bytes.Equal(expected, h.DataHash) {
+		return nil
+	}
+
+	err := fmt.Errorf("%w: height %d: expected %s, got %s", ErrRootMismatch, h.Height(), expected, h.DataHash)
+	d.halt(err)
+	return err
+}
+
+// halt stops sampling on the unrecoverable error. The DASer must still be stopped with Stop. If
+// sampling is not started yet, e.g. when halted by samples of SelfTest, it is stopped once started.
+func (d *DASer) halt(err error) {
+	d.haltOnce.Do(func() {
+		log.Errorw("halting DASer", "err", err)
+		d.haltErr.Store(&err)
+		if d.cancel != nil {
+			d.cancel()
+		}
+	})
+}
+
+// listen checks the header received from the subscription for equivocation and passes it on to
+// the sampler.
+func (d *DASer) listen(ctx context.Context, h *header.ExtendedHeader) {
+	d.detectEquivocation(ctx, h, sourceSubscription)
+	d.sampler.listen(ctx, h)
+}
+
+// observeHeader checks headers retrieved from the getter for sampling for equivocation. Headers of
+// recent jobs come from the subscription and are checked once received.
+func (d *DASer) observeHeader(o sampleOutcome) {
+	if o.header == nil || o.source == recentJob {
+		return
+	}
+	d.detectEquivocation(context.Background(), o.header, sourceGetter)
+}
+
+// detectEquivocation reports the header if its data root differs from the one of the header seen
+// before for the same height, and halts sampling if the EquivocationHaltPolicy decides so.
+func (d *DASer) detectEquivocation(ctx context.Context, h *header.ExtendedHeader, source headerSource) {
+	ev, ok := d.equivocations.check(h, source)
+	if !ok {
+		return
+	}
+	log.Errorw("received equivocating header",
+		"height", ev.Height,
+		"seen data root", ev.SeenRoot.String(),
+		"received data root", ev.ReceivedRoot.String(),
+		"source", ev.Source,
+	)
+	d.sampler.metrics.observeEquivocation(ctx, source)
+	if d.equivocationHaltPolicy != nil && d.equivocationHaltPolicy(ev) {
+		d.halt(fmt.Errorf("%w at height %d", ErrEquivocation, ev.Height))
+	}
+}
+
+// awaitFraud waits for bad encoding fraud proofs and halts sampling once one is received, unless
+// the halt policy declines halting on it.
+func (d *DASer) awaitFraud(ctx context.Context, sub fraud.Subscription[*header.ExtendedHeader]) {
+	defer sub.Cancel()
+
+	for {
+		proof, err := sub.Proof(ctx)
+		if err != nil || proof == nil {
+			return
+		}
+		if d.handleFraud(ctx, proof) {
+			return
+		}
+	}
+}
+
+// handleFraud validates the received fraud proof according to the FraudValidation mode. Strict
+// validation against the header from the getter is given up on after FraudHandleTimeout, so a
+// stuck getter never prevents halting. Then sampling is halted, unless the proof failed validation
+// or the halt policy declines it. It reports whether sampling was halted.
+func (d *DASer) handleFraud(ctx context.Context, proof fraud.Proof[*header.ExtendedHeader]) bool {
+	ctx, cancel := d.clock.WithTimeout(ctx, d.params.FraudHandleTimeout)
+	defer cancel()
+	if err := d.validateFraud(ctx, proof); err != nil {
+		log.Warnw("received fraud proof failed validation, sampling continues",
+			"height", proof.Height(), "type", proof.Type(), "mode", d.fraudValidation.String(), "err", err)
+		return false
+	}
+
+	if d.fraudHaltPolicy != nil && !d.fraudHaltPolicy(proof) {
+		log.Warnw("received fraud proof, but halting was declined by policy, sampling continues",
+			"height", proof.Height(), "type", proof.Type())
+		d.declinedFraud.Store(&proof)
+		return false
+	}
+	d.halt(fmt.Errorf("%w at height %d", ErrFraudDetected, proof.Height()))
+	return true
+}
+
+// validateFraud validates the received fraud proof according to the FraudValidation mode.
+func (d *DASer) validateFraud(ctx context.Context, proof fraud.Proof[*header.ExtendedHeader]) error {
+	switch d.fraudValidation {
+	case FraudValidationNone:
+		return nil
+	case FraudValidationLenient:
+		// only proofs able to validate themselves are checked, e.g. bad encoding fraud proofs
+		if basic, ok := proof.(interface{ ValidateBasic() error }); ok {
+			return basic.ValidateBasic()
+		}
+		return nil
+	default:
+		return d.verifyReceivedFraud(ctx, proof)
+	}
+}
+
+// verifyReceivedFraud verifies the received fraud proof against the local header. The proof is
+// only accepted without verification if getting the header did not finish within
+// FraudHandleTimeout; it is rejected on any other error, e.g. for a height unknown to the getter.
+func (d *DASer) verifyReceivedFraud(ctx context.Context, proof fraud.Proof[*header.ExtendedHeader]) error {
+	h, err := d.getter.GetByHeight(ctx, proof.Height())
+	switch {
+	case err == nil && h == nil:
+		return fmt.Errorf("getting header for fraud proof: %w: height %d", ErrHeaderNotFound, proof.Height())
+	case err == nil:
+		return proof.Validate(h)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		log.Errorw("fraud proof handling timed out, proceeding without verification",
+			"height", proof.Height(), "timeout", d.params.FraudHandleTimeout)
+		return nil
+	default:
+		return fmt.Errorf("getting header for fraud proof at height %d: %w", proof.Height(), err)
+	}
+}
+
+// DeclinedFraudProof returns the last received fraud proof the halt policy declined halting on, or
+// nil if there is none.
+func (d *DASer) DeclinedFraudProof() fraud.Proof[*header.ExtendedHeader] {
+	if proof := d.declinedFraud.Load(); proof != nil {
+		return *proof
+	}
+	return nil
+}
+
+// HaltErr returns the error sampling was halted with, or nil if sampling was not halted.
+func (d *DASer) HaltErr() error {
+	if err := d.haltErr.Load(); err != nil {
+		return *err
+	}
 	return nil
 }
 
+// isEmptySquare indicates whether the root commits to no data: either a square of size 0 or the
+// minimum square of an empty block.
+func isEmptySquare(dah *share.Root) bool {
+	return len(dah.RowRoots) == 0 || share.DataHash(dah.Hash()).IsEmptyRoot()
+}
+
 func (d *DASer) isWithinSamplingWindow(eh *header.ExtendedHeader) bool {
 	// if sampling window is not set, then all headers are within the window
 	if d.params.SamplingWindow == 0 {
 		return true
 	}
-	return time.Since(eh.Time()) <= d.params.SamplingWindow
+	return d.clock.Since(eh.Time()) <= d.params.SamplingWindow
+}
+
+func (d *DASer) publishTopic() string {
+	if d.publisher == nil {
+		return ""
+	}
+	return d.publisher.topic
+}
+
+// Config returns a copy of the effective DASer configuration.
+func (d *DASer) Config() DASConfig {
+	cfg := DASConfig{
+		Parameters:      d.params,
+		RecentSampling:  d.recentSampling,
+		AuditLog:        d.audit != nil,
+		RootIndex:       d.rootIndex != nil,
+		PostVerify:      d.postVerifier != nil,
+		PublishTopic:    d.publishTopic(),
+		CheckpointCodec: fmt.Sprintf("%T", d.store.codec),
+		SelfTest:        d.selfTest,
+		Warmup:          d.warmup,
+	}
+	if d.store.compression != nil {
+		cfg.CheckpointCompression = fmt.Sprintf("%T", d.store.compression)
+	}
+	cfg.SampleTimeout = d.sampler.sampleTimeout()
+	if d.namespaces != nil {
+		cfg.RequiredNamespaces = make([]share.Namespace, len(d.namespaces.namespaces))
+		copy(cfg.RequiredNamespaces, d.namespaces.namespaces)
+	}
+	return cfg
+}
+
+// SetSampleTimeout changes the SampleTimeout at runtime. The new timeout applies to samples started
+// afterwards, while samples in flight keep their deadline. Non-positive timeouts are ignored. It is
+// safe to call concurrently with sampling.
+func (d *DASer) SetSampleTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		log.Warnw("ignoring non-positive sample timeout", "timeout", timeout)
+		return
+	}
+	d.sampler.setSamplingTimeout(timeout)
+	log.Infow("sample timeout changed", "timeout", timeout)
+}
+
+// SampleFrom returns the height sampling resumes from after restart, according to the last
+// persisted checkpoint. It is safe to call concurrently with sampling and returns 0 if no
+// checkpoint was persisted yet.
+func (d *DASer) SampleFrom() uint64 {
+	cp, ok := d.store.loadSnapshot()
+	if !ok {
+		return 0
+	}
+	return cp.SampleFrom
 }
 
 // SamplingStats returns the current statistics over the DA sampling process.
 func (d *DASer) SamplingStats(ctx context.Context) (SamplingStats, error) {
-	return d.sampler.stats(ctx)
+	stats, err := d.sampler.stats(ctx)
+	if err != nil {
+		return stats, err
+	}
+	stats.FetchedBytes, stats.FetchedBytesPerHeight = d.fetched.get()
+	stats.ConfirmedRows = d.partial.get()
+	return stats, nil
+}
+
+// EstimateStorage estimates the storage in bytes required to persist extended squares of heights
+// not yet sampled up to the network head, using the average size of squares sampled so far. It
+// returns false while the DASer is not running or until enough heights are sampled to establish the
+// average.
+func (d *DASer) EstimateStorage() (uint64, bool) {
+	if atomic.LoadInt32(&d.running) == 0 {
+		return 0, false
+	}
+
+	// the coordinator is either running or already stopped, so getting stats never blocks
+	stats, err := d.sampler.stats(context.Background())
+	if err != nil {
+		return 0, false
+	}
+	return d.storage.estimate(heightsBetween(stats.SampledChainHead, stats.NetworkHead))
+}
+
+// ProvidersFor returns the peers that served shares to sample the given height. Providers are
+// only recorded by getters fetching data from remote peers and kept for the 1024 most recently
+// sampled heights.
+func (d *DASer) ProvidersFor(height uint64) []peer.ID {
+	return d.providers.get(height)
+}
+
+// RootAt returns the data root indexed for the sampled height. It requires the index to be
+// configured with WithRootIndex. Heights skipped for being outside the sampling window are not
+// indexed.
+func (d *DASer) RootAt(ctx context.Context, height uint64) (share.DataHash, error) {
+	if d.rootIndex == nil {
+		return nil, errors.New("das: root index is not configured")
+	}
+	return d.rootIndex.get(ctx, height)
+}
+
+// NamespaceAvailability returns availability of each required namespace, keyed by its hex string,
+// for the given sampled height. It returns nil if no required namespaces were checked at the
+// height.
+func (d *DASer) NamespaceAvailability(height uint64) map[string]bool {
+	if d.namespaces == nil {
+		return nil
+	}
+	return d.namespaces.availability(height)
+}
+
+// SubscribeFailures returns a channel that receives an event on each failed sampling attempt.
+// Events are dropped if the subscriber doesn't keep up, so sampling is never stalled. The channel
+// is closed once the given context is done or the DASer is stopped.
+func (d *DASer) SubscribeFailures(ctx context.Context) <-chan FailureEvent {
+	return d.failures.subscribe(ctx)
+}
+
+// SubscribeEquivocations returns a channel that receives an event on each header received with a
+// data root different from the one of the header seen before for the same height, either from the
+// subscription or the getter. Data roots are remembered for the most recent heights only. Events
+// are dropped if the subscriber doesn't keep up. The channel is closed once the given context is
+// done or the DASer is stopped.
+func (d *DASer) SubscribeEquivocations(ctx context.Context) <-chan EquivocationEvent {
+	return d.equivocations.subscribe(ctx)
+}
+
+// SubscribeSamples returns a channel that receives an event on each sampling attempt, including
+// samples requested via SampleRange and Resample. Events are dropped if the subscriber doesn't keep
+// up, so sampling is never stalled. The channel is closed once the given context is done or the
+// DASer is stopped.
+func (d *DASer) SubscribeSamples(ctx context.Context) <-chan SampleEvent {
+	return d.samples.subscribe(ctx)
+}
+
+// SubscribePauses returns a channel that receives an event each time sampling is paused or resumed
+// for a reason: on request via Pause and Resume or by the disk guard on low disk space. Throttled
+// and Unthrottled events are received while the amount of parallel workers is reduced due to
+// backpressure from availability and once it is restored. Events are dropped if the subscriber
+// doesn't keep up. The channel is closed once the given context is done or the DASer is stopped.
+func (d *DASer) SubscribePauses(ctx context.Context) <-chan PauseEvent {
+	return d.sampler.pauses.subscribe(ctx)
+}
+
+// RangeError is returned by SampleRange if sampling of any heights in the range failed. It matches
+// any error the sampling of a height failed with, e.g. share.ErrNotAvailable, via errors.Is.
+type RangeError struct {
+	// Failures is the error sampling failed with by height
+	Failures map[uint64]error
+}
+
+func (e *RangeError) Error() string {
+	errs := make([]string, 0, len(e.Failures))
+	for _, height := range e.heights() {
+		errs = append(errs, fmt.Sprintf("height: %d, err: %v", height, e.Failures[height]))
+	}
+	return strings.Join(errs, "\n")
+}
+
+// Unwrap returns errors of all failed heights in ascending order of heights.
+func (e *RangeError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, height := range e.heights() {
+		errs = append(errs, e.Failures[height])
+	}
+	return errs
+}
+
+func (e *RangeError) heights() []uint64 {
+	heights := make([]uint64, 0, len(e.Failures))
+	for height := range e.Failures {
+		heights = append(heights, height)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	return heights
+}
+
+// SampleRange samples headers in the given inclusive range of heights on demand, regardless of
+// whether they were sampled before. The resulting SampleEvents, success rates and audit records are
+// reported under the given source, e.g. the name of the subsystem requesting the samples, or
+// "manual" if it is empty. The sources of background samples, i.e. "catchup", "recent" and "retry",
+// are reserved. SampleMetadata attached to the context via WithSampleMetadata is propagated to the
+// resulting SampleEvents and audit records. Outcomes of on demand samples do not affect the
+// checkpoint. If sampling of any heights fails, *RangeError is returned.
+func (d *DASer) SampleRange(ctx context.Context, source SampleSource, from, to uint64) error {
+	return d.SampleRangeWithProgress(ctx, source, from, to, nil)
+}
+
+// progressInterval is the minimum interval between reports of SampleRangeWithProgress.
+const progressInterval = 100 * time.Millisecond
+
+// SampleRangeWithProgress samples headers in the given inclusive range like SampleRange, reporting
+// the amount of heights done, regardless of their outcome, out of the total amount of heights in the
+// range to the progress callback, e.g. to render a progress bar. Reports are throttled to one per
+// progressInterval, except for the report of the last height, which is always made. The callback is
+// never called concurrently and must not block.
+func (d *DASer) SampleRangeWithProgress(
+	ctx context.Context,
+	source SampleSource,
+	from, to uint64,
+	progress func(done, total int),
+) error {
+	if atomic.LoadInt32(&d.running) == 0 {
+		return errors.New("das: DASer is not running")
+	}
+	if from == 0 || from > to {
+		return fmt.Errorf("das: invalid range [%d:%d]", from, to)
+	}
+	if err := validateHeight(to); err != nil {
+		return fmt.Errorf("das: invalid range [%d:%d]: %w", from, to, err)
+	}
+
+	source, err := onDemandSource(source)
+	if err != nil {
+		return err
+	}
+	md := sampleMetadataFrom(ctx)
+	total := int(to - from + 1)
+	var (
+		failures   map[uint64]error
+		reportedAt time.Time
+	)
+	for height := from; height <= to; height++ {
+		err := d.sampleOnDemand(ctx, height, source, md)
+		if errors.Is(err, context.Canceled) || errors.Is(err, errSamplingStopped) {
+			return err
+		}
+		if err != nil {
+			if failures == nil {
+				failures = make(map[uint64]error)
+			}
+			failures[height] = err
+		}
+
+		if progress != nil && (height == to || d.clock.Since(reportedAt) >= progressInterval) {
+			progress(int(height-from+1), total)
+			reportedAt = d.clock.Now()
+		}
+	}
+	if failures != nil {
+		return &RangeError{Failures: failures}
+	}
+	return nil
+}
+
+// Resample samples the header at the given height on demand for the source. See SampleRange. If
+// the Availability implements share.Reverifier, availability is verified again even if it was
+// verified before, reusing cached proofs of previous samples where possible.
+func (d *DASer) Resample(ctx context.Context, source SampleSource, height uint64) error {
+	return d.SampleRange(context.WithValue(ctx, reverifyKey{}, true), source, height, height)
+}
+
+type reverifyKey struct{}
+
+// withShareGetter routes requests of the Availability for the sample to the share.Getter configured
+// for the type of the job the sample was dispatched by, if any. Samples requested on demand use
+// the own getter of the Availability.
+func (d *DASer) withShareGetter(ctx context.Context) context.Context {
+	jt, ok := jobTypeFrom(ctx)
+	if !ok {
+		return ctx
+	}
+	switch {
+	case jt == recentJob && d.recentGetter != nil:
+		return getters.WithGetter(ctx, d.recentGetter)
+	case jt != recentJob && d.catchupGetter != nil:
+		return getters.WithGetter(ctx, d.catchupGetter)
+	default:
+		return ctx
+	}
+}
+
+// sharesAvailable validates availability of the header's data. The validation is repeated
+// regardless of previous results if requested by Resample and supported by the Availability.
+func (d *DASer) sharesAvailable(ctx context.Context, h *header.ExtendedHeader) error {
+	if reverifier, ok := d.da.(share.Reverifier); ok {
+		if reverify, _ := ctx.Value(reverifyKey{}).(bool); reverify {
+			return reverifier.ReverifyAvailable(ctx, h)
+		}
+	}
+	return d.da.SharesAvailable(ctx, h)
+}
+
+// sampleOnDemand samples the header at the given height and reports the outcome to observers. It
+// returns errSamplingStopped if the DASer is not running, and the sample is aborted once it stops.
+func (d *DASer) sampleOnDemand(ctx context.Context, height uint64, source SampleSource, md SampleMetadata) error {
+	ctx, release, err := d.onDemand.add(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	start := d.clock.Now()
+	h, err := d.getter.GetByHeight(ctx, height)
+	switch {
+	case err != nil:
+		h = nil
+	case h == nil:
+		err = fmt.Errorf("%w: height %d", ErrHeaderNotFound, height)
+	case h.DAH == nil:
+		err = fmt.Errorf("%w: height %d", errNilDAH, height)
+	default:
+		sampleCtx, cancel := d.clock.WithTimeout(ctx, d.sampler.sampleTimeout())
+		err = classifyAborted(ctx, sampleCtx, d.sample(sampleCtx, h))
+		cancel()
+	}
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	d.sampler.observe(sampleOutcome{
+		height:   height,
+		header:   h,
+		source:   source,
+		attempt:  1,
+		duration: d.clock.Since(start),
+		err:      err,
+		metadata: md,
+	})
+	return err
+}
+
+// SuccessRates returns the share of successful sampling attempts by SampleSource over each of the
+// sliding windows (1m, 5m and 15m). Windows without any attempts are omitted.
+func (d *DASer) SuccessRates() map[SampleSource]map[time.Duration]float64 {
+	return d.rates.get(d.clock.Now())
+}
+
+// SampleExemplars returns the most recent exemplar for each bucket of the sample time histogram,
+// linking the recorded sample times to the traces of the samples. Exemplars are only recorded if
+// both metrics and tracing are enabled. They are exposed as OpenMetrics exemplars of the histogram
+// registered with RegisterExemplars.
+func (d *DASer) SampleExemplars() []Exemplar {
+	if d.sampler.metrics == nil {
+		return nil
+	}
+	return d.sampler.metrics.exemplars.get()
+}
+
+// Throughput returns the moving average of samples completed per second over the last 10 seconds.
+// It is cheap to call frequently.
+func (d *DASer) Throughput() float64 {
+	return d.throughput.get(d.clock.Now())
+}
+
+// LatencyStats returns the mean duration of successful samples together with the median and 99th
+// percentile durations estimated over a random sample of them. It is cheap to call frequently.
+func (d *DASer) LatencyStats() LatencyStats {
+	return d.latencies.get()
+}
+
+// BacklogHistory returns the catchup backlog, i.e. the amount of headers not yet sampled up to
+// the network head, recorded every BacklogInterval, the oldest first. Only the 1440 most recent
+// points are retained.
+func (d *DASer) BacklogHistory() []BacklogPoint {
+	return d.backlog.get()
+}
+
+// Healthy reports whether the DASer keeps up with the network. The DASer is healthy if the amount
+// of headers not yet sampled up to the network head does not exceed the SamplingRange. During the
+// HealthWarmup period after start it is reported as healthy regardless of the backlog.
+func (d *DASer) Healthy(ctx context.Context) bool {
+	if atomic.LoadInt32(&d.running) == 0 || d.HaltErr() != nil {
+		return false
+	}
+
+	if d.clock.Since(time.Unix(0, d.startedAt.Load())) < d.params.HealthWarmup {
+		return true
+	}
+
+	stats, err := d.sampler.stats(ctx)
+	if err != nil {
+		return false
+	}
+	return heightsBetween(stats.SampledChainHead, stats.NetworkHead) <= d.params.SamplingRange
+}
+
+// VerifyStoreIntegrity checks consistency of the persisted checkpoint and returns sorted heights
+// that are inconsistent: failed heights that were not yet reached by catchup, i.e. not below
+// SampleFrom, and failed heights that are also recorded as sampled.
+func (d *DASer) VerifyStoreIntegrity(ctx context.Context) ([]uint64, error) {
+	cp, err := d.store.load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("das: loading checkpoint: %w", err)
+	}
+	return inconsistentHeights(cp), nil
+}
+
+// RepairStoreIntegrity removes inconsistent failed heights reported by VerifyStoreIntegrity from
+// the persisted checkpoint and returns them. It can only be called while the DASer is stopped.
+func (d *DASer) RepairStoreIntegrity(ctx context.Context) ([]uint64, error) {
+	if atomic.LoadInt32(&d.running) == 1 {
+		return nil, errors.New("das: cannot repair store while DASer is running")
+	}
+
+	cp, err := d.store.load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("das: loading checkpoint: %w", err)
+	}
+
+	heights := inconsistentHeights(cp)
+	if len(heights) == 0 {
+		return nil, nil
+	}
+	for _, h := range heights {
+		delete(cp.Failed, h)
+	}
+	log.Warnw("removing inconsistent failed heights from checkpoint", "heights", heights)
+	if err = d.store.store(ctx, cp); err != nil {
+		return nil, fmt.Errorf("das: storing repaired checkpoint: %w", err)
+	}
+	return heights, nil
+}
+
+func inconsistentHeights(cp checkpoint) []uint64 {
+	var heights []uint64
+	for h := range cp.Failed {
+		_, sampled := cp.Sampled[h]
+		if h >= cp.SampleFrom || sampled {
+			heights = append(heights, h)
+		}
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	return heights
+}
+
+// VerifyFraud re-runs validation of the stored bad encoding fraud proof against the header at the
+// proof's height from the header getter and reports whether the proof still holds. The proof
+// created by the DASer itself is preferred; otherwise the most recent proof persisted by the fraud
+// service is used, if it supports retrieval of proofs. ErrNoFraudProof is returned if there is no
+// proof to verify.
+func (d *DASer) VerifyFraud(ctx context.Context) (bool, error) {
+	proof, err := d.storedFraudProof(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	h, err := d.getter.GetByHeight(ctx, proof.Height())
+	if err != nil {
+		return false, fmt.Errorf("das: getting header for fraud proof at height %d: %w", proof.Height(), err)
+	}
+
+	if err = proof.Validate(h); err != nil {
+		log.Warnw("stored fraud proof does not hold", "height", proof.Height(), "err", err)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (d *DASer) storedFraudProof(ctx context.Context) (fraud.Proof[*header.ExtendedHeader], error) {
+	if befp := d.befp.Load(); befp != nil {
+		return *befp, nil
+	}
+
+	getter, ok := d.bcast.(fraud.Getter[*header.ExtendedHeader])
+	if !ok {
+		return nil, ErrNoFraudProof
+	}
+	proofs, err := getter.Get(ctx, byzantine.BadEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("das: getting stored fraud proofs: %w", err)
+	}
+
+	var latest fraud.Proof[*header.ExtendedHeader]
+	for _, proof := range proofs {
+		if latest == nil || proof.Height() > latest.Height() {
+			latest = proof
+		}
+	}
+	if latest == nil {
+		return nil, ErrNoFraudProof
+	}
+	return latest, nil
 }
 
 // WaitCatchUp waits for DASer to indicate catchup is done
 func (d *DASer) WaitCatchUp(ctx context.Context) error {
 	return d.sampler.state.waitCatchUp(ctx)
 }
+
+// atHeadPollInterval is the interval WaitUntilAtHead checks whether the DASer reached the head at.
+const atHeadPollInterval = 50 * time.Millisecond
+
+// WaitUntilAtHead waits until every height up to the network head is sampled and no recent headers
+// are pending, i.e. SampleFrom of the checkpoint is past the network head. Unlike WaitCatchUp, which
+// returns once the current backlog is processed, it keeps waiting while the head advances or failed
+// heights are retried. It returns an error if sampling stops before reaching the head.
+func (d *DASer) WaitUntilAtHead(ctx context.Context) error {
+	ticker := d.clock.Ticker(atHeadPollInterval)
+	defer ticker.Stop()
+	for {
+		atHead, err := d.sampler.atHead(ctx)
+		if err != nil {
+			return err
+		}
+		if atHead {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-d.sampler.finished:
+			return errors.New("das: sampling stopped before reaching the network head")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// AdvanceHead extends sampling up to the given height, e.g. once a higher network head is learned
+// out-of-band, before its header is delivered by the subscription. The header of the height must
+// be available from the getter. It returns once catchup is extended, so WaitCatchUp waits for the
+// new head. Heights not above the known network head are ignored.
+func (d *DASer) AdvanceHead(ctx context.Context, height uint64) error {
+	if atomic.LoadInt32(&d.running) == 0 {
+		return errors.New("das: DASer is not running")
+	}
+	if err := validateHeight(height); err != nil {
+		return err
+	}
+
+	stats, err := d.sampler.stats(ctx)
+	if err != nil {
+		return err
+	}
+	if height <= stats.NetworkHead {
+		return nil
+	}
+
+	h, err := d.getter.GetByHeight(ctx, height)
+	switch {
+	case err != nil:
+		return fmt.Errorf("das: getting header at height %d: %w", height, err)
+	case h == nil:
+		return fmt.Errorf("%w: height %d", ErrHeaderNotFound, height)
+	case h.Height() != height:
+		return fmt.Errorf("das: getter returned header at height %d instead of %d", h.Height(), height)
+	}
+
+	log.Infow("advancing network head", "from", stats.NetworkHead, "to", height)
+	d.detectEquivocation(ctx, h, sourceGetter)
+	d.sampler.listen(ctx, h)
+	// the coordinator is released for stats only after it handled the new head
+	_, err = d.sampler.stats(ctx)
+	return err
+}
+
+// retryFailedPollInterval is the interval RetryFailed checks outcomes of retried heights in.
+const retryFailedPollInterval = 100 * time.Millisecond
+
+// RetryFailed retries all failed heights at once, regardless of their retry backoff and including
+// the ones that exceeded MaxRetries, e.g. after recovering from an outage of providers. It waits
+// until every failed height is retried and reports the amount of heights that succeeded and that
+// are still failing. It is safe to call while the DASer is running.
+func (d *DASer) RetryFailed(ctx context.Context) (succeeded, stillFailed int, err error) {
+	if atomic.LoadInt32(&d.running) == 0 {
+		return 0, 0, errors.New("das: DASer is not running")
+	}
+
+	pending, err := d.sampler.retryFailed(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ticker := d.clock.Ticker(retryFailedPollInterval)
+	defer ticker.Stop()
+	for len(pending) > 0 {
+		select {
+		case <-ticker.C:
+		case <-d.sampler.finished:
+			return succeeded, stillFailed, errSamplingStopped
+		case <-ctx.Done():
+			return succeeded, stillFailed, ctx.Err()
+		}
+
+		stats, err := d.sampler.stats(ctx)
+		if err != nil {
+			return succeeded, stillFailed, err
+		}
+		for h, attempts := range pending {
+			// every attempt of a failed height is counted, so an increased count means the retry
+			// failed again
+			count, failed := stats.Failed[h]
+			switch {
+			case !failed:
+				succeeded++
+			case count > attempts:
+				stillFailed++
+			default:
+				continue
+			}
+			delete(pending, h)
+		}
+	}
+	return succeeded, stillFailed, nil
+}
+
+// Pause pauses dispatching of all sampling jobs until Resume is called. Running jobs are finished.
+// It blocks until the DASer handles the request or the context is done, and returns an error if
+// the DASer is not running.
+func (d *DASer) Pause(ctx context.Context) error {
+	return d.requestPause(ctx, true)
+}
+
+// Resume resumes dispatching of sampling jobs paused by Pause. Sampling stays paused while it is
+// paused for other reasons, e.g. by the disk guard.
+func (d *DASer) Resume(ctx context.Context) error {
+	return d.requestPause(ctx, false)
+}
+
+func (d *DASer) requestPause(ctx context.Context, pause bool) error {
+	if atomic.LoadInt32(&d.running) == 0 {
+		return errors.New("das: DASer is not running")
+	}
+	select {
+	case d.sampler.pauseCh <- pauseRequest{reason: PauseManual, pause: pause}:
+		return nil
+	case <-d.sampler.finished:
+		return errSamplingStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PauseCatchUp pauses sampling of headers below the network head, e.g. to save bandwidth during
+// peak traffic, while new headers from the subscription are still sampled. Running catchup jobs are
+// finished. It could be called before the DASer is started.
+func (d *DASer) PauseCatchUp() {
+	d.sampler.pauseCatchUp(true)
+}
+
+// ResumeCatchUp resumes sampling of headers below the network head paused by PauseCatchUp.
+func (d *DASer) ResumeCatchUp() {
+	d.sampler.pauseCatchUp(false)
+}
@@ -1,15 +1,30 @@
 package das
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/golang/mock/gomock"
 	"github.com/ipfs/boxo/blockservice"
 	"github.com/ipfs/go-datastore"
 	ds_sync "github.com/ipfs/go-datastore/sync"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/p2p/net/conngater"
 	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -24,13 +39,21 @@ import (
 	"github.com/celestiaorg/celestia-node/header/headertest"
 	headerfraud "github.com/celestiaorg/celestia-node/header/headertest/fraud"
 	"github.com/celestiaorg/celestia-node/share"
+	"github.com/celestiaorg/celestia-node/share/availability/availabilitytest"
 	"github.com/celestiaorg/celestia-node/share/availability/full"
 	"github.com/celestiaorg/celestia-node/share/availability/light"
 	"github.com/celestiaorg/celestia-node/share/availability/mocks"
+	"github.com/celestiaorg/celestia-node/share/availability/remote"
 	availability_test "github.com/celestiaorg/celestia-node/share/availability/test"
+	"github.com/celestiaorg/celestia-node/share/eds"
 	"github.com/celestiaorg/celestia-node/share/eds/byzantine"
+	"github.com/celestiaorg/celestia-node/share/eds/edstest"
 	"github.com/celestiaorg/celestia-node/share/getters"
 	"github.com/celestiaorg/celestia-node/share/ipld"
+	"github.com/celestiaorg/celestia-node/share/p2p/peers"
+	"github.com/celestiaorg/celestia-node/share/p2p/shrexeds"
+	"github.com/celestiaorg/celestia-node/share/p2p/shrexsub"
+	"github.com/celestiaorg/celestia-node/share/sharetest"
 )
 
 var timeout = time.Second * 15
@@ -77,6 +100,168 @@ func TestDASerLifecycle(t *testing.T) {
 	assert.NoError(t, daser.sampler.state.waitCatchUp(ctx))
 }
 
+func TestDASer_WaitUntilAtHead(t *testing.T) {
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	// 15 headers from the past and 15 future headers
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 15)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(15))
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
+
+	// the head is static once all future headers are delivered
+	require.NoError(t, daser.subscriber.wait(ctx))
+	require.NoError(t, daser.WaitUntilAtHead(ctx))
+
+	cp, err := daser.sampler.getCheckpoint(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 30, cp.NetworkHead)
+	assert.EqualValues(t, 30, cp.SampleFrom-1)
+
+	atHead, err := daser.sampler.atHead(ctx)
+	require.NoError(t, err)
+	assert.True(t, atHead)
+}
+
+func TestDASer_PauseCatchUp(t *testing.T) {
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	// 15 headers from the past and 15 future headers
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 15)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1))
+	require.NoError(t, err)
+	daser.PauseCatchUp()
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
+
+	// recent headers are sampled
+	require.Eventually(t, func() bool {
+		stats, err := daser.SamplingStats(ctx)
+		require.NoError(t, err)
+		return len(stats.Sampled) == 15
+	}, timeout, 10*time.Millisecond)
+
+	// while the catchup backlog is not drained
+	stats, err := daser.SamplingStats(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 30, stats.NetworkHead)
+	assert.Zero(t, stats.CatchupHead)
+	assert.Zero(t, stats.SampledChainHead)
+	assert.False(t, stats.CatchUpDone)
+
+	daser.ResumeCatchUp()
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	stats, err = daser.SamplingStats(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 30, stats.SampledChainHead)
+}
+
+func TestDASer_RetryFailed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 10, 0)
+	avail := &recoveringAvailability{
+		Availability: light.TestAvailability(getters.NewIPLDGetter(bServ)),
+		failing:      map[uint64]bool{3: true, 6: true, 9: true},
+	}
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false))
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
+
+	// failed heights are retried only after a minute of backoff
+	require.Eventually(t, func() bool {
+		stats, err := daser.SamplingStats(ctx)
+		return err == nil && len(stats.Failed) == 3 && len(stats.Workers) == 0
+	}, timeout, 10*time.Millisecond)
+
+	avail.recover()
+	succeeded, stillFailed, err := daser.RetryFailed(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, succeeded)
+	assert.Zero(t, stillFailed)
+
+	stats, err := daser.SamplingStats(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, stats.Failed)
+	assert.EqualValues(t, 10, stats.SampledChainHead)
+}
+
+func TestDASer_AdvanceHead(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 20, 0)
+	// the getter already has headers up to 20, while the head is known only up to 10
+	mockGet.head = 10
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1))
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
+	require.NoError(t, daser.WaitCatchUp(ctx))
+
+	stats, err := daser.SamplingStats(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 10, stats.NetworkHead)
+
+	require.ErrorIs(t, daser.AdvanceHead(ctx, 25), ErrHeaderNotFound)
+	require.NoError(t, daser.AdvanceHead(ctx, 20))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+
+	stats, err = daser.SamplingStats(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 20, stats.NetworkHead)
+	assert.EqualValues(t, 20, stats.SampledChainHead)
+	assert.Empty(t, stats.Failed)
+
+	// heights below the head are ignored
+	require.NoError(t, daser.AdvanceHead(ctx, 15))
+}
+
+// recoveringAvailability reports shares of the failing heights unavailable until it recovers.
+type recoveringAvailability struct {
+	share.Availability
+	recovered atomic.Bool
+	failing   map[uint64]bool
+}
+
+func (a *recoveringAvailability) SharesAvailable(ctx context.Context, h *header.ExtendedHeader) error {
+	if !a.recovered.Load() && a.failing[h.Height()] {
+		return share.ErrNotAvailable
+	}
+	return a.Availability.SharesAvailable(ctx, h)
+}
+
+func (a *recoveringAvailability) recover() {
+	a.recovered.Store(true)
+}
+
 func TestDASer_Restart(t *testing.T) {
 	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
 	bServ := ipld.NewMemBlockservice()
@@ -206,11 +391,216 @@ func TestDASer_stopsAfter_BEFP(t *testing.T) {
 	require.True(t, daser.running == 0)
 }
 
+func TestDASer_VerifyFraud(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := full.TestAvailability(t, getters.NewIPLDGetter(bServ))
+	mockGet, sub, _ := createDASerSubcomponents(t, bServ, 3, 0)
+	mockGet.headers[2] = headerfraud.CreateFraudExtHeader(t, mockGet.headers[2], bServ)
+
+	bcast := &fraudBroadcasterStub{proofCh: make(chan fraud.Proof[*header.ExtendedHeader], 1)}
+	daser, err := NewDASer(avail, sub, mockGet, ds, bcast, newBroadcastMock(1),
+		WithRecentSampling(false))
+	require.NoError(t, err)
+
+	_, err = daser.VerifyFraud(ctx)
+	require.ErrorIs(t, err, ErrNoFraudProof)
+
+	require.NoError(t, daser.Start(ctx))
+	select {
+	case proof := <-bcast.proofCh:
+		assert.EqualValues(t, 2, proof.Height())
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	}
+	require.NoError(t, daser.Stop(ctx))
+
+	valid, err := daser.VerifyFraud(ctx)
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestDASer_FraudHandleTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	mockGet, sub, _ := createDASerSubcomponents(t, bServ, 15, 0)
+	// getter never returns headers, including the one of the fraud proof
+	getter := &stuckGetter{mockGetter: mockGet}
+	fsub := &fraudSubscriberStub{proofCh: make(chan fraud.Proof[*header.ExtendedHeader], 1)}
+
+	const handleTimeout = 100 * time.Millisecond
+	daser, err := NewDASer(avail, sub, getter, ds, fsub, newBroadcastMock(1),
+		WithRecentSampling(false),
+		WithFraudHandleTimeout(handleTimeout),
+	)
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+
+	sentAt := time.Now()
+	fsub.proofCh <- fraudtest.NewValidProof[*header.ExtendedHeader]()
+	require.Eventually(t, func() bool {
+		return daser.HaltErr() != nil
+	}, timeout, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(sentAt), handleTimeout)
+	assert.ErrorIs(t, daser.HaltErr(), ErrFraudDetected)
+	require.NoError(t, daser.Stop(ctx))
+}
+
+func TestDASer_FraudHaltPolicy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	mockGet, sub, _ := createDASerSubcomponents(t, bServ, 15, 0)
+	fsub := &fraudSubscriberStub{proofCh: make(chan fraud.Proof[*header.ExtendedHeader], 1)}
+
+	var declined atomic.Int32
+	daser, err := NewDASer(avail, sub, mockGet, ds, fsub, newBroadcastMock(1),
+		WithRecentSampling(false),
+		WithFraudHaltPolicy(func(fraud.Proof[*header.ExtendedHeader]) bool {
+			declined.Add(1)
+			return false
+		}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+
+	proof := fraudtest.NewValidProof[*header.ExtendedHeader]()
+	fsub.proofCh <- proof
+	require.Eventually(t, func() bool {
+		return daser.DeclinedFraudProof() != nil
+	}, timeout, 10*time.Millisecond)
+	assert.EqualValues(t, 1, declined.Load())
+	assert.Equal(t, proof, daser.DeclinedFraudProof())
+
+	// sampling continues
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	assert.NoError(t, daser.HaltErr())
+	assert.EqualValues(t, 1, atomic.LoadInt32(&daser.running))
+	require.NoError(t, daser.Stop(ctx))
+}
+
+func TestDASer_FraudValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     FraudValidation
+		proof    fraud.Proof[*header.ExtendedHeader]
+		accepted bool
+	}{
+		{
+			name:     "strict rejects mismatched proof",
+			mode:     FraudValidationStrict,
+			proof:    &mismatchedProof{DummyProof: fraudtest.NewInvalidProof[*header.ExtendedHeader]()},
+			accepted: false,
+		},
+		{
+			name:     "strict rejects proof of unknown height",
+			mode:     FraudValidationStrict,
+			proof:    &unknownHeightProof{DummyProof: fraudtest.NewValidProof[*header.ExtendedHeader]()},
+			accepted: false,
+		},
+		{
+			name:     "lenient accepts self-consistent proof",
+			mode:     FraudValidationLenient,
+			proof:    &mismatchedProof{DummyProof: fraudtest.NewInvalidProof[*header.ExtendedHeader]()},
+			accepted: true,
+		},
+		{
+			name: "lenient rejects inconsistent proof",
+			mode: FraudValidationLenient,
+			proof: &mismatchedProof{
+				DummyProof:    fraudtest.NewInvalidProof[*header.ExtendedHeader](),
+				inconsistency: errors.New("inconsistent"),
+			},
+			accepted: false,
+		},
+		{
+			name: "none accepts any proof",
+			mode: FraudValidationNone,
+			proof: &mismatchedProof{
+				DummyProof:    fraudtest.NewInvalidProof[*header.ExtendedHeader](),
+				inconsistency: errors.New("inconsistent"),
+			},
+			accepted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			t.Cleanup(cancel)
+
+			ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+			bServ := ipld.NewMemBlockservice()
+			avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+			mockGet, sub, _ := createDASerSubcomponents(t, bServ, 15, 0)
+			fsub := &fraudSubscriberStub{proofCh: make(chan fraud.Proof[*header.ExtendedHeader])}
+
+			// the policy receives accepted proofs only and never halts, so more proofs could be sent
+			accepted := make(chan fraud.Proof[*header.ExtendedHeader], 2)
+			daser, err := NewDASer(avail, sub, mockGet, ds, fsub, newBroadcastMock(1),
+				WithRecentSampling(false),
+				WithFraudValidation(tt.mode),
+				WithFraudHaltPolicy(func(proof fraud.Proof[*header.ExtendedHeader]) bool {
+					accepted <- proof
+					return false
+				}),
+			)
+			require.NoError(t, err)
+			require.NoError(t, daser.Start(ctx))
+			t.Cleanup(func() {
+				require.NoError(t, daser.Stop(ctx))
+			})
+
+			fsub.proofCh <- tt.proof
+			// the valid proof is accepted in any mode and is received after the tested one was handled
+			sentinel := fraudtest.NewValidProof[*header.ExtendedHeader]()
+			fsub.proofCh <- sentinel
+
+			if tt.accepted {
+				assert.Equal(t, tt.proof, <-accepted)
+			}
+			assert.Equal(t, sentinel, <-accepted)
+		})
+	}
+}
+
+// mismatchedProof does not hold against any header, while it is consistent in itself unless the
+// inconsistency is set.
+type mismatchedProof struct {
+	*fraudtest.DummyProof[*header.ExtendedHeader]
+	inconsistency error
+}
+
+func (p *mismatchedProof) ValidateBasic() error {
+	return p.inconsistency
+}
+
+// unknownHeightProof is valid, but for a height the getter has no header for.
+type unknownHeightProof struct {
+	*fraudtest.DummyProof[*header.ExtendedHeader]
+}
+
+func (p *unknownHeightProof) Height() uint64 {
+	return 1000
+}
+
 func TestDASerSampleTimeout(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	t.Cleanup(cancel)
 
-	getter := getterStub{}
+	// header with non-empty square, so availability is called
+	dah := availability_test.RandFillBS(t, 16, ipld.NewMemBlockservice())
+	getter := benchGetterStub{header: headertest.RandExtendedHeaderWithRoot(t, dah)}
 	avail := mocks.NewMockAvailability(gomock.NewController(t))
 	doneCh := make(chan struct{})
 	avail.EXPECT().SharesAvailable(gomock.Any(), gomock.Any()).DoAndReturn(
@@ -244,93 +634,2435 @@ func TestDASerSampleTimeout(t *testing.T) {
 	}
 }
 
-// createDASerSubcomponents takes numGetter (number of headers
-// to store in mockGetter) and numSub (number of headers to store
-// in the mock header.Subscriber), returning a newly instantiated
-// mockGetter, share.Availability, and mock header.Subscriber.
-func createDASerSubcomponents(
-	t *testing.T,
-	bServ blockservice.BlockService,
-	numGetter,
-	numSub int,
-) (*mockGetter, *headertest.Subscriber, *fraudtest.DummyService[*header.ExtendedHeader]) {
-	mockGet, sub := createMockGetterAndSub(t, bServ, numGetter, numSub)
-	fraud := &fraudtest.DummyService[*header.ExtendedHeader]{}
-	return mockGet, sub, fraud
-}
+func TestDASer_SetSampleTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
 
-func createMockGetterAndSub(
-	t *testing.T,
-	bServ blockservice.BlockService,
-	numGetter,
-	numSub int,
-) (*mockGetter, *headertest.Subscriber) {
-	mockGet := &mockGetter{
-		headers:        make(map[int64]*header.ExtendedHeader),
-		doneCh:         make(chan struct{}),
-		brokenHeightCh: make(chan struct{}),
-	}
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 2, 0)
 
-	mockGet.generateHeaders(t, bServ, 0, numGetter)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var (
+		lk        sync.Mutex
+		deadlines = make(map[uint64][]time.Time)
+	)
+	avail := mocks.NewMockAvailability(gomock.NewController(t))
+	avail.EXPECT().SharesAvailable(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, h *header.ExtendedHeader) error {
+			deadline, _ := ctx.Deadline()
+			lk.Lock()
+			deadlines[h.Height()] = append(deadlines[h.Height()], deadline)
+			lk.Unlock()
+			if h.Height() != 1 {
+				return nil
+			}
 
-	sub := new(headertest.Subscriber)
-	mockGet.fillSubWithHeaders(t, sub, bServ, numGetter, numGetter+numSub)
-	return mockGet, sub
-}
+			// the first sample is in flight while the timeout changes
+			close(started)
+			<-release
+			deadline, _ = ctx.Deadline()
+			lk.Lock()
+			deadlines[1] = append(deadlines[1], deadline)
+			lk.Unlock()
+			return nil
+		}).Times(2)
+
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false),
+		WithConcurrencyLimit(1),
+		WithSampleTimeout(time.Hour),
+	)
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
 
-// fillSubWithHeaders generates `num` headers from the future for p2pSub to pipe through to DASer.
-func (m *mockGetter) fillSubWithHeaders(
-	t *testing.T,
-	sub *headertest.Subscriber,
-	bServ blockservice.BlockService,
-	startHeight,
-	endHeight int,
-) {
-	sub.Headers = make([]*header.ExtendedHeader, endHeight-startHeight)
+	select {
+	case <-started:
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	}
+	daser.SetSampleTimeout(time.Minute)
+	// non-positive timeouts are ignored
+	daser.SetSampleTimeout(0)
+	changedAt := time.Now()
+	close(release)
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	assert.Equal(t, time.Minute, daser.Config().SampleTimeout)
+
+	lk.Lock()
+	defer lk.Unlock()
+	require.Len(t, deadlines[1], 2)
+	// the sample in flight keeps its deadline
+	assert.Equal(t, deadlines[1][0], deadlines[1][1])
+	assert.Greater(t, deadlines[1][0].Sub(changedAt), time.Minute)
+	// the next sample uses the new timeout
+	require.Len(t, deadlines[2], 1)
+	assert.LessOrEqual(t, deadlines[2][0].Sub(changedAt), time.Minute+time.Second)
+}
 
-	index := 0
-	for i := startHeight; i < endHeight; i++ {
-		dah := availability_test.RandFillBS(t, 16, bServ)
+func TestDASer_SampleMetadata(t *testing.T) {
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 0)
 
-		randHeader := headertest.RandExtendedHeaderWithRoot(t, dah)
-		randHeader.RawHeader.Height = int64(i + 1)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
 
-		sub.Headers[index] = randHeader
-		// also checkpointStore to mock getter for duplicate sampling
-		m.headers[int64(i+1)] = randHeader
+	var out bytes.Buffer
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false),
+		WithAuditLog(&out),
+	)
+	require.NoError(t, err)
 
-		index++
+	require.NoError(t, daser.Start(ctx))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+
+	events := daser.SubscribeSamples(ctx)
+	md := SampleMetadata{"request_id": "42"}
+	require.NoError(t, daser.SampleRange(WithSampleMetadata(ctx, md), "", 2, 4))
+	require.NoError(t, daser.Resample(ctx, "", 5))
+
+	for height := uint64(2); height <= 5; height++ {
+		select {
+		case ev := <-events:
+			assert.Equal(t, height, ev.Height)
+			assert.Equal(t, manualJob, ev.Source)
+			assert.NoError(t, ev.Err)
+			if height <= 4 {
+				assert.Equal(t, md, ev.Metadata)
+			} else {
+				assert.Empty(t, ev.Metadata)
+			}
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+	require.NoError(t, daser.Stop(ctx))
+
+	var withMetadata int
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var rec auditRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		if rec.Metadata != nil {
+			assert.Equal(t, md, rec.Metadata)
+			withMetadata++
+		}
 	}
+	require.NoError(t, scanner.Err())
+	// background samples have no metadata
+	assert.Equal(t, 3, withMetadata)
 }
 
-type mockGetter struct {
-	getterStub
-	doneCh chan struct{} // signals all stored headers have been retrieved
+func TestDASer_SampleSource(t *testing.T) {
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 5, 0)
 
-	brokenHeight   int64
-	brokenHeightCh chan struct{}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
 
-	head    int64
-	headers map[int64]*header.ExtendedHeader
+	var out bytes.Buffer
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false),
+		WithAuditLog(&out),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, daser.Start(ctx))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+
+	events := daser.SubscribeSamples(ctx)
+	const source SampleSource = "blob-fetch"
+	require.NoError(t, daser.SampleRange(ctx, source, 2, 4))
+	for height := uint64(2); height <= 4; height++ {
+		select {
+		case ev := <-events:
+			assert.Equal(t, height, ev.Height)
+			assert.Equal(t, source, ev.Source)
+			assert.NoError(t, ev.Err)
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+	assert.Contains(t, daser.SuccessRates(), source)
+
+	// sources of background samples are reserved
+	err = daser.SampleRange(ctx, catchupJob, 2, 4)
+	assert.ErrorIs(t, err, errReservedSource)
+	err = daser.SampleRange(ctx, SampleSource(strings.Repeat("a", maxSourceLength+1)), 2, 4)
+	assert.Error(t, err)
+	require.NoError(t, daser.Stop(ctx))
+
+	sources := make(map[string]int)
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var rec auditRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		sources[rec.Source]++
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, map[string]int{string(catchupJob): 5, string(source): 3}, sources)
 }
 
-func (m *mockGetter) generateHeaders(t *testing.T, bServ blockservice.BlockService, startHeight, endHeight int) {
-	for i := startHeight; i < endHeight; i++ {
-		dah := availability_test.RandFillBS(t, 16, bServ)
+func TestDASer_SampleRangeWithProgress(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
 
-		randHeader := headertest.RandExtendedHeaderWithRoot(t, dah)
-		randHeader.RawHeader.Height = int64(i + 1)
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 0)
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false))
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
 
-		m.headers[int64(i+1)] = randHeader
+	var reports [][2]int
+	err = daser.SampleRangeWithProgress(ctx, "", 3, 12, func(done, total int) {
+		reports = append(reports, [2]int{done, total})
+	})
+	require.NoError(t, err)
+	require.NoError(t, daser.Stop(ctx))
+
+	require.NotEmpty(t, reports)
+	assert.Equal(t, [2]int{10, 10}, reports[len(reports)-1])
+	for i := 1; i < len(reports); i++ {
+		assert.Greater(t, reports[i][0], reports[i-1][0])
 	}
-	// set network head
-	m.head = int64(startHeight + endHeight)
 }
 
-func (m *mockGetter) Head(
-	context.Context,
-	...libhead.HeadOption[*header.ExtendedHeader],
-) (*header.ExtendedHeader, error) {
+func TestDASer_SampleRangeError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 10, 0)
+	avail := failingHeightsAvailability{
+		Availability: light.TestAvailability(getters.NewIPLDGetter(bServ)),
+		heights:      map[uint64]bool{3: true, 5: true},
+	}
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false))
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
+
+	err = daser.SampleRange(ctx, "", 2, 6)
+	var rangeErr *RangeError
+	require.ErrorAs(t, err, &rangeErr)
+	assert.Len(t, rangeErr.Failures, 2)
+	assert.ErrorIs(t, rangeErr.Failures[3], share.ErrNotAvailable)
+	assert.ErrorIs(t, rangeErr.Failures[5], share.ErrNotAvailable)
+	assert.ErrorIs(t, err, share.ErrNotAvailable)
+
+	require.NoError(t, daser.SampleRange(ctx, "", 6, 8))
+}
+
+// failingHeightsAvailability reports shares of the given heights unavailable.
+type failingHeightsAvailability struct {
+	share.Availability
+	heights map[uint64]bool
+}
+
+func (a failingHeightsAvailability) SharesAvailable(ctx context.Context, h *header.ExtendedHeader) error {
+	if a.heights[h.Height()] {
+		return share.ErrNotAvailable
+	}
+	return a.Availability.SharesAvailable(ctx, h)
+}
+
+func TestDASer_InvestigateAround(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 10, 0)
+	// headers are available when sampled first, but the one of height 5 is not once re-verified
+	avail := reverifyFailingAvailability{
+		Availability: light.TestAvailability(getters.NewIPLDGetter(bServ)),
+		heights:      map[uint64]bool{5: true},
+	}
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false))
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
+	require.NoError(t, daser.WaitCatchUp(ctx))
+
+	before, err := daser.sampler.getCheckpoint(ctx)
+	require.NoError(t, err)
+
+	results, err := daser.InvestigateAround(ctx, "", 5, 2)
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	for i, res := range results {
+		assert.EqualValues(t, 3+i, res.Height)
+		if res.Height == 5 {
+			assert.ErrorIs(t, res.Err, share.ErrNotAvailable)
+			continue
+		}
+		assert.NoError(t, res.Err)
+	}
+
+	after, err := daser.sampler.getCheckpoint(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	// the window is clipped to the heights between 1 and the network head
+	results, err = daser.InvestigateAround(ctx, "", 2, 3)
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	assert.EqualValues(t, 1, results[0].Height)
+	assert.EqualValues(t, 5, results[4].Height)
+
+	results, err = daser.InvestigateAround(ctx, "", 9, 3)
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	assert.EqualValues(t, 6, results[0].Height)
+	assert.EqualValues(t, 10, results[4].Height)
+
+	_, err = daser.InvestigateAround(ctx, "", 0, 3)
+	assert.ErrorIs(t, err, ErrHeightOutOfRange)
+}
+
+// reverifyFailingAvailability reports shares of the given heights unavailable once re-verified.
+type reverifyFailingAvailability struct {
+	share.Availability
+	heights map[uint64]bool
+}
+
+func (a reverifyFailingAvailability) ReverifyAvailable(ctx context.Context, h *header.ExtendedHeader) error {
+	if a.heights[h.Height()] {
+		return share.ErrNotAvailable
+	}
+	return a.Availability.SharesAvailable(ctx, h)
+}
+
+func TestDASer_PostVerify(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 3, 0)
+	// the availability reports every square available without verifying anything
+	lying := availabilitytest.NewFake()
+
+	_, err := NewDASer(lying, sub, mockGet, datastore.NewMapDatastore(), mockService, newBroadcastMock(1),
+		WithPostVerify(true))
+	require.Error(t, err)
+
+	t.Run("data withheld", func(t *testing.T) {
+		daser, err := NewDASer(lying, sub, mockGet, ds_sync.MutexWrap(datastore.NewMapDatastore()),
+			mockService, newBroadcastMock(1),
+			WithRecentSampling(false),
+			WithPostVerify(true),
+			// nothing is served by the network
+			WithPostVerifyGetter(getters.NewIPLDGetter(ipld.NewMemBlockservice())),
+		)
+		require.NoError(t, err)
+		require.NoError(t, daser.Start(ctx))
+		t.Cleanup(func() {
+			require.NoError(t, daser.Stop(ctx))
+		})
+
+		err = daser.SampleRange(ctx, "", 1, 3)
+		var rangeErr *RangeError
+		require.ErrorAs(t, err, &rangeErr)
+		assert.Len(t, rangeErr.Failures, 3)
+		assert.ErrorIs(t, err, ErrFaultyAvailability)
+	})
+
+	t.Run("data available", func(t *testing.T) {
+		daser, err := NewDASer(lying, sub, mockGet, ds_sync.MutexWrap(datastore.NewMapDatastore()),
+			mockService, newBroadcastMock(1),
+			WithRecentSampling(false),
+			WithPostVerify(true),
+			WithPostVerifyGetter(getters.NewIPLDGetter(bServ)),
+		)
+		require.NoError(t, err)
+		require.NoError(t, daser.Start(ctx))
+		t.Cleanup(func() {
+			require.NoError(t, daser.Stop(ctx))
+		})
+
+		require.NoError(t, daser.SampleRange(ctx, "", 1, 3))
+		assert.True(t, daser.Config().PostVerify)
+	})
+
+	t.Run("nmt hasher", func(t *testing.T) {
+		var hashers atomic.Int32
+		daser, err := NewDASer(lying, sub, mockGet, ds_sync.MutexWrap(datastore.NewMapDatastore()),
+			mockService, newBroadcastMock(1),
+			WithRecentSampling(false),
+			WithPostVerify(true),
+			WithPostVerifyGetter(getters.NewIPLDGetter(bServ)),
+			WithPostVerifyNMTHasher(func() hash.Hash {
+				hashers.Add(1)
+				return share.DefaultNMTHasher()
+			}),
+		)
+		require.NoError(t, err)
+		require.NoError(t, daser.Start(ctx))
+		t.Cleanup(func() {
+			require.NoError(t, daser.Stop(ctx))
+		})
+
+		// proofs of spot checked shares are verified with the configured hasher
+		require.NoError(t, daser.SampleRange(ctx, "", 1, 3))
+		assert.EqualValues(t, 3, hashers.Load())
+	})
+}
+
+func TestPostVerifier_TransientErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	h := &header.ExtendedHeader{DAH: &share.Root{RowRoots: make([][]byte, 4)}}
+	errTransient := errors.New("connection reset")
+
+	t.Run("retried", func(t *testing.T) {
+		getter := &spotCheckGetter{errs: []error{errTransient, errTransient}}
+		require.NoError(t, newPostVerifier(getter, share.DefaultNMTHasher).verify(ctx, h))
+		assert.Equal(t, 3, getter.calls)
+	})
+
+	t.Run("inconclusive", func(t *testing.T) {
+		getter := &spotCheckGetter{errs: []error{errTransient, errTransient, errTransient, errTransient}}
+		require.NoError(t, newPostVerifier(getter, share.DefaultNMTHasher).verify(ctx, h))
+		assert.Equal(t, postVerifyAttempts, getter.calls)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		getter := &spotCheckGetter{errs: []error{errTransient, share.ErrNotFound}}
+		require.ErrorIs(t, newPostVerifier(getter, share.DefaultNMTHasher).verify(ctx, h), ErrFaultyAvailability)
+		assert.Equal(t, 2, getter.calls)
+	})
+}
+
+// spotCheckGetter fails retrieval of shares with the given errors in order, succeeding afterwards.
+type spotCheckGetter struct {
+	share.Getter
+	errs  []error
+	calls int
+}
+
+func (g *spotCheckGetter) GetShare(context.Context, *header.ExtendedHeader, int, int) (share.Share, error) {
+	g.calls++
+	if len(g.errs) > 0 {
+		err := g.errs[0]
+		g.errs = g.errs[1:]
+		return nil, err
+	}
+	return share.Share{}, nil
+}
+
+func TestDASer_LatencyStats(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 20, 0)
+	// all samples take 10ms, except the ones of the last height taking 100ms
+	avail := &delayingAvailability{
+		delay:  10 * time.Millisecond,
+		delays: map[uint64]time.Duration{20: 100 * time.Millisecond},
+	}
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false))
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+	assert.Zero(t, daser.LatencyStats())
+
+	require.NoError(t, daser.SampleRange(ctx, "", 1, 20))
+	require.NoError(t, daser.Stop(ctx))
+
+	// catchup samples the same heights concurrently, so the slow height may be accounted for more
+	// than once
+	stats := daser.LatencyStats()
+	assert.Greater(t, stats.Mean, 10*time.Millisecond)
+	assert.Less(t, stats.Mean, 50*time.Millisecond)
+	assert.GreaterOrEqual(t, stats.P50, 10*time.Millisecond)
+	assert.Less(t, stats.P50, 50*time.Millisecond)
+	assert.GreaterOrEqual(t, stats.P99, 100*time.Millisecond)
+}
+
+func TestDASer_BacklogHistory(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 30, 0)
+	// a single worker samples a height every 20ms, so catchup takes longer than a few intervals
+	avail := &delayingAvailability{delay: 20 * time.Millisecond}
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false), WithConcurrencyLimit(1), WithBacklogInterval(50*time.Millisecond))
+	require.NoError(t, err)
+	assert.Empty(t, daser.BacklogHistory())
+
+	require.NoError(t, daser.Start(ctx))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	require.NoError(t, daser.Stop(ctx))
+
+	points := daser.BacklogHistory()
+	require.GreaterOrEqual(t, len(points), 3)
+	for i := 1; i < len(points); i++ {
+		assert.True(t, points[i].At.After(points[i-1].At))
+		assert.LessOrEqual(t, points[i].Size, points[i-1].Size)
+	}
+	assert.Less(t, points[len(points)-1].Size, points[0].Size)
+}
+
+// TestDASer_OnSampledDetailed ensures the detail of a height sampled over shrex includes the peer
+// that served the square.
+func TestDASer_OnSampledDetailed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	net, err := mocknet.FullMeshConnected(2)
+	require.NoError(t, err)
+	clHost, srvHost := net.Hosts()[0], net.Hosts()[1]
+
+	// serve the square of the only header from the server host
+	edsStore, err := eds.NewStore(eds.DefaultParameters(), t.TempDir(),
+		ds_sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	require.NoError(t, edsStore.Start(ctx))
+	t.Cleanup(func() {
+		_ = edsStore.Stop(ctx)
+	})
+	square := edstest.RandEDS(t, 4)
+	dah, err := share.NewRoot(square)
+	require.NoError(t, err)
+	require.NoError(t, edsStore.Put(ctx, dah.Hash(), square))
+	eh := headertest.RandExtendedHeaderWithRoot(t, dah)
+	eh.RawHeader.Height = 1
+
+	server, err := shrexeds.NewServer(shrexeds.DefaultParameters(), srvHost, edsStore)
+	require.NoError(t, err)
+	require.NoError(t, server.Start(ctx))
+	t.Cleanup(func() {
+		_ = server.Stop(ctx)
+	})
+	client, err := shrexeds.NewClient(shrexeds.DefaultParameters(), clHost)
+	require.NoError(t, err)
+
+	shrexSub, err := shrexsub.NewPubSub(ctx, clHost, "test")
+	require.NoError(t, err)
+	connGater, err := conngater.NewBasicConnectionGater(ds_sync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+	peerManager, err := peers.NewManager(peers.DefaultParameters(), clHost, connGater,
+		peers.WithShrexSubPools(shrexSub, new(headertest.Subscriber)))
+	require.NoError(t, err)
+	getter := getters.NewShrexGetter(client, nil, peerManager)
+	require.NoError(t, getter.Start(ctx))
+	t.Cleanup(func() {
+		_ = getter.Stop(ctx)
+	})
+	peerManager.Validate(ctx, srvHost.ID(), shrexsub.Notification{
+		DataHash: dah.Hash(),
+		Height:   eh.Height(),
+	})
+
+	var (
+		lock    sync.Mutex
+		details []SampleDetail
+	)
+	onSampled := func(detail SampleDetail) {
+		lock.Lock()
+		defer lock.Unlock()
+		details = append(details, detail)
+	}
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	daser, err := NewDASer(full.TestAvailability(t, getter), new(headertest.Subscriber),
+		benchGetterStub{header: eh}, ds, &fraudtest.DummyService[*header.ExtendedHeader]{}, newBroadcastMock(1),
+		WithRecentSampling(false), WithOnSampledDetailed(onSampled))
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	require.NoError(t, daser.Stop(ctx))
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Len(t, details, 1)
+	detail := details[0]
+	assert.NoError(t, detail.Err)
+	assert.EqualValues(t, 1, detail.Height)
+	assert.Equal(t, share.DataHash(dah.Hash()), detail.Root)
+	assert.Equal(t, catchupJob, detail.Source)
+	assert.Contains(t, detail.Providers, srvHost.ID())
+	assert.NotZero(t, detail.FetchedBytes)
+}
+
+// TestDASer_LocalStoreSkip ensures heights whose squares are stored locally are marked sampled
+// during catchup without requesting any shares.
+func TestDASer_LocalStoreSkip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 10, 0)
+
+	stored := map[uint64]bool{2: true, 5: true, 6: true}
+	local := localStoreStub{}
+	for height := range stored {
+		local[string(mockGet.headers[int64(height)].DataHash)] = true
+	}
+
+	getter := &heightRecordingGetter{Getter: getters.NewIPLDGetter(bServ), heights: make(map[uint64]int)}
+	daser, err := NewDASer(light.TestAvailability(getter), sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false), WithLocalStoreSkip(local))
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	stats, err := daser.SamplingStats(ctx)
+	require.NoError(t, err)
+	require.NoError(t, daser.Stop(ctx))
+	assert.EqualValues(t, 10, stats.SampledChainHead)
+	assert.Empty(t, stats.Failed)
+
+	requested := getter.requested()
+	for height := uint64(1); height <= 10; height++ {
+		if stored[height] {
+			assert.Zero(t, requested[height], "height %d", height)
+			continue
+		}
+		assert.NotZero(t, requested[height], "height %d", height)
+	}
+
+	// the local store is only checked by catchup, so samples outside of it are sampled as usual
+	require.NoError(t, daser.sample(ctx, mockGet.headers[2]))
+	assert.NotZero(t, getter.requested()[2])
+}
+
+func TestDASer_RootIndex(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	index := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 10, 0)
+
+	daser, err := NewDASer(light.TestAvailability(getters.NewIPLDGetter(bServ)), sub, mockGet, ds,
+		mockService, newBroadcastMock(1), WithRecentSampling(false), WithRootIndex(index))
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	require.NoError(t, daser.Stop(ctx))
+
+	cp, err := daser.store.load(ctx)
+	require.NoError(t, err)
+	// every height covered by the checkpoint has its root indexed
+	for height := uint64(1); height < cp.SampleFrom; height++ {
+		root, err := daser.RootAt(ctx, height)
+		require.NoError(t, err, "height %d", height)
+		assert.Equal(t, share.DataHash(mockGet.headers[int64(height)].DataHash), root, "height %d", height)
+	}
+
+	_, err = daser.RootAt(ctx, cp.SampleFrom)
+	require.ErrorIs(t, err, datastore.ErrNotFound)
+}
+
+func TestDASer_StopKeepsBufferedHeaders(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	// the getter's head is 5, while headers up to 10 are received via subscription only
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 5, 5)
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1))
+	require.NoError(t, err)
+
+	// hold the coordinator, so received headers stay buffered in the subscriber
+	var hold sync.WaitGroup
+	hold.Add(1)
+	go func() {
+		daser.sampler.waitCh <- &hold
+	}()
+	require.NoError(t, daser.Start(ctx))
+	require.Eventually(t, func() bool {
+		daser.equivocations.lock.Lock()
+		defer daser.equivocations.lock.Unlock()
+		_, ok := daser.equivocations.roots[6]
+		return ok
+	}, timeout, 10*time.Millisecond)
+
+	// stop sampling before the buffered headers reach the coordinator
+	daser.cancel()
+	hold.Done()
+	require.NoError(t, daser.Stop(ctx))
+
+	cp, err := daser.store.load(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, cp.NetworkHead)
+
+	// the headers are sampled by catchup after restart
+	daser, err = NewDASer(avail, new(headertest.Subscriber), mockGet, ds, mockService, newBroadcastMock(1))
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	stats, err := daser.SamplingStats(ctx)
+	require.NoError(t, err)
+	require.NoError(t, daser.Stop(ctx))
+	assert.EqualValues(t, 10, stats.SampledChainHead)
+	assert.Empty(t, stats.Failed)
+}
+
+func TestDASer_HeadSource(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	// the getter's head is at 10
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 10, 0)
+
+	var calls atomic.Int32
+	source := func(context.Context) (uint64, error) {
+		calls.Add(1)
+		return 7, nil
+	}
+	daser, err := NewDASer(light.TestAvailability(getters.NewIPLDGetter(bServ)), sub, mockGet, ds,
+		mockService, newBroadcastMock(1), WithRecentSampling(false), WithHeadSource(source))
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	stats, err := daser.SamplingStats(ctx)
+	require.NoError(t, err)
+	require.NoError(t, daser.Stop(ctx))
+
+	// catchup targets the head reported by the source rather than the getter's one
+	assert.NotZero(t, calls.Load())
+	assert.EqualValues(t, 7, stats.NetworkHead)
+	assert.EqualValues(t, 7, stats.SampledChainHead)
+	assert.Empty(t, stats.Failed)
+}
+
+// localStoreStub reports squares of the contained data roots stored.
+type localStoreStub map[string]bool
+
+func (s localStoreStub) Has(_ context.Context, root share.DataHash) (bool, error) {
+	return s[string(root)], nil
+}
+
+// heightRecordingGetter counts shares requested per height.
+type heightRecordingGetter struct {
+	share.Getter
+	lock    sync.Mutex
+	heights map[uint64]int
+}
+
+func (g *heightRecordingGetter) GetShare(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+	row, col int,
+) (share.Share, error) {
+	g.lock.Lock()
+	g.heights[header.Height()]++
+	g.lock.Unlock()
+	return g.Getter.GetShare(ctx, header, row, col)
+}
+
+func (g *heightRecordingGetter) requested() map[uint64]int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	heights := make(map[uint64]int, len(g.heights))
+	for height, n := range g.heights {
+		heights[height] = n
+	}
+	return heights
+}
+
+// delayingAvailability reports shares available after the delay configured for the height.
+type delayingAvailability struct {
+	delay  time.Duration
+	delays map[uint64]time.Duration
+}
+
+func (a *delayingAvailability) SharesAvailable(ctx context.Context, h *header.ExtendedHeader) error {
+	delay, ok := a.delays[h.Height()]
+	if !ok {
+		delay = a.delay
+	}
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestDASer_StateFingerprint(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 0)
+	newDASer := func(avail share.Availability) *DASer {
+		// the getter closes its channels on requests, so every DASer gets its own one with the same headers
+		getter := &mockGetter{
+			headers:        mockGet.headers,
+			head:           mockGet.head,
+			doneCh:         make(chan struct{}),
+			brokenHeightCh: make(chan struct{}),
+		}
+		ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+		daser, err := NewDASer(avail, sub, getter, ds, mockService, newBroadcastMock(1),
+			WithRecentSampling(false))
+		require.NoError(t, err)
+		require.NoError(t, daser.Start(ctx))
+		t.Cleanup(func() {
+			require.NoError(t, daser.Stop(ctx))
+		})
+		return daser
+	}
+
+	// two nodes sampling the same headers
+	first := newDASer(light.TestAvailability(getters.NewIPLDGetter(bServ)))
+	second := newDASer(light.TestAvailability(getters.NewIPLDGetter(bServ)))
+	require.NoError(t, first.WaitCatchUp(ctx))
+	require.NoError(t, second.WaitCatchUp(ctx))
+
+	fingerprint, err := first.StateFingerprint(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, fingerprint)
+	other, err := second.StateFingerprint(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint, other)
+
+	// a node failing to sample one of the headers
+	failing := newDASer(&failingHeightAvailability{
+		Availability: light.TestAvailability(getters.NewIPLDGetter(bServ)),
+		height:       5,
+	})
+	require.Eventually(t, func() bool {
+		stats, err := failing.SamplingStats(ctx)
+		require.NoError(t, err)
+		_, failed := stats.Failed[5]
+		return failed && stats.SampledChainHead == 4 && stats.CatchupHead == 15
+	}, timeout, 10*time.Millisecond)
+	other, err = failing.StateFingerprint(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, other)
+}
+
+// failingHeightAvailability fails to validate availability of the header at the height.
+type failingHeightAvailability struct {
+	share.Availability
+	height uint64
+}
+
+func (a *failingHeightAvailability) SharesAvailable(ctx context.Context, h *header.ExtendedHeader) error {
+	if h.Height() == a.height {
+		return share.ErrNotAvailable
+	}
+	return a.Availability.SharesAvailable(ctx, h)
+}
+
+func TestDASer_BlockTime(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	const blockTime = 100 * time.Millisecond
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	// 5 headers from the past and a burst of 5 headers at the head
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 5, 5)
+	avail := &startRecordingAvailability{startedAt: make(map[uint64]time.Time)}
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithBlockTime(blockTime))
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
+
+	require.Eventually(t, func() bool {
+		return len(avail.started()) == 10
+	}, timeout, 10*time.Millisecond)
+
+	startedAt := avail.started()
+	for height := uint64(7); height <= 10; height++ {
+		spacing := startedAt[height].Sub(startedAt[height-1])
+		assert.GreaterOrEqual(t, spacing, blockTime-10*time.Millisecond, "height %d", height)
+		assert.Less(t, spacing, 2*blockTime, "height %d", height)
+	}
+}
+
+// startRecordingAvailability records the time sampling of each height started at.
+type startRecordingAvailability struct {
+	lock      sync.Mutex
+	startedAt map[uint64]time.Time
+}
+
+func (a *startRecordingAvailability) SharesAvailable(_ context.Context, h *header.ExtendedHeader) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if _, ok := a.startedAt[h.Height()]; !ok {
+		a.startedAt[h.Height()] = time.Now()
+	}
+	return nil
+}
+
+func (a *startRecordingAvailability) started() map[uint64]time.Time {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	startedAt := make(map[uint64]time.Time, len(a.startedAt))
+	for height, at := range a.startedAt {
+		startedAt[height] = at
+	}
+	return startedAt
+}
+
+func TestDASer_CheckpointFlushInterval(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := &checkpointWriteCounter{Datastore: ds_sync.MutexWrap(datastore.NewMapDatastore())}
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 40, 0)
+	// a single worker samples all heights one by one, advancing the checkpoint on every sample
+	avail := &delayingAvailability{delay: 5 * time.Millisecond}
+
+	const flushInterval = 50 * time.Millisecond
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false),
+		WithBackgroundStoreInterval(time.Millisecond),
+		WithCheckpointFlushInterval(flushInterval),
+	)
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.NoError(t, daser.Start(ctx))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	elapsed := time.Since(start)
+	writes := ds.writes.Load()
+	assert.GreaterOrEqual(t, writes, int64(1))
+	assert.LessOrEqual(t, writes, int64(elapsed/flushInterval)+1)
+
+	// the checkpoint is always stored on stop
+	require.NoError(t, daser.Stop(ctx))
+	cp, err := daser.store.load(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 41, cp.SampleFrom)
+}
+
+// checkpointWriteCounter counts writes of the checkpoint to the underlying datastore.
+type checkpointWriteCounter struct {
+	datastore.Datastore
+	writes atomic.Int64
+}
+
+func (c *checkpointWriteCounter) Put(ctx context.Context, key datastore.Key, value []byte) error {
+	if key.Equal(storePrefix.Child(checkpointKey)) {
+		c.writes.Add(1)
+	}
+	return c.Datastore.Put(ctx, key, value)
+}
+
+func TestDASer_Equivocation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 5)
+	// the subscription delivers another header for one of the heights
+	conflicting := headertest.RandExtendedHeaderWithRoot(t, availability_test.RandFillBS(t, 16, bServ))
+	conflicting.RawHeader.Height = 18
+	sub.Headers = append(sub.Headers, conflicting)
+
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithEquivocationHaltPolicy(func(ev EquivocationEvent) bool {
+			return ev.Height == 18
+		}),
+	)
+	require.NoError(t, err)
+	events := daser.SubscribeEquivocations(ctx)
+	require.NoError(t, daser.Start(ctx))
+
+	select {
+	case ev := <-events:
+		assert.EqualValues(t, 18, ev.Height)
+		assert.Equal(t, share.DataHash(mockGet.headers[18].DataHash), ev.SeenRoot)
+		assert.Equal(t, share.DataHash(conflicting.DataHash), ev.ReceivedRoot)
+		assert.Equal(t, sourceSubscription, ev.Source)
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	}
+	require.Eventually(t, func() bool {
+		return daser.HaltErr() != nil
+	}, timeout, 10*time.Millisecond)
+	assert.ErrorIs(t, daser.HaltErr(), ErrEquivocation)
+	require.NoError(t, daser.Stop(ctx))
+}
+
+func TestDASer_ExpectedRoots(t *testing.T) {
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	expected := map[uint64]share.DataHash{
+		// matching root
+		2: share.DataHash(mockGet.headers[2].DataHash),
+		// root different from the header served by the getter
+		5: share.DataHash(mockGet.headers[6].DataHash),
+	}
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false),
+		WithExpectedRoots(expected),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, daser.Start(ctx))
+	require.Eventually(t, func() bool {
+		return daser.HaltErr() != nil
+	}, timeout, 10*time.Millisecond)
+	assert.ErrorIs(t, daser.HaltErr(), ErrRootMismatch)
+	assert.Contains(t, daser.HaltErr().Error(), "height 5")
+	assert.False(t, daser.Healthy(ctx))
+	require.NoError(t, daser.Stop(ctx))
+}
+
+func TestDASer_HaltBeforeStart(t *testing.T) {
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	expected := map[uint64]share.DataHash{5: share.DataHash(mockGet.headers[6].DataHash)}
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false),
+		WithExpectedRoots(expected),
+	)
+	require.NoError(t, err)
+
+	// halting must not panic while sampling is not started yet
+	assert.ErrorIs(t, daser.sample(ctx, mockGet.headers[5]), ErrRootMismatch)
+	assert.ErrorIs(t, daser.HaltErr(), ErrRootMismatch)
+
+	require.NoError(t, daser.Start(ctx))
+	assert.False(t, daser.Healthy(ctx))
+	require.NoError(t, daser.Stop(ctx))
+}
+
+func TestDASer_DiskGuard(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 0)
+
+	var calls atomic.Int32
+	avail := mocks.NewMockAvailability(gomock.NewController(t))
+	avail.EXPECT().SharesAvailable(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, *header.ExtendedHeader) error {
+			calls.Add(1)
+			return nil
+		}).AnyTimes()
+
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false),
+		WithDiskGuard("/data", 1024),
+	)
+	require.NoError(t, err)
+
+	var free atomic.Uint64
+	free.Store(512)
+	daser.diskGuard.interval = 10 * time.Millisecond
+	daser.diskGuard.freeBytes = func(path string) (uint64, error) {
+		assert.Equal(t, "/data", path)
+		return free.Load(), nil
+	}
+
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
+
+	// nothing is sampled while disk space is low
+	time.Sleep(100 * time.Millisecond)
+	assert.Zero(t, calls.Load())
+
+	free.Store(2048)
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	assert.EqualValues(t, 15, calls.Load())
+}
+
+func TestDASer_PauseEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 5, 0)
+
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false),
+		WithDiskGuard("/data", 1024),
+	)
+	require.NoError(t, err)
+
+	var free atomic.Uint64
+	free.Store(512)
+	daser.diskGuard.interval = 10 * time.Millisecond
+	daser.diskGuard.freeBytes = func(string) (uint64, error) {
+		return free.Load(), nil
+	}
+
+	// pausing is only possible while the DASer is running
+	require.Error(t, daser.Pause(ctx))
+
+	events := daser.SubscribePauses(ctx)
+	next := func() PauseEvent {
+		select {
+		case ev := <-events:
+			return ev
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for pause event")
+			return PauseEvent{}
+		}
+	}
+
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
+
+	ev := next()
+	assert.Equal(t, Paused, ev.State)
+	assert.Equal(t, PauseDiskGuard, ev.Reason)
+
+	free.Store(2048)
+	ev = next()
+	assert.Equal(t, Resumed, ev.State)
+	assert.Equal(t, PauseDiskGuard, ev.Reason)
+	require.NoError(t, daser.WaitCatchUp(ctx))
+
+	require.NoError(t, daser.Pause(ctx))
+	ev = next()
+	assert.Equal(t, Paused, ev.State)
+	assert.Equal(t, PauseManual, ev.Reason)
+
+	require.NoError(t, daser.Resume(ctx))
+	ev = next()
+	assert.Equal(t, Resumed, ev.State)
+	assert.Equal(t, PauseManual, ev.Reason)
+}
+
+func TestDASer_RecentAndCatchupGetters(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	// 5 headers from the past and 5 future headers
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 5, 5)
+
+	newGetter := func() *heightRecordingGetter {
+		return &heightRecordingGetter{Getter: getters.NewIPLDGetter(bServ), heights: make(map[uint64]int)}
+	}
+	// sampled returns sorted heights the getter retrieved shares for
+	sampled := func(g *heightRecordingGetter) []uint64 {
+		heights := make([]uint64, 0)
+		for h := range g.requested() {
+			heights = append(heights, h)
+		}
+		sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+		return heights
+	}
+	own, recent, catchup := newGetter(), newGetter(), newGetter()
+	daser, err := NewDASer(light.TestAvailability(own), sub, mockGet, ds, mockService, newBroadcastMock(5),
+		WithRecentGetter(recent),
+		WithCatchupGetter(catchup),
+	)
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
+
+	require.Eventually(t, func() bool {
+		stats, err := daser.SamplingStats(ctx)
+		return err == nil && stats.SampledChainHead == 10
+	}, timeout, 10*time.Millisecond)
+
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5}, sampled(catchup))
+	assert.Equal(t, []uint64{6, 7, 8, 9, 10}, sampled(recent))
+	assert.Empty(t, sampled(own))
+
+	// samples on demand use the getter of the availability
+	require.NoError(t, daser.Resample(ctx, "", 3))
+	assert.Equal(t, []uint64{3}, sampled(own))
+}
+
+func TestDASer_FetchedBytes(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 3, 0)
+
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false))
+	require.NoError(t, err)
+
+	require.NoError(t, daser.Start(ctx))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
+
+	stats, err := daser.SamplingStats(ctx)
+	require.NoError(t, err)
+	require.Len(t, stats.FetchedBytesPerHeight, len(mockGet.headers))
+
+	var total uint64
+	for height, h := range mockGet.headers {
+		// every sampled share is fetched from the blockservice exactly once
+		samples := light.DefaultSampleCount(light.DefaultSampleAmount, len(h.DAH.RowRoots))
+		expected := uint64(samples * share.Size)
+		assert.Equal(t, expected, stats.FetchedBytesPerHeight[uint64(height)], "height %d", height)
+		total += expected
+	}
+	assert.Equal(t, total, stats.FetchedBytes)
+}
+
+func TestDASer_FakeAvailability(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 3, 0)
+
+	avail := availabilitytest.NewFake()
+	root := func(height int64) share.DataHash {
+		return share.DataHash(mockGet.headers[height].DataHash)
+	}
+	errCustom := errors.New("custom")
+	avail.SetUnavailable(root(2))
+	avail.SetError(root(3), errCustom)
+	avail.SetDelay(root(3), time.Minute)
+
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false),
+		WithSampleTimeout(50*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	failures := daser.SubscribeFailures(ctx)
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
+
+	got := make(map[uint64]error)
+	for len(got) < 2 {
+		select {
+		case ev := <-failures:
+			got[ev.Height] = ev.Err
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+	assert.ErrorIs(t, got[2], share.ErrNotAvailable)
+	// the delay exceeds the sample timeout, so the programmed error is never returned
+	assert.ErrorIs(t, got[3], context.DeadlineExceeded)
+	assert.NotContains(t, got, uint64(1))
+	assert.Equal(t, 1, avail.Calls(root(1)))
+}
+
+func TestDASer_SampleAbortClassification(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	t.Run("timeout", func(t *testing.T) {
+		bServ := ipld.NewMemBlockservice()
+		mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 3, 0)
+		avail := availabilitytest.NewFake()
+		avail.SetDelay(share.DataHash(mockGet.headers[2].DataHash), time.Minute)
+
+		daser, err := NewDASer(avail, sub, mockGet, ds_sync.MutexWrap(datastore.NewMapDatastore()),
+			mockService, newBroadcastMock(1), WithRecentSampling(false), WithSampleTimeout(50*time.Millisecond))
+		require.NoError(t, err)
+		failures := daser.SubscribeFailures(ctx)
+		require.NoError(t, daser.Start(ctx))
+		t.Cleanup(func() {
+			require.NoError(t, daser.Stop(ctx))
+		})
+
+		select {
+		case ev := <-failures:
+			assert.EqualValues(t, 2, ev.Height)
+			assert.ErrorIs(t, ev.Err, ErrSampleTimeout)
+			assert.ErrorIs(t, ev.Err, context.DeadlineExceeded)
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		bServ := ipld.NewMemBlockservice()
+		mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 3, 0)
+		// the availability hides the cancellation behind an availability verdict
+		avail := &blockingAvailability{started: make(chan struct{})}
+
+		ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+		daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+			WithRecentSampling(false), WithSampleTimeout(time.Hour))
+		require.NoError(t, err)
+		failures := daser.SubscribeFailures(ctx)
+		require.NoError(t, daser.Start(ctx))
+
+		select {
+		case <-avail.started:
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+		require.NoError(t, daser.Stop(ctx))
+
+		// the feed is closed on Stop
+		for ev := range failures {
+			t.Errorf("unexpected failure at height %d: %v", ev.Height, ev.Err)
+		}
+		store := newCheckpointStore(ds)
+		cp, err := store.load(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, cp.Failed)
+		// the abandoned sample is resumed on restart
+		require.Len(t, cp.Workers, 1)
+		assert.EqualValues(t, 1, cp.Workers[0].From)
+	})
+}
+
+// blockingAvailability blocks sampling until the context is done and reports shares unavailable
+// then.
+type blockingAvailability struct {
+	once    sync.Once
+	started chan struct{}
+}
+
+func (a *blockingAvailability) SharesAvailable(ctx context.Context, _ *header.ExtendedHeader) error {
+	a.once.Do(func() {
+		close(a.started)
+	})
+	<-ctx.Done()
+	return share.ErrNotAvailable
+}
+
+func TestDASer_Publisher(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 10, 0)
+
+	// every publish of an even height fails once before succeeding
+	pub := &publisherStub{failFirst: func(height uint64) bool { return height%2 == 0 }}
+	daser, err := NewDASer(light.TestAvailability(getters.NewIPLDGetter(bServ)),
+		sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false),
+		WithPublisher(pub, "sampled"),
+	)
+	require.NoError(t, err)
+	daser.publisher.backoff = time.Millisecond
+
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
+
+	require.Eventually(t, func() bool {
+		return len(pub.publishedHeights()) == 10
+	}, timeout, 10*time.Millisecond)
+
+	pub.lock.Lock()
+	defer pub.lock.Unlock()
+	for height := uint64(1); height <= 10; height++ {
+		msg, ok := pub.published[height]
+		require.True(t, ok, height)
+		assert.Equal(t, "sampled", msg.topic)
+		assert.EqualValues(t, height, msg.sample.Height)
+		assert.Equal(t, mockGet.headers[int64(height)].DataHash.String(), msg.sample.Root)
+
+		expectedAttempts := 1
+		if height%2 == 0 {
+			expectedAttempts = 2
+		}
+		assert.Equal(t, expectedAttempts, pub.attempts[height], height)
+	}
+}
+
+func TestSamplePublisher_FlushOnClose(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	t.Run("queued events are published", func(t *testing.T) {
+		pub := &publisherStub{failFirst: func(uint64) bool { return false }}
+		p := newSamplePublisher(pub, "sampled")
+		// events are queued before publishing starts, like the ones queued right before Stop
+		for height := uint64(1); height <= 3; height++ {
+			p.observe(sampleOutcome{height: height})
+		}
+		p.start()
+
+		require.NoError(t, p.close(ctx))
+		assert.ElementsMatch(t, []uint64{1, 2, 3}, pub.publishedHeights())
+		assert.Zero(t, p.dropped.Load())
+	})
+
+	t.Run("events are dropped after the flush timeout", func(t *testing.T) {
+		p := newSamplePublisher(blockingPublisher{}, "sampled")
+		p.flushTimeout = 10 * time.Millisecond
+		for height := uint64(1); height <= 3; height++ {
+			p.observe(sampleOutcome{height: height})
+		}
+		p.start()
+
+		require.NoError(t, p.close(ctx))
+		assert.EqualValues(t, 3, p.dropped.Load())
+	})
+}
+
+// blockingPublisher never publishes, blocking until the context is done.
+type blockingPublisher struct{}
+
+func (blockingPublisher) Publish(ctx context.Context, _ string, _ []byte) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+type publishedMsg struct {
+	topic  string
+	sample publishedSample
+}
+
+type publisherStub struct {
+	failFirst func(height uint64) bool
+
+	lock      sync.Mutex
+	attempts  map[uint64]int
+	published map[uint64]publishedMsg
+}
+
+func (p *publisherStub) Publish(_ context.Context, topic string, msg []byte) error {
+	var sample publishedSample
+	if err := json.Unmarshal(msg, &sample); err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.attempts == nil {
+		p.attempts = make(map[uint64]int)
+		p.published = make(map[uint64]publishedMsg)
+	}
+	p.attempts[sample.Height]++
+	if p.attempts[sample.Height] == 1 && p.failFirst(sample.Height) {
+		return errors.New("bus unavailable")
+	}
+	p.published[sample.Height] = publishedMsg{topic: topic, sample: sample}
+	return nil
+}
+
+func (p *publisherStub) publishedHeights() []uint64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	heights := make([]uint64, 0, len(p.published))
+	for h := range p.published {
+		heights = append(heights, h)
+	}
+	return heights
+}
+
+func TestDASer_EmptySquare(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	// availability must not be called for empty squares
+	avail := mocks.NewMockAvailability(gomock.NewController(t))
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	sub := new(headertest.Subscriber)
+	fserv := &fraudtest.DummyService[*header.ExtendedHeader]{}
+
+	// getterStub returns header at height 1 with square of size 0
+	daser, err := NewDASer(avail, sub, getterStub{}, ds, fserv, newBroadcastMock(1),
+		WithRecentSampling(false))
+	require.NoError(t, err)
+
+	require.NoError(t, daser.Start(ctx))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	require.NoError(t, daser.Stop(ctx))
+
+	cp, err := daser.store.load(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, cp.SampleFrom)
+	assert.Empty(t, cp.Failed)
+
+	// minimum square of an empty block
+	h := headertest.RandExtendedHeaderWithRoot(t, share.EmptyRoot())
+	require.NoError(t, daser.sample(ctx, h))
+}
+
+func TestDASer_RequiredNamespaces(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	getter := getters.NewIPLDGetter(bServ)
+	avail := light.TestAvailability(getter)
+	sub := new(headertest.Subscriber)
+	fserv := &fraudtest.DummyService[*header.ExtendedHeader]{}
+
+	required := sharetest.RandV0Namespace()
+	daser, err := NewDASer(avail, sub, getterStub{}, ds, fserv, newBroadcastMock(1),
+		WithRequiredNamespaces(getter, []share.Namespace{required}))
+	require.NoError(t, err)
+
+	// odd heights contain the required namespace, even heights don't
+	for height := 1; height <= 4; height++ {
+		dah := availability_test.RandFillBS(t, 4, bServ)
+		if height%2 == 1 {
+			shares := sharetest.RandSharesWithNamespace(t, required, 16)
+			dah = availability_test.FillBS(t, bServ, shares)
+		}
+		h := headertest.RandExtendedHeaderWithRoot(t, dah)
+		h.RawHeader.Height = int64(height)
+
+		require.NoError(t, daser.sample(ctx, h))
+	}
+
+	for height := uint64(1); height <= 4; height++ {
+		availability := daser.NamespaceAvailability(height)
+		require.Len(t, availability, 1)
+		assert.Equal(t, height%2 == 1, availability[required.String()], "height %d", height)
+	}
+	assert.Nil(t, daser.NamespaceAvailability(5))
+}
+
+func TestDASer_SubscribeFailures(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	getter := failingGetterStub{
+		head:    5,
+		failing: map[uint64]bool{2: true, 4: true},
+	}
+	avail := mocks.NewMockAvailability(gomock.NewController(t))
+	avail.EXPECT().SharesAvailable(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	sub := new(headertest.Subscriber)
+	fserv := &fraudtest.DummyService[*header.ExtendedHeader]{}
+
+	daser, err := NewDASer(avail, sub, getter, ds, fserv, newBroadcastMock(1))
+	require.NoError(t, err)
+
+	failures := daser.SubscribeFailures(ctx)
+	require.NoError(t, daser.Start(ctx))
+
+	got := make(map[uint64]FailureEvent)
+	for len(got) < len(getter.failing) {
+		select {
+		case ev := <-failures:
+			got[ev.Height] = ev
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+
+	for height := range getter.failing {
+		ev, ok := got[height]
+		require.True(t, ok, "no failure event for height %d", height)
+		assert.Equal(t, catchupJob, ev.Source)
+		assert.Equal(t, 1, ev.Attempt)
+		assert.ErrorIs(t, ev.Err, errGetterFailed)
+	}
+
+	require.NoError(t, daser.Stop(ctx))
+	// subscription should be closed after stop
+	for {
+		select {
+		case _, ok := <-failures:
+			if !ok {
+				return
+			}
+		case <-ctx.Done():
+			t.Fatal("failures subscription was not closed on stop")
+		}
+	}
+}
+
+func TestDASer_HeadRollback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	// getter head is at 15, below the checkpoint
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 15)
+	stored := checkpoint{SampleFrom: 21, NetworkHead: 20}
+	store := newCheckpointStore(ds)
+	require.NoError(t, store.store(ctx, stored))
+
+	var sampled atomic.Int64
+	avail := mocks.NewMockAvailability(gomock.NewController(t))
+	avail.EXPECT().SharesAvailable(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, *header.ExtendedHeader) error {
+			sampled.Add(1)
+			return nil
+		}).AnyTimes()
+
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false))
+	require.NoError(t, err)
+
+	require.NoError(t, daser.Start(ctx))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	stats, err := daser.SamplingStats(ctx)
+	require.NoError(t, err)
+	require.NoError(t, daser.Stop(ctx))
+
+	assert.EqualValues(t, 20, stats.NetworkHead)
+	assert.EqualValues(t, 20, stats.CatchupHead)
+	assert.Zero(t, sampled.Load())
+
+	cp, err := daser.store.load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, stored.SampleFrom, cp.SampleFrom)
+	assert.Equal(t, stored.NetworkHead, cp.NetworkHead)
+}
+
+func TestDASer_StartFrom(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 0)
+	getter := &recordingGetter{mockGetter: mockGet}
+
+	daser, err := NewDASer(avail, sub, getter, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false))
+	require.NoError(t, err)
+
+	const trustedHeight = 10
+	require.Error(t, daser.StartFrom(ctx, 0))
+	require.NoError(t, daser.StartFrom(ctx, trustedHeight))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	stats, err := daser.SamplingStats(ctx)
+	require.NoError(t, err)
+	require.NoError(t, daser.Stop(ctx))
+
+	assert.EqualValues(t, 15, stats.CatchupHead)
+	heights := getter.requestedHeights()
+	for height := uint64(trustedHeight); height <= 15; height++ {
+		assert.Contains(t, heights, height)
+	}
+	for _, height := range heights {
+		assert.GreaterOrEqual(t, height, uint64(trustedHeight))
+	}
+}
+
+// recordingGetter records heights of requested headers.
+type recordingGetter struct {
+	*mockGetter
+
+	lock    sync.Mutex
+	heights []uint64
+}
+
+func (g *recordingGetter) GetByHeight(ctx context.Context, height uint64) (*header.ExtendedHeader, error) {
+	g.lock.Lock()
+	g.heights = append(g.heights, height)
+	g.lock.Unlock()
+	return g.mockGetter.GetByHeight(ctx, height)
+}
+
+func (g *recordingGetter) requestedHeights() []uint64 {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return append([]uint64(nil), g.heights...)
+}
+
+func TestDASer_RecentSamplingDisabled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	// 15 headers from the past and 15 future headers
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 15)
+
+	var lk sync.Mutex
+	sampled := make(map[uint64]bool)
+	avail := mocks.NewMockAvailability(gomock.NewController(t))
+	avail.EXPECT().SharesAvailable(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, h *header.ExtendedHeader) error {
+			lk.Lock()
+			defer lk.Unlock()
+			sampled[h.Height()] = true
+			return nil
+		}).AnyTimes()
+
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false))
+	require.NoError(t, err)
+
+	require.NoError(t, daser.Start(ctx))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	require.NoError(t, daser.Stop(ctx))
+
+	// subscription should never be consumed
+	assert.Len(t, sub.Headers, 15)
+
+	lk.Lock()
+	defer lk.Unlock()
+	assert.Len(t, sampled, 15)
+	for height := range sampled {
+		assert.LessOrEqual(t, height, uint64(15))
+	}
+}
+
+func TestDASer_HealthWarmup(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 0)
+
+	// block sampling to keep the backlog
+	release := make(chan struct{})
+	avail := mocks.NewMockAvailability(gomock.NewController(t))
+	avail.EXPECT().SharesAvailable(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ *header.ExtendedHeader) error {
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}).AnyTimes()
+
+	warmup := time.Second
+	clk := clock.NewMock()
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithSamplingRange(1),
+		WithRecentSampling(false),
+		WithHealthWarmup(warmup),
+		WithClock(clk),
+	)
+	require.NoError(t, err)
+	require.False(t, daser.Healthy(ctx))
+
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
+	assert.True(t, daser.Healthy(ctx), "should be healthy during warmup")
+
+	clk.Add(warmup)
+	assert.False(t, daser.Healthy(ctx), "should be unhealthy with backlog after warmup")
+
+	close(release)
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	assert.True(t, daser.Healthy(ctx), "should be healthy after catch up")
+}
+
+func TestDASer_AuditLog(t *testing.T) {
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	// 15 headers from the past and 15 future headers
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 15)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	var out bytes.Buffer
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1), WithAuditLog(&out))
+	require.NoError(t, err)
+
+	require.NoError(t, daser.Start(ctx))
+	select {
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	case <-mockGet.doneCh:
+	}
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	require.NoError(t, daser.Stop(ctx))
+
+	cp, err := daser.store.load(ctx)
+	require.NoError(t, err)
+
+	// the log is flushed on stop, so every sampled height must be there
+	logged := make(map[uint64]bool)
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var rec auditRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		assert.Equal(t, auditOutcomeSampled, rec.Outcome)
+		assert.NotEmpty(t, rec.Root)
+		assert.False(t, rec.Time.IsZero())
+		logged[rec.Height] = true
+	}
+	require.NoError(t, scanner.Err())
+
+	for height := uint64(1); height < cp.SampleFrom; height++ {
+		assert.True(t, logged[height], "height %d is not logged", height)
+	}
+}
+
+// reverifyBlockingAvailability reports shares available right away, but blocks reverification
+// requested by Resample until the context is done.
+type reverifyBlockingAvailability struct {
+	blockingAvailability
+}
+
+func (a *reverifyBlockingAvailability) SharesAvailable(context.Context, *header.ExtendedHeader) error {
+	return nil
+}
+
+func (a *reverifyBlockingAvailability) ReverifyAvailable(ctx context.Context, h *header.ExtendedHeader) error {
+	return a.blockingAvailability.SharesAvailable(ctx, h)
+}
+
+func TestDASer_StopWithOnDemandSample(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 5, 0)
+
+	avail := &reverifyBlockingAvailability{blockingAvailability{started: make(chan struct{})}}
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithAuditLog(io.Discard))
+	require.NoError(t, err)
+	require.NoError(t, daser.Start(ctx))
+
+	resampled := make(chan error, 1)
+	go func() {
+		// the caller's context outlives the DASer
+		resampled <- daser.Resample(context.Background(), "", 1)
+	}()
+	select {
+	case <-avail.started:
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	}
+
+	// the in-flight sample is aborted and waited for before the audit log is closed
+	require.NoError(t, daser.Stop(ctx))
+	select {
+	case err := <-resampled:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	}
+
+	// no more samples are admitted once stopped
+	_, release, err := daser.onDemand.add(ctx)
+	require.ErrorIs(t, err, errSamplingStopped)
+	require.Nil(t, release)
+}
+
+func TestDASer_SelfTest(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	t.Run("broken getter", func(t *testing.T) {
+		bServ := ipld.NewMemBlockservice()
+		_, sub, mockService := createDASerSubcomponents(t, bServ, 1, 0)
+		ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+		daser, err := NewDASer(light.TestAvailability(getters.NewIPLDGetter(bServ)),
+			sub, brokenGetterStub{}, ds, mockService, newBroadcastMock(1), WithSelfTest(true))
+		require.NoError(t, err)
+
+		err = daser.SelfTest(ctx)
+		require.ErrorIs(t, err, ErrSelfTest)
+		require.ErrorIs(t, err, errGetterFailed)
+
+		// the DASer is not started
+		require.ErrorIs(t, daser.Start(ctx), ErrSelfTest)
+		assert.EqualValues(t, 0, atomic.LoadInt32(&daser.running))
+	})
+
+	t.Run("working getter", func(t *testing.T) {
+		bServ := ipld.NewMemBlockservice()
+		mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 5, 0)
+		ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+		daser, err := NewDASer(light.TestAvailability(getters.NewIPLDGetter(bServ)),
+			sub, mockGet, ds, mockService, newBroadcastMock(1), WithSelfTest(true))
+		require.NoError(t, err)
+
+		require.NoError(t, daser.Start(ctx))
+		require.NoError(t, daser.Stop(ctx))
+	})
+}
+
+func TestDASer_Warmup(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 5, 0)
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	getter := &warmupRecordingGetter{IPLDGetter: getters.NewIPLDGetter(bServ)}
+	daser, err := NewDASer(light.TestAvailability(getter),
+		sub, mockGet, ds, mockService, newBroadcastMock(1), WithWarmup(true))
+	require.NoError(t, err)
+	assert.True(t, daser.Config().Warmup)
+
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(context.Background()))
+	})
+	require.NoError(t, daser.WaitCatchUp(ctx))
+
+	events := getter.recorded()
+	require.NotEmpty(t, events)
+	// the getter is warmed up once, before the first sample
+	assert.Equal(t, "warmup", events[0])
+	assert.NotContains(t, events[1:], "warmup")
+	assert.Contains(t, events, "sample")
+}
+
+func TestDASer_DuplicateSubscriptionHeader(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	// headers with distinct roots, so the fake counts calls per header
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub := createMockGetterAndSub(t, bServ, 1, 1)
+	h := sub.Headers[0]
+	// the same header is delivered twice
+	sub.Headers = []*header.ExtendedHeader{h, h}
+
+	avail := availabilitytest.NewFake()
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	daser, err := NewDASer(avail, sub, mockGet, ds,
+		&fraudtest.DummyService[*header.ExtendedHeader]{}, newBroadcastMock(1))
+	require.NoError(t, err)
+
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(context.Background()))
+	})
+	require.NoError(t, daser.subscriber.wait(ctx))
+	require.Eventually(t, func() bool {
+		stats, err := daser.SamplingStats(ctx)
+		return err == nil && stats.SampledChainHead == 2
+	}, timeout, 10*time.Millisecond)
+
+	assert.EqualValues(t, 1, daser.subscriber.duplicates.Load())
+	assert.Equal(t, 1, avail.Calls(share.DataHash(h.DataHash)))
+}
+
+// warmupRecordingGetter records the order of warmups and samples of the wrapped getter.
+type warmupRecordingGetter struct {
+	*getters.IPLDGetter
+
+	lock   sync.Mutex
+	events []string
+}
+
+func (g *warmupRecordingGetter) Warmup(context.Context) error {
+	g.record("warmup")
+	return nil
+}
+
+func (g *warmupRecordingGetter) GetShare(
+	ctx context.Context, h *header.ExtendedHeader, row, col int,
+) (share.Share, error) {
+	g.record("sample")
+	return g.IPLDGetter.GetShare(ctx, h, row, col)
+}
+
+// GetShareWithProof records samples as well, as light availability fetches shares with proofs
+// when its proof cache is enabled.
+func (g *warmupRecordingGetter) GetShareWithProof(
+	ctx context.Context, h *header.ExtendedHeader, row, col int,
+) (*byzantine.ShareWithProof, error) {
+	g.record("sample")
+	return g.IPLDGetter.GetShareWithProof(ctx, h, row, col)
+}
+
+func (g *warmupRecordingGetter) GetSamples(
+	ctx context.Context, h *header.ExtendedHeader, coords []share.SampleCoord,
+) ([]share.SampleResult, error) {
+	g.record("sample")
+	return g.IPLDGetter.GetSamples(ctx, h, coords)
+}
+
+func (g *warmupRecordingGetter) record(event string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.events = append(g.events, event)
+}
+
+func (g *warmupRecordingGetter) recorded() []string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return append([]string(nil), g.events...)
+}
+
+func TestDASer_Config(t *testing.T) {
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 1, 0)
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	namespace := sharetest.RandV0Namespace()
+
+	daser, err := NewDASer(light.TestAvailability(getters.NewIPLDGetter(bServ)),
+		sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithSampleTimeout(time.Minute),
+		WithConcurrencyLimit(3),
+		WithSampleFrom(42),
+		WithRecentSampling(false),
+		WithCheckpointCodec(BinaryCodec{}),
+		WithRequiredNamespaces(getters.NewIPLDGetter(bServ), []share.Namespace{namespace}),
+	)
+	require.NoError(t, err)
+
+	expected := DefaultParameters()
+	expected.SampleTimeout = time.Minute
+	expected.ConcurrencyLimit = 3
+	expected.SampleFrom = 42
+
+	cfg := daser.Config()
+	assert.Equal(t, expected, cfg.Parameters)
+	assert.False(t, cfg.RecentSampling)
+	assert.False(t, cfg.AuditLog)
+	assert.Equal(t, "das.BinaryCodec", cfg.CheckpointCodec)
+	assert.Equal(t, []share.Namespace{namespace}, cfg.RequiredNamespaces)
+
+	// config is a copy
+	cfg.RequiredNamespaces[0] = nil
+	assert.Equal(t, []share.Namespace{namespace}, daser.Config().RequiredNamespaces)
+}
+
+func TestDASer_RemoteAvailability(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 0)
+
+	// the service reports some heights as unavailable
+	unavailable := map[uint64]bool{3: true, 7: true, 11: true}
+	avail := remote.TestAvailability(t, remote.ServerFunc(
+		func(_ context.Context, req *remote.SampleRequest) (*remote.SampleResponse, error) {
+			if unavailable[req.Height] {
+				return &remote.SampleResponse{Verdict: remote.VerdictUnavailable}, nil
+			}
+			return &remote.SampleResponse{Verdict: remote.VerdictAvailable}, nil
+		}))
+
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithRecentSampling(false))
+	require.NoError(t, err)
+	failures := daser.SubscribeFailures(ctx)
+
+	require.NoError(t, daser.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, daser.Stop(ctx))
+	})
+
+	failed := make(map[uint64]bool)
+	for len(failed) < len(unavailable) {
+		select {
+		case ev := <-failures:
+			require.ErrorIs(t, ev.Err, share.ErrNotAvailable)
+			failed[ev.Height] = true
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+	assert.Equal(t, unavailable, failed)
+
+	// all other heights are sampled successfully
+	require.Eventually(t, func() bool {
+		stats, err := daser.SamplingStats(ctx)
+		return err == nil && stats.CatchupHead == 15 && len(stats.Workers) == 0
+	}, timeout, 10*time.Millisecond)
+	stats, err := daser.SamplingStats(ctx)
+	require.NoError(t, err)
+	assert.Len(t, stats.Failed, len(unavailable))
+	for h := range unavailable {
+		assert.Contains(t, stats.Failed, h)
+	}
+	assert.EqualValues(t, 2, stats.SampledChainHead)
+}
+
+func TestDASer_SampleFromSnapshot(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	avail := light.TestAvailability(getters.NewIPLDGetter(bServ))
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 15, 15)
+
+	daser, err := NewDASer(avail, sub, mockGet, ds, mockService, newBroadcastMock(1),
+		WithBackgroundStoreInterval(time.Millisecond))
+	require.NoError(t, err)
+	assert.Zero(t, daser.SampleFrom())
+
+	// read snapshots while sampling advances the checkpoint
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var prev uint64
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				sampleFrom := daser.SampleFrom()
+				assert.GreaterOrEqual(t, sampleFrom, prev, "SampleFrom must not decrease")
+				prev = sampleFrom
+
+				// snapshot is a copy, so modifying it must not affect the store
+				if cp, ok := daser.store.loadSnapshot(); ok {
+					cp.Failed = map[uint64]int{1: 1}
+					cp.Workers = append(cp.Workers, workerCheckpoint{})
+				}
+			}
+		}()
+	}
+
+	require.NoError(t, daser.Start(ctx))
+	select {
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	case <-mockGet.doneCh:
+	}
+	require.NoError(t, daser.WaitCatchUp(ctx))
+	require.NoError(t, daser.Stop(ctx))
+	close(done)
+	wg.Wait()
+
+	cp, err := daser.store.load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, cp.SampleFrom, daser.SampleFrom())
+	snapshot, ok := daser.store.loadSnapshot()
+	require.True(t, ok)
+	assert.Equal(t, cp, snapshot)
+}
+
+func TestDASer_VerifyStoreIntegrity(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 1, 0)
+	daser, err := NewDASer(light.TestAvailability(getters.NewIPLDGetter(bServ)),
+		sub, mockGet, ds, mockService, newBroadcastMock(1))
+	require.NoError(t, err)
+
+	now := time.Now()
+	err = daser.store.store(ctx, checkpoint{
+		SampleFrom:  10,
+		NetworkHead: 20,
+		// 12 was not reached by catchup yet and 15 is both failed and sampled
+		Failed:  map[uint64]int{3: 1, 12: 1, 15: 2},
+		Sampled: map[uint64]time.Time{15: now, 16: now},
+	})
+	require.NoError(t, err)
+
+	heights, err := daser.VerifyStoreIntegrity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{12, 15}, heights)
+
+	// verification doesn't modify the store
+	cp, err := daser.store.load(ctx)
+	require.NoError(t, err)
+	assert.Len(t, cp.Failed, 3)
+
+	heights, err = daser.RepairStoreIntegrity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{12, 15}, heights)
+
+	cp, err = daser.store.load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[uint64]int{3: 1}, cp.Failed)
+
+	heights, err = daser.VerifyStoreIntegrity(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, heights)
+}
+
+func TestDASer_Iterate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	bServ := ipld.NewMemBlockservice()
+	mockGet, sub, mockService := createDASerSubcomponents(t, bServ, 20, 0)
+	daser, err := NewDASer(light.TestAvailability(getters.NewIPLDGetter(bServ)),
+		sub, mockGet, ds, mockService, newBroadcastMock(1))
+	require.NoError(t, err)
+
+	now := time.Now()
+	err = daser.store.store(ctx, checkpoint{
+		SampleFrom:  6,
+		NetworkHead: 20,
+		Failed:      map[uint64]int{3: 1},
+		Sampled:     map[uint64]time.Time{8: now, 12: now},
+	})
+	require.NoError(t, err)
+
+	it, err := daser.Iterate(ctx, 0)
+	require.NoError(t, err)
+	first, err := it.Next(ctx)
+	require.NoError(t, err)
+	root := share.DataHash(mockGet.headers[1].DataHash)
+	assert.Equal(t, SampledHeight{Height: 1, Outcome: auditOutcomeSampled, Root: root}, first)
+
+	// resume from the cursor, while the store is updated afterwards
+	it, err = daser.Iterate(ctx, it.Cursor())
+	require.NoError(t, err)
+	err = daser.store.store(ctx, checkpoint{SampleFrom: 21, NetworkHead: 20})
+	require.NoError(t, err)
+
+	var (
+		heights []uint64
+		failed  []uint64
+	)
+	for {
+		sh, err := it.Next(ctx)
+		if errors.Is(err, ErrIteratorDone) {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, share.DataHash(mockGet.headers[int64(sh.Height)].DataHash), sh.Root)
+		heights = append(heights, sh.Height)
+		if sh.Outcome == auditOutcomeFailed {
+			failed = append(failed, sh.Height)
+		}
+	}
+	assert.Equal(t, []uint64{2, 3, 4, 5, 8, 12}, heights)
+	assert.Equal(t, []uint64{3}, failed)
+	assert.EqualValues(t, 13, it.Cursor())
+}
+
+func TestDASer_BundleGetter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	// write a bundle of the first 3 heights
+	entries := make([]getters.BundleEntry, 3)
+	for i := range entries {
+		square := edstest.RandEDS(t, 4)
+		dah, err := share.NewRoot(square)
+		require.NoError(t, err)
+		h := headertest.RandExtendedHeaderWithRoot(t, dah)
+		h.RawHeader.Height = int64(i + 1)
+		entries[i] = getters.BundleEntry{Header: h, EDS: square}
+	}
+	path := filepath.Join(t.TempDir(), "bundle")
+	var buf bytes.Buffer
+	require.NoError(t, getters.WriteBundle(ctx, &buf, entries))
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+
+	bundle, err := getters.NewBundleGetter(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, bundle.Close()) })
+
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	sub := new(headertest.Subscriber)
+	mockService := &fraudtest.DummyService[*header.ExtendedHeader]{}
+	// sampling is served by the bundle alone
+	daser, err := NewDASer(light.TestAvailability(bundle), sub, bundle, ds, mockService,
+		newBroadcastMock(1), WithRecentSampling(false))
+	require.NoError(t, err)
+
+	require.NoError(t, daser.Start(ctx))
+	require.NoError(t, daser.WaitCatchUp(ctx))
+
+	stats, err := daser.SamplingStats(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, stats.CatchupHead)
+	assert.Empty(t, stats.Failed)
+	require.NoError(t, daser.Stop(ctx))
+
+	// heights not included in the bundle can't be sampled
+	dah, err := share.NewRoot(edstest.RandEDS(t, 4))
+	require.NoError(t, err)
+	_, err = bundle.GetEDS(ctx, headertest.RandExtendedHeaderWithRoot(t, dah))
+	require.ErrorIs(t, err, share.ErrNotFound)
+}
+
+func TestDASer_StrictContiguity(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	tests := []struct {
+		strict     bool
+		sampleFrom uint64
+	}{
+		{strict: true, sampleFrom: 3},
+		{strict: false, sampleFrom: 11},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("strict=%v", tt.strict), func(t *testing.T) {
+			// height 3 fails permanently
+			getter := failingGetterStub{head: 10, failing: map[uint64]bool{3: true}}
+			avail := mocks.NewMockAvailability(gomock.NewController(t))
+			avail.EXPECT().SharesAvailable(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+			sub := new(headertest.Subscriber)
+			fserv := &fraudtest.DummyService[*header.ExtendedHeader]{}
+
+			daser, err := NewDASer(avail, sub, getter, ds, fserv, newBroadcastMock(1),
+				WithRecentSampling(false), WithStrictContiguity(tt.strict))
+			require.NoError(t, err)
+			require.NoError(t, daser.Start(ctx))
+
+			require.Eventually(t, func() bool {
+				stats, err := daser.SamplingStats(ctx)
+				return err == nil && stats.CatchupHead == 10 && len(stats.Workers) == 0 && stats.Failed[3] > 0
+			}, timeout, 50*time.Millisecond)
+			require.NoError(t, daser.Stop(ctx))
+
+			cp, err := daser.store.load(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.sampleFrom, cp.SampleFrom)
+			assert.Contains(t, cp.Failed, uint64(3))
+		})
+	}
+}
+
+// createDASerSubcomponents takes numGetter (number of headers
+// to store in mockGetter) and numSub (number of headers to store
+// in the mock header.Subscriber), returning a newly instantiated
+// mockGetter, share.Availability, and mock header.Subscriber.
+func createDASerSubcomponents(
+	t *testing.T,
+	bServ blockservice.BlockService,
+	numGetter,
+	numSub int,
+) (*mockGetter, *headertest.Subscriber, *fraudtest.DummyService[*header.ExtendedHeader]) {
+	mockGet, sub := createMockGetterAndSub(t, bServ, numGetter, numSub)
+	fraud := &fraudtest.DummyService[*header.ExtendedHeader]{}
+	return mockGet, sub, fraud
+}
+
+func createMockGetterAndSub(
+	t *testing.T,
+	bServ blockservice.BlockService,
+	numGetter,
+	numSub int,
+) (*mockGetter, *headertest.Subscriber) {
+	mockGet := &mockGetter{
+		headers:        make(map[int64]*header.ExtendedHeader),
+		doneCh:         make(chan struct{}),
+		brokenHeightCh: make(chan struct{}),
+	}
+
+	mockGet.generateHeaders(t, bServ, 0, numGetter)
+
+	sub := new(headertest.Subscriber)
+	mockGet.fillSubWithHeaders(t, sub, bServ, numGetter, numGetter+numSub)
+	return mockGet, sub
+}
+
+// fillSubWithHeaders generates `num` headers from the future for p2pSub to pipe through to DASer.
+func (m *mockGetter) fillSubWithHeaders(
+	t *testing.T,
+	sub *headertest.Subscriber,
+	bServ blockservice.BlockService,
+	startHeight,
+	endHeight int,
+) {
+	sub.Headers = make([]*header.ExtendedHeader, endHeight-startHeight)
+
+	index := 0
+	for i := startHeight; i < endHeight; i++ {
+		dah := availability_test.RandFillBS(t, 16, bServ)
+
+		randHeader := headertest.RandExtendedHeaderWithRoot(t, dah)
+		randHeader.RawHeader.Height = int64(i + 1)
+
+		sub.Headers[index] = randHeader
+		// also checkpointStore to mock getter for duplicate sampling
+		m.headers[int64(i+1)] = randHeader
+
+		index++
+	}
+}
+
+type mockGetter struct {
+	getterStub
+	doneCh chan struct{} // signals all stored headers have been retrieved
+
+	brokenHeight   int64
+	brokenHeightCh chan struct{}
+
+	head    int64
+	headers map[int64]*header.ExtendedHeader
+}
+
+func (m *mockGetter) generateHeaders(t *testing.T, bServ blockservice.BlockService, startHeight, endHeight int) {
+	for i := startHeight; i < endHeight; i++ {
+		dah := availability_test.RandFillBS(t, 16, bServ)
+
+		randHeader := headertest.RandExtendedHeaderWithRoot(t, dah)
+		randHeader.RawHeader.Height = int64(i + 1)
+
+		m.headers[int64(i+1)] = randHeader
+	}
+	// set network head
+	m.head = int64(startHeight + endHeight)
+}
+
+func (m *mockGetter) Head(
+	context.Context,
+	...libhead.HeadOption[*header.ExtendedHeader],
+) (*header.ExtendedHeader, error) {
 	return m.headers[m.head], nil
 }
 
@@ -355,6 +3087,93 @@ func (m *mockGetter) GetByHeight(_ context.Context, height uint64) (*header.Exte
 	return m.headers[int64(height)], nil
 }
 
+var errGetterFailed = errors.New("getter failed")
+
+// failingGetterStub fails to get headers for the heights in failing.
+type failingGetterStub struct {
+	getterStub
+	head    uint64
+	failing map[uint64]bool
+}
+
+func (m failingGetterStub) Head(
+	context.Context,
+	...libhead.HeadOption[*header.ExtendedHeader],
+) (*header.ExtendedHeader, error) {
+	return &header.ExtendedHeader{RawHeader: header.RawHeader{Height: int64(m.head)}}, nil
+}
+
+func (m failingGetterStub) GetByHeight(ctx context.Context, height uint64) (*header.ExtendedHeader, error) {
+	if m.failing[height] {
+		return nil, errGetterFailed
+	}
+	return m.getterStub.GetByHeight(ctx, height)
+}
+
+// brokenGetterStub fails to get any header.
+type brokenGetterStub struct {
+	getterStub
+}
+
+func (m brokenGetterStub) Head(
+	context.Context,
+	...libhead.HeadOption[*header.ExtendedHeader],
+) (*header.ExtendedHeader, error) {
+	return nil, errGetterFailed
+}
+
+func (m brokenGetterStub) GetByHeight(context.Context, uint64) (*header.ExtendedHeader, error) {
+	return nil, errGetterFailed
+}
+
+// fraudBroadcasterStub sends broadcasted proofs to proofCh.
+type fraudBroadcasterStub struct {
+	proofCh chan fraud.Proof[*header.ExtendedHeader]
+}
+
+func (b *fraudBroadcasterStub) Broadcast(ctx context.Context, proof fraud.Proof[*header.ExtendedHeader]) error {
+	select {
+	case b.proofCh <- proof:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// fraudSubscriberStub delivers proofs sent to proofCh to subscribers.
+type fraudSubscriberStub struct {
+	fraudtest.DummyService[*header.ExtendedHeader]
+	proofCh chan fraud.Proof[*header.ExtendedHeader]
+}
+
+func (s *fraudSubscriberStub) Subscribe(fraud.ProofType) (fraud.Subscription[*header.ExtendedHeader], error) {
+	return s, nil
+}
+
+func (s *fraudSubscriberStub) AddVerifier(fraud.ProofType, fraud.Verifier[*header.ExtendedHeader]) error {
+	return nil
+}
+
+func (s *fraudSubscriberStub) Proof(ctx context.Context) (fraud.Proof[*header.ExtendedHeader], error) {
+	select {
+	case proof := <-s.proofCh:
+		return proof, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *fraudSubscriberStub) Cancel() {}
+
+// stuckGetter blocks header requests by height until the context is done.
+type stuckGetter struct {
+	*mockGetter
+}
+
+func (g *stuckGetter) GetByHeight(ctx context.Context, _ uint64) (*header.ExtendedHeader, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
 type benchGetterStub struct {
 	getterStub
 	header *header.ExtendedHeader
@@ -0,0 +1,44 @@
+package das
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+// SampleDetail is the complete record of a single sampling attempt, including the provenance of
+// the sampled data.
+type SampleDetail struct {
+	Height uint64
+	// Root is the data root of the sampled header. It is nil if the header could not be retrieved.
+	Root share.DataHash
+	// Source is the background job or on demand source the height was sampled by
+	Source   SampleSource
+	Duration time.Duration
+	// Providers are the peers that served shares for the height. They are only recorded by getters
+	// fetching data from remote peers.
+	Providers []peer.ID
+	// FetchedBytes is the amount of bytes fetched from the network to sample the height
+	FetchedBytes uint64
+	// Err is nil if the height was sampled successfully
+	Err error
+}
+
+// observeDetailed reports the detailed outcome of every sampling attempt to the configured
+// callback. Providers and fetched bytes are accumulated over all attempts for the height.
+func (d *DASer) observeDetailed(o sampleOutcome) {
+	detail := SampleDetail{
+		Height:       o.height,
+		Source:       o.source,
+		Duration:     o.duration,
+		Providers:    d.providers.get(o.height),
+		FetchedBytes: d.fetched.height(o.height),
+		Err:          o.err,
+	}
+	if o.header != nil {
+		detail.Root = share.DataHash(o.header.DataHash)
+	}
+	d.onSampledDetailed(detail)
+}
@@ -0,0 +1,68 @@
+package das
+
+import (
+	"context"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+// defaultDiskGuardInterval is the interval free disk space is checked at.
+const defaultDiskGuardInterval = 10 * time.Second
+
+// diskChecker returns the amount of free bytes available on the filesystem of the path.
+type diskChecker func(path string) (uint64, error)
+
+// diskGuard pauses sampling while free disk space is below the threshold.
+type diskGuard struct {
+	path         string
+	minFreeBytes uint64
+	interval     time.Duration
+	freeBytes    diskChecker
+	clock        clock.Clock
+}
+
+func newDiskGuard(path string, minFreeBytes uint64) *diskGuard {
+	return &diskGuard{
+		path:         path,
+		minFreeBytes: minFreeBytes,
+		interval:     defaultDiskGuardInterval,
+		freeBytes:    freeDiskSpace,
+		clock:        clock.New(),
+	}
+}
+
+// isLow reports whether free disk space is below the threshold. Failed checks are logged and do not
+// change the previous verdict.
+func (g *diskGuard) isLow(prev bool) bool {
+	free, err := g.freeBytes(g.path)
+	if err != nil {
+		log.Errorw("checking free disk space", "path", g.path, "err", err)
+		return prev
+	}
+
+	low := free < g.minFreeBytes
+	if low && !prev {
+		log.Warnw("free disk space is below threshold",
+			"path", g.path, "free", free, "threshold", g.minFreeBytes)
+	}
+	return low
+}
+
+// run periodically checks free disk space and pauses sampling while it is low.
+func (g *diskGuard) run(ctx context.Context, low bool, pause func(context.Context, PauseReason, bool)) {
+	ticker := g.clock.Ticker(g.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if curr := g.isLow(low); curr != low {
+				low = curr
+				pause(ctx, PauseDiskGuard, low)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,16 @@
+//go:build darwin || freebsd || linux
+
+package das
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func freeDiskSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, fmt.Errorf("das: statfs %s: %w", path, err)
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil //nolint:unconvert
+}
@@ -0,0 +1,104 @@
+package das
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+// equivocationWindow is the amount of the most recent heights data roots are remembered for to
+// detect equivocating headers.
+const equivocationWindow = 1024
+
+// ErrEquivocation is the error sampling is halted with once equivocating headers are detected and
+// the EquivocationHaltPolicy decides to halt.
+var ErrEquivocation = errors.New("das: equivocating headers detected")
+
+// headerSource is where a header was received from.
+type headerSource string
+
+const (
+	sourceSubscription headerSource = "subscription"
+	sourceGetter       headerSource = "getter"
+)
+
+// EquivocationEvent describes two headers with different data roots received for the same height.
+type EquivocationEvent struct {
+	Height uint64
+	// SeenRoot is the data root of the header seen first for the height
+	SeenRoot share.DataHash
+	// ReceivedRoot is the data root of the conflicting header
+	ReceivedRoot share.DataHash
+	// Source is where the conflicting header was received from
+	Source headerSource
+}
+
+// EquivocationHaltPolicy decides whether sampling is halted on the detected equivocation.
+type EquivocationHaltPolicy func(EquivocationEvent) bool
+
+// equivocationDetector remembers data roots of headers received for the most recent heights and
+// fans out EquivocationEvents to subscribers once a header with a different data root is received
+// for one of them.
+type equivocationDetector struct {
+	*eventFeed[EquivocationEvent]
+
+	lock    sync.Mutex
+	roots   map[uint64]share.DataHash
+	highest uint64
+}
+
+func newEquivocationDetector() *equivocationDetector {
+	return &equivocationDetector{
+		eventFeed: newEventFeed[EquivocationEvent](),
+		roots:     make(map[uint64]share.DataHash),
+	}
+}
+
+// check compares the data root of the header against the one seen before for its height, if any,
+// and publishes the event if they differ. The data root seen first is kept for the height.
+func (d *equivocationDetector) check(h *header.ExtendedHeader, source headerSource) (EquivocationEvent, bool) {
+	height, root := h.Height(), share.DataHash(h.DataHash)
+
+	d.lock.Lock()
+	seen, ok := d.roots[height]
+	if !ok {
+		d.remember(height, root)
+		d.lock.Unlock()
+		return EquivocationEvent{}, false
+	}
+	d.lock.Unlock()
+	if bytes.Equal(seen, root) {
+		return EquivocationEvent{}, false
+	}
+
+	ev := EquivocationEvent{
+		Height:       height,
+		SeenRoot:     seen,
+		ReceivedRoot: root,
+		Source:       source,
+	}
+	d.publish(ev)
+	return ev, true
+}
+
+// remember keeps the data root of the height, unless the height is outside the window. Heights
+// leaving the window are forgotten in batches to amortize the cost of pruning.
+func (d *equivocationDetector) remember(height uint64, root share.DataHash) {
+	if height+equivocationWindow <= d.highest {
+		return
+	}
+	d.roots[height] = root
+	d.highest = max(d.highest, height)
+
+	if len(d.roots) <= 2*equivocationWindow {
+		return
+	}
+	for h := range d.roots {
+		if h+equivocationWindow <= d.highest {
+			delete(d.roots, h)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package das
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// sampleEventVersion is the version of the schema SampleEvents are encoded with. It is bumped on
+// every incompatible change of the encodings.
+const sampleEventVersion = 1
+
+// errUnsupportedVersion is returned when decoding a SampleEvent encoded with an unknown schema.
+var errUnsupportedVersion = errors.New("das: unsupported sample event version")
+
+// sampleEventJSON is the JSON schema of SampleEvent.
+type sampleEventJSON struct {
+	Version  int               `json:"version"`
+	Height   uint64            `json:"height"`
+	Source   string            `json:"source"`
+	Duration int64             `json:"duration_ns"`
+	Err      *string           `json:"error,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// MarshalJSON encodes the event with the versioned schema. The error is encoded by its message.
+func (e SampleEvent) MarshalJSON() ([]byte, error) {
+	ev := sampleEventJSON{
+		Version:  sampleEventVersion,
+		Height:   e.Height,
+		Source:   string(e.Source),
+		Duration: int64(e.Duration),
+		Metadata: e.Metadata,
+	}
+	if e.Err != nil {
+		msg := e.Err.Error()
+		ev.Err = &msg
+	}
+	return json.Marshal(ev)
+}
+
+// UnmarshalJSON decodes the event encoded with MarshalJSON. The decoded error only retains the
+// message of the original one.
+func (e *SampleEvent) UnmarshalJSON(data []byte) error {
+	var ev sampleEventJSON
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return err
+	}
+	if ev.Version != sampleEventVersion {
+		return fmt.Errorf("%w: %d", errUnsupportedVersion, ev.Version)
+	}
+
+	*e = SampleEvent{
+		Height:   ev.Height,
+		Source:   SampleSource(ev.Source),
+		Duration: time.Duration(ev.Duration),
+		Metadata: ev.Metadata,
+	}
+	if ev.Err != nil {
+		e.Err = errors.New(*ev.Err)
+	}
+	return nil
+}
+
+// MarshalBinary encodes the event with the versioned compact binary schema: the version byte,
+// followed by the height, the source, the duration, the optional error message and the metadata
+// sorted by key. Integers are varint encoded and strings are prefixed with their length.
+func (e SampleEvent) MarshalBinary() ([]byte, error) {
+	buf := []byte{sampleEventVersion}
+	buf = binary.AppendUvarint(buf, e.Height)
+	buf = appendString(buf, string(e.Source))
+	buf = binary.AppendVarint(buf, int64(e.Duration))
+	if e.Err != nil {
+		buf = append(buf, 1)
+		buf = appendString(buf, e.Err.Error())
+	} else {
+		buf = append(buf, 0)
+	}
+
+	keys := make([]string, 0, len(e.Metadata))
+	for k := range e.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	buf = binary.AppendUvarint(buf, uint64(len(keys)))
+	for _, k := range keys {
+		buf = appendString(buf, k)
+		buf = appendString(buf, e.Metadata[k])
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes the event encoded with MarshalBinary. The decoded error only retains the
+// message of the original one.
+func (e *SampleEvent) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("das: empty sample event")
+	}
+	if data[0] != sampleEventVersion {
+		return fmt.Errorf("%w: %d", errUnsupportedVersion, data[0])
+	}
+
+	r := &eventReader{data: data[1:]}
+	ev := SampleEvent{
+		Height:   r.uvarint(),
+		Source:   SampleSource(r.string()),
+		Duration: time.Duration(r.varint()),
+	}
+	if r.byte() == 1 {
+		ev.Err = errors.New(r.string())
+	}
+	if n := r.uvarint(); n > 0 && r.err == nil {
+		ev.Metadata = make(SampleMetadata, min(n, uint64(len(r.data))))
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			k := r.string()
+			ev.Metadata[k] = r.string()
+		}
+	}
+	if r.err != nil {
+		return fmt.Errorf("das: decoding sample event: %w", r.err)
+	}
+	if len(r.data) > 0 {
+		return fmt.Errorf("das: decoding sample event: %d trailing bytes", len(r.data))
+	}
+	*e = ev
+	return nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// eventReader reads fields of the binary encoded SampleEvent, remembering the first error.
+type eventReader struct {
+	data []byte
+	err  error
+}
+
+func (r *eventReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = errors.New("malformed uvarint")
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *eventReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.err = errors.New("malformed varint")
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *eventReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) == 0 {
+		r.err = errors.New("unexpected end of data")
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+func (r *eventReader) string() string {
+	l := r.uvarint()
+	if r.err != nil {
+		return ""
+	}
+	if l > uint64(len(r.data)) {
+		r.err = errors.New("unexpected end of data")
+		return ""
+	}
+	s := string(r.data[:l])
+	r.data = r.data[l:]
+	return s
+}
@@ -0,0 +1,85 @@
+package das
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleEvent_Encoding(t *testing.T) {
+	events := map[string]SampleEvent{
+		"failed on demand": {
+			Height:   42,
+			Source:   manualJob,
+			Duration: 1500 * time.Millisecond,
+			Err:      errors.New("das: sample timed out"),
+			Metadata: SampleMetadata{"request": "7", "caller": "rollup"},
+		},
+		"sampled": {
+			Height:   1,
+			Source:   catchupJob,
+			Duration: time.Millisecond,
+		},
+		"empty error": {
+			Height: 3,
+			Source: retryJob,
+			Err:    errors.New(""),
+		},
+	}
+
+	encodings := map[string]struct {
+		marshal   func(SampleEvent) ([]byte, error)
+		unmarshal func([]byte, *SampleEvent) error
+	}{
+		"binary": {
+			marshal:   SampleEvent.MarshalBinary,
+			unmarshal: func(data []byte, ev *SampleEvent) error { return ev.UnmarshalBinary(data) },
+		},
+		"json": {
+			marshal:   func(ev SampleEvent) ([]byte, error) { return json.Marshal(ev) },
+			unmarshal: func(data []byte, ev *SampleEvent) error { return json.Unmarshal(data, ev) },
+		},
+	}
+
+	for encName, enc := range encodings {
+		for evName, ev := range events {
+			t.Run(encName+"/"+evName, func(t *testing.T) {
+				data, err := enc.marshal(ev)
+				require.NoError(t, err)
+
+				var got SampleEvent
+				require.NoError(t, enc.unmarshal(data, &got))
+				assert.Equal(t, ev.Height, got.Height)
+				assert.Equal(t, ev.Source, got.Source)
+				assert.Equal(t, ev.Duration, got.Duration)
+				assert.Equal(t, ev.Metadata, got.Metadata)
+				if ev.Err == nil {
+					assert.NoError(t, got.Err)
+				} else {
+					require.Error(t, got.Err)
+					assert.Equal(t, ev.Err.Error(), got.Err.Error())
+				}
+			})
+		}
+	}
+
+	t.Run("unsupported version", func(t *testing.T) {
+		data, err := events["sampled"].MarshalBinary()
+		require.NoError(t, err)
+		data[0] = sampleEventVersion + 1
+		var ev SampleEvent
+		require.ErrorIs(t, ev.UnmarshalBinary(data), errUnsupportedVersion)
+		require.ErrorIs(t, json.Unmarshal([]byte(`{"version":2,"height":1}`), &ev), errUnsupportedVersion)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		data, err := events["failed on demand"].MarshalBinary()
+		require.NoError(t, err)
+		var ev SampleEvent
+		require.Error(t, ev.UnmarshalBinary(data[:len(data)-1]))
+	})
+}
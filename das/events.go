@@ -0,0 +1,85 @@
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// manualJob is the source of samples requested on demand via SampleRange, Resample or
+// InvestigateAround, unless another one is given.
+const manualJob SampleSource = "manual"
+
+// SampleMetadata is arbitrary metadata of the caller attached to SampleEvents and audit records of
+// samples requested on demand.
+type SampleMetadata map[string]string
+
+type sampleMetadataKey struct{}
+
+// WithSampleMetadata returns a copy of the context carrying the given SampleMetadata. Passing the
+// context to SampleRange or Resample attaches the metadata to the resulting SampleEvents and audit
+// records. The metadata must not be modified afterwards.
+func WithSampleMetadata(ctx context.Context, md SampleMetadata) context.Context {
+	return context.WithValue(ctx, sampleMetadataKey{}, md)
+}
+
+// maxSourceLength is the maximum length of sources of samples on demand in bytes.
+const maxSourceLength = 64
+
+// errReservedSource is returned for samples requested on demand tagged with a source reserved for
+// background samples or aggregation.
+var errReservedSource = errors.New("das: sample source is reserved")
+
+// onDemandSource validates the source samples requested on demand are tagged with, e.g. the name
+// of the subsystem requesting them. The empty source is reported as manualJob.
+func onDemandSource(source SampleSource) (SampleSource, error) {
+	switch {
+	case source == "":
+		return manualJob, nil
+	case source == catchupJob || source == recentJob || source == retryJob || source == otherSource:
+		return "", fmt.Errorf("%w: %s", errReservedSource, source)
+	case len(source) > maxSourceLength:
+		return "", fmt.Errorf("das: sample source is longer than %d bytes", maxSourceLength)
+	default:
+		return source, nil
+	}
+}
+
+// sampleMetadataFrom returns the SampleMetadata carried by the context, if any.
+func sampleMetadataFrom(ctx context.Context) SampleMetadata {
+	md, _ := ctx.Value(sampleMetadataKey{}).(SampleMetadata)
+	return md
+}
+
+// SampleEvent describes the outcome of a single sampling attempt.
+type SampleEvent struct {
+	Height uint64
+	// Source is the background job or on demand source the height was sampled by
+	Source   SampleSource
+	Duration time.Duration
+	// Err is nil if the height was sampled successfully
+	Err error
+	// Metadata is the SampleMetadata of the on demand request. It is empty for background samples.
+	Metadata SampleMetadata
+}
+
+// sampleFeed fans out SampleEvents to subscribers.
+type sampleFeed struct {
+	*eventFeed[SampleEvent]
+}
+
+func newSampleFeed() *sampleFeed {
+	return &sampleFeed{newEventFeed[SampleEvent]()}
+}
+
+// observe publishes an event for every sampling attempt.
+func (f *sampleFeed) observe(o sampleOutcome) {
+	f.publish(SampleEvent{
+		Height:   o.height,
+		Source:   o.source,
+		Duration: o.duration,
+		Err:      o.err,
+		Metadata: o.metadata,
+	})
+}
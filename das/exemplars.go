@@ -0,0 +1,107 @@
+package das
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sampleTimeBuckets are upper bounds of the sample time histogram buckets in seconds. They match
+// the default bucket boundaries of the OpenTelemetry SDK the histogram is aggregated with.
+var sampleTimeBuckets = []float64{0, 5, 10, 25, 50, 75, 100, 250, 500, 750, 1000, 2500, 5000, 7500, 10000}
+
+// labels of OpenMetrics exemplars linking sample times to traces
+const (
+	traceIDLabel = "trace_id"
+	spanIDLabel  = "span_id"
+)
+
+// Exemplar links a sample time recorded in the sample time histogram to the trace of the sample.
+type Exemplar struct {
+	// Bucket is the upper bound of the histogram bucket in seconds the sample time falls into.
+	Bucket     float64
+	Height     uint64
+	SampleTime time.Duration
+	TraceID    trace.TraceID
+	SpanID     trace.SpanID
+	Time       time.Time
+}
+
+// exemplars keeps the most recent exemplar for each bucket of the sample time histogram. If a
+// Prometheus histogram is set, sample times are also observed in it together with trace and span
+// IDs of the samples, so they are exposed as OpenMetrics exemplars.
+type exemplars struct {
+	lock    sync.Mutex
+	buckets map[float64]Exemplar
+	hist    prometheus.Histogram
+}
+
+func newExemplars() *exemplars {
+	return &exemplars{buckets: make(map[float64]Exemplar)}
+}
+
+// setHistogram sets the Prometheus histogram sample times are observed in with their exemplars.
+func (e *exemplars) setHistogram(hist prometheus.Histogram) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.hist = hist
+}
+
+// observe records the exemplar of the sample, if the sample is traced. The sample time is observed
+// in the Prometheus histogram, if set, with the exemplar attached.
+func (e *exemplars) observe(ctx context.Context, height uint64, sampleTime time.Duration, now time.Time) {
+	sc := trace.SpanContextFromContext(ctx)
+	traced := sc.IsValid() && sc.IsSampled()
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.hist != nil {
+		observer, ok := e.hist.(prometheus.ExemplarObserver)
+		if traced && ok {
+			observer.ObserveWithExemplar(sampleTime.Seconds(), prometheus.Labels{
+				traceIDLabel: sc.TraceID().String(),
+				spanIDLabel:  sc.SpanID().String(),
+			})
+		} else {
+			e.hist.Observe(sampleTime.Seconds())
+		}
+	}
+	if !traced {
+		return
+	}
+
+	bucket := math.Inf(1)
+	idx := sort.SearchFloat64s(sampleTimeBuckets, sampleTime.Seconds())
+	if idx < len(sampleTimeBuckets) {
+		bucket = sampleTimeBuckets[idx]
+	}
+	e.buckets[bucket] = Exemplar{
+		Bucket:     bucket,
+		Height:     height,
+		SampleTime: sampleTime,
+		TraceID:    sc.TraceID(),
+		SpanID:     sc.SpanID(),
+		Time:       now,
+	}
+}
+
+// get returns exemplars ordered by bucket.
+func (e *exemplars) get() []Exemplar {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if len(e.buckets) == 0 {
+		return nil
+	}
+
+	out := make([]Exemplar, 0, len(e.buckets))
+	for _, ex := range e.buckets {
+		out = append(out, ex)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Bucket < out[j].Bucket })
+	return out
+}
@@ -0,0 +1,33 @@
+package das
+
+// FailureEvent describes a single failed attempt to sample a header.
+type FailureEvent struct {
+	Height uint64
+	// Source is the type of job the height was sampled by
+	Source SampleSource
+	Err    error
+	// Attempt is the number of the sampling attempt for the height, starting from 1
+	Attempt int
+}
+
+// failureFeed fans out FailureEvents to subscribers.
+type failureFeed struct {
+	*eventFeed[FailureEvent]
+}
+
+func newFailureFeed() *failureFeed {
+	return &failureFeed{newEventFeed[FailureEvent]()}
+}
+
+// observe publishes an event for a failed sampling attempt.
+func (f *failureFeed) observe(o sampleOutcome) {
+	if o.err == nil {
+		return
+	}
+	f.publish(FailureEvent{
+		Height:  o.height,
+		Source:  o.source,
+		Err:     o.err,
+		Attempt: o.attempt,
+	})
+}
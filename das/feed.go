@@ -0,0 +1,93 @@
+package das
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// feedSubscriptionBuffer is the amount of events buffered for each subscriber. Events are dropped
+// for subscribers with full buffer, so slow consumers never stall sampling.
+const feedSubscriptionBuffer = 64
+
+// eventFeed fans out events to subscribers.
+type eventFeed[T any] struct {
+	lock   sync.Mutex
+	subs   map[chan T]struct{}
+	closed bool
+	doneCh chan struct{}
+
+	// dropped counts events that were not delivered due to slow subscribers
+	dropped atomic.Uint64
+}
+
+func newEventFeed[T any]() *eventFeed[T] {
+	return &eventFeed[T]{
+		subs:   make(map[chan T]struct{}),
+		doneCh: make(chan struct{}),
+	}
+}
+
+// subscribe returns a channel receiving events until the given context is done or the feed is
+// closed.
+func (f *eventFeed[T]) subscribe(ctx context.Context) <-chan T {
+	ch := make(chan T, feedSubscriptionBuffer)
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.closed {
+		close(ch)
+		return ch
+	}
+	f.subs[ch] = struct{}{}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			f.unsubscribe(ch)
+		case <-f.doneCh:
+		}
+	}()
+	return ch
+}
+
+func (f *eventFeed[T]) unsubscribe(ch chan T) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if _, ok := f.subs[ch]; ok {
+		delete(f.subs, ch)
+		close(ch)
+	}
+}
+
+// publish delivers the event to all subscribers without blocking.
+func (f *eventFeed[T]) publish(ev T) {
+	if f == nil {
+		return
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for ch := range f.subs {
+		select {
+		case ch <- ev:
+		default:
+			f.dropped.Add(1)
+		}
+	}
+}
+
+// close closes all subscriptions. Subsequent subscriptions are closed immediately.
+func (f *eventFeed[T]) close() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.closed {
+		return
+	}
+	f.closed = true
+	close(f.doneCh)
+	for ch := range f.subs {
+		delete(f.subs, ch)
+		close(ch)
+	}
+}
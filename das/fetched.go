@@ -0,0 +1,65 @@
+package das
+
+import (
+	"sync"
+)
+
+// fetchedBytesHistory is the amount of the most recent heights fetched bytes are reported for.
+const fetchedBytesHistory = 1024
+
+// fetchedBytes accounts the bytes fetched from the network to sample headers.
+type fetchedBytes struct {
+	lock      sync.Mutex
+	total     uint64
+	perHeight map[uint64]uint64
+}
+
+func newFetchedBytes() *fetchedBytes {
+	return &fetchedBytes{perHeight: make(map[uint64]uint64)}
+}
+
+// add accounts bytes fetched to sample the height. Once the history is full, the lowest height is
+// evicted.
+func (f *fetchedBytes) add(height, size uint64) {
+	if size == 0 {
+		return
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.total += size
+	f.perHeight[height] += size
+	if len(f.perHeight) <= fetchedBytesHistory {
+		return
+	}
+
+	lowest := height
+	for h := range f.perHeight {
+		if h < lowest {
+			lowest = h
+		}
+	}
+	delete(f.perHeight, lowest)
+}
+
+// get returns the total amount of fetched bytes and a copy of bytes fetched per height.
+func (f *fetchedBytes) get() (uint64, map[uint64]uint64) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if len(f.perHeight) == 0 {
+		return f.total, nil
+	}
+
+	perHeight := make(map[uint64]uint64, len(f.perHeight))
+	for h, size := range f.perHeight {
+		perHeight[h] = size
+	}
+	return f.total, perHeight
+}
+
+// height returns the amount of bytes fetched to sample the height.
+func (f *fetchedBytes) height(height uint64) uint64 {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.perHeight[height]
+}
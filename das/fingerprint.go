@@ -0,0 +1,59 @@
+package das
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/tendermint/tendermint/crypto/merkle"
+)
+
+// fingerprint leaf prefixes distinguish the sampled chain head from failed heights.
+const (
+	fingerprintSampled byte = iota
+	fingerprintFailed
+)
+
+// StateFingerprint returns a compact fingerprint of the sampling state, so the states of two nodes
+// could be compared to debug their divergence. The fingerprint is the Merkle root over the hash of
+// the header at the SampledChainHead, which commits to all the headers before it, followed by the
+// failed heights together with their data roots in ascending order. Retry counts and heights
+// sampled ahead of the catchup are not accounted for, as they depend on timing. Nodes that sampled
+// the same chain up to the same height produce the same fingerprint.
+func (d *DASer) StateFingerprint(ctx context.Context) ([]byte, error) {
+	stats, err := d.SamplingStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	leaves := make([][]byte, 0, len(stats.Failed)+1)
+	if stats.SampledChainHead > 0 {
+		h, err := d.getter.GetByHeight(ctx, stats.SampledChainHead)
+		if err != nil {
+			return nil, fmt.Errorf("das: getting header of sampled chain head %d: %w", stats.SampledChainHead, err)
+		}
+		leaves = append(leaves, fingerprintLeaf(fingerprintSampled, h.Height(), h.Hash()))
+	}
+
+	failed := make([]uint64, 0, len(stats.Failed))
+	for height := range stats.Failed {
+		failed = append(failed, height)
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i] < failed[j] })
+	for _, height := range failed {
+		h, err := d.getter.GetByHeight(ctx, height)
+		if err != nil {
+			return nil, fmt.Errorf("das: getting header of failed height %d: %w", height, err)
+		}
+		leaves = append(leaves, fingerprintLeaf(fingerprintFailed, height, h.DataHash))
+	}
+	return merkle.HashFromByteSlices(leaves), nil
+}
+
+func fingerprintLeaf(prefix byte, height uint64, hash []byte) []byte {
+	leaf := make([]byte, 0, 1+8+len(hash))
+	leaf = append(leaf, prefix)
+	leaf = binary.BigEndian.AppendUint64(leaf, height)
+	return append(leaf, hash...)
+}
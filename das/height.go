@@ -0,0 +1,38 @@
+package das
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxHeight is the highest height the DASer samples. Higher heights are rejected, so the height
+// following any sampled one, e.g. SampleFrom of the checkpoint, never overflows.
+const maxHeight = math.MaxUint64 - 1
+
+// ErrHeightOutOfRange is returned for heights the DASer can't sample, i.e. 0 or above the maximum.
+var ErrHeightOutOfRange = errors.New("das: height out of range")
+
+// validateHeight returns ErrHeightOutOfRange if the height can't be sampled.
+func validateHeight(height uint64) error {
+	if height == 0 || height > maxHeight {
+		return fmt.Errorf("%w: %d", ErrHeightOutOfRange, height)
+	}
+	return nil
+}
+
+// prevHeight returns the height preceding the given one, or 0 for height 0.
+func prevHeight(height uint64) uint64 {
+	if height == 0 {
+		return 0
+	}
+	return height - 1
+}
+
+// heightsBetween returns the amount of heights the head is ahead of the tail, or 0 if it is not.
+func heightsBetween(tail, head uint64) uint64 {
+	if head < tail {
+		return 0
+	}
+	return head - tail
+}
@@ -0,0 +1,134 @@
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/celestiaorg/celestia-node/header"
+)
+
+// inflightSamples deduplicates samples of the same height running concurrently, e.g. once catchup
+// approaches the network head and reaches a height that is still sampled by a recent job. A sample
+// of a height that is already being sampled waits for the running sample and shares its verdict
+// instead of fetching the data again.
+type inflightSamples struct {
+	lock    sync.Mutex
+	samples map[uint64]*inflightSample
+}
+
+// sharedVerdict is returned by a sample that joined the running sample of the same height. It
+// carries the verdict of the running sample, which reports it, so the joined sample is not reported
+// again.
+type sharedVerdict struct {
+	err error
+}
+
+func (v *sharedVerdict) Error() string {
+	if v.err == nil {
+		return "das: shared verdict: available"
+	}
+	return "das: shared verdict: " + v.err.Error()
+}
+
+func (v *sharedVerdict) Unwrap() error {
+	return v.err
+}
+
+// unwrapShared returns whether the sample was deduplicated and the verdict carried by a
+// sharedVerdict.
+func unwrapShared(err error) (bool, error) {
+	var shared *sharedVerdict
+	if errors.As(err, &shared) {
+		return true, shared.err
+	}
+	return false, err
+}
+
+// inflightSample is a running sample of a height.
+type inflightSample struct {
+	dataHash []byte
+	done     chan struct{}
+	err      error
+	// abandoned indicates the sample was canceled before its verdict was known
+	abandoned bool
+	// waiters is the amount of samples waiting for the verdict
+	waiters int
+}
+
+func newInflightSamples() *inflightSamples {
+	return &inflightSamples{samples: make(map[uint64]*inflightSample)}
+}
+
+// wrap returns the sampleFn that deduplicates samples of the given one. Samples of the same height,
+// but a different data root, e.g. after a reorg, are not deduplicated. If the running sample is
+// abandoned, the waiting one samples the height itself. The verdict shared with a waiting sample
+// is wrapped in sharedVerdict.
+func (s *inflightSamples) wrap(sample sampleFn) sampleFn {
+	return func(ctx context.Context, h *header.ExtendedHeader) error {
+		for {
+			running, ok := s.join(h)
+			if !ok {
+				return s.run(ctx, h, sample)
+			}
+
+			select {
+			case <-running.done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			if !running.abandoned {
+				return &sharedVerdict{err: running.err}
+			}
+		}
+	}
+}
+
+// join returns the running sample of the header's height to wait for, if any.
+func (s *inflightSamples) join(h *header.ExtendedHeader) (*inflightSample, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	running, ok := s.samples[h.Height()]
+	if !ok || !bytes.Equal(running.dataHash, h.DataHash) {
+		return nil, false
+	}
+	running.waiters++
+	log.Debugw("height is already being sampled, waiting for the verdict", "height", h.Height())
+	return running, true
+}
+
+// run samples the header, sharing the verdict with samples of the same height joining meanwhile.
+func (s *inflightSamples) run(ctx context.Context, h *header.ExtendedHeader, sample sampleFn) error {
+	s.lock.Lock()
+	own := &inflightSample{dataHash: h.DataHash, done: make(chan struct{})}
+	_, taken := s.samples[h.Height()]
+	if !taken {
+		// the height could be taken by a sample of a different data root, which is not shared
+		s.samples[h.Height()] = own
+	}
+	s.lock.Unlock()
+
+	err := sample(ctx, h)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	own.err = err
+	own.abandoned = errors.Is(ctx.Err(), context.Canceled)
+	close(own.done)
+	if !taken {
+		delete(s.samples, h.Height())
+	}
+	return err
+}
+
+// waiting returns the amount of samples waiting for the verdict of the running sample of the
+// height.
+func (s *inflightSamples) waiting(height uint64) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if running, ok := s.samples[height]; ok {
+		return running.waiters
+	}
+	return 0
+}
@@ -0,0 +1,67 @@
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ProbeResult is the outcome of probing a single height by InvestigateAround.
+type ProbeResult struct {
+	Height   uint64
+	Duration time.Duration
+	// Err is nil if availability of the height was verified
+	Err error
+}
+
+// InvestigateAround re-samples heights in the window [height-window, height+window] around the
+// given height, e.g. to assess the blast radius of a fault detected at it. The window is clipped to
+// the heights between 1 and the network head. Availability is verified again even if it was
+// verified before, like with Resample. Probes are sampled on demand, so they do not affect the
+// checkpoint and are reported under the given source like with SampleRange. Results are returned in
+// ascending order of heights. Failures of probes are reported in the results, while the returned
+// error is only set if probing could not be done.
+func (d *DASer) InvestigateAround(
+	ctx context.Context,
+	source SampleSource,
+	height, window uint64,
+) ([]ProbeResult, error) {
+	if atomic.LoadInt32(&d.running) == 0 {
+		return nil, errors.New("das: DASer is not running")
+	}
+	if err := validateHeight(height); err != nil {
+		return nil, err
+	}
+
+	stats, err := d.sampler.stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	from := height - min(window, prevHeight(height))
+	to := height + min(window, heightsBetween(height, maxHeight))
+	to = min(to, stats.NetworkHead)
+	if from > to {
+		return nil, fmt.Errorf("das: height %d is above the network head %d", height, stats.NetworkHead)
+	}
+
+	source, err = onDemandSource(source)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, reverifyKey{}, true)
+	md := sampleMetadataFrom(ctx)
+	results := make([]ProbeResult, 0, to-from+1)
+	for h := from; ; h++ {
+		start := d.clock.Now()
+		err := d.sampleOnDemand(ctx, h, source, md)
+		if errors.Is(err, context.Canceled) || errors.Is(err, errSamplingStopped) {
+			return results, err
+		}
+		results = append(results, ProbeResult{Height: h, Duration: d.clock.Since(start), Err: err})
+		if h == to {
+			return results, nil
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ipfs/go-datastore"
+
+	libhead "github.com/celestiaorg/go-header"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+// ErrIteratorDone is returned by Iterator.Next once all heights are visited.
+var ErrIteratorDone = errors.New("das: iterator done")
+
+// SampledHeight describes the sampling outcome of a single height recorded in the store.
+type SampledHeight struct {
+	Height uint64 `json:"height"`
+	// Outcome is either "sampled" or "failed".
+	Outcome string `json:"outcome"`
+	// Root is the data root of the header at Height.
+	Root share.DataHash `json:"root"`
+}
+
+// Iterator yields sampling outcomes in ascending order of heights.
+type Iterator interface {
+	// Next returns the outcome of the next height or ErrIteratorDone once all heights are visited.
+	Next(ctx context.Context) (SampledHeight, error)
+	// Cursor returns the height the iteration can be resumed from with DASer.Iterate.
+	Cursor() uint64
+}
+
+// Iterate returns an Iterator over sampling outcomes of heights starting from the given one, as
+// recorded in the persisted checkpoint. The iterator reads from a snapshot of the checkpoint taken
+// on the call, so it is consistent even if the store is updated during the iteration.
+func (d *DASer) Iterate(ctx context.Context, from uint64) (Iterator, error) {
+	cp, err := d.store.load(ctx)
+	switch {
+	case errors.Is(err, datastore.ErrNotFound):
+		cp = checkpoint{}
+	case err != nil:
+		return nil, fmt.Errorf("das: loading checkpoint: %w", err)
+	}
+	return newCheckpointIterator(d.getter, cp, from), nil
+}
+
+// checkpointIterator iterates over a checkpoint: heights below SampleFrom are all sampled unless
+// failed, while heights above are only sampled if recorded as sampled ahead or failed.
+type checkpointIterator struct {
+	getter libhead.Getter[*header.ExtendedHeader]
+	cp     checkpoint
+	// ahead are sorted heights not below SampleFrom with a recorded outcome
+	ahead []uint64
+	next  uint64
+}
+
+func newCheckpointIterator(
+	getter libhead.Getter[*header.ExtendedHeader],
+	cp checkpoint,
+	from uint64,
+) *checkpointIterator {
+	var ahead []uint64
+	for h := range cp.Sampled {
+		if h >= cp.SampleFrom {
+			ahead = append(ahead, h)
+		}
+	}
+	for h := range cp.Failed {
+		if _, ok := cp.Sampled[h]; !ok && h >= cp.SampleFrom {
+			ahead = append(ahead, h)
+		}
+	}
+	sort.Slice(ahead, func(i, j int) bool { return ahead[i] < ahead[j] })
+	return &checkpointIterator{
+		getter: getter,
+		cp:     cp,
+		ahead:  ahead,
+		next:   max(from, 1),
+	}
+}
+
+func (it *checkpointIterator) Next(ctx context.Context) (SampledHeight, error) {
+	height, ok := it.nextHeight()
+	if !ok {
+		return SampledHeight{}, ErrIteratorDone
+	}
+
+	h, err := it.getter.GetByHeight(ctx, height)
+	if err != nil {
+		return SampledHeight{}, fmt.Errorf("das: getting header at height %d: %w", height, err)
+	}
+	if h == nil {
+		return SampledHeight{}, fmt.Errorf("%w: height %d", ErrHeaderNotFound, height)
+	}
+	it.next = height + 1
+
+	outcome := auditOutcomeSampled
+	if _, failed := it.cp.Failed[height]; failed {
+		outcome = auditOutcomeFailed
+	}
+	return SampledHeight{
+		Height:  height,
+		Outcome: outcome,
+		Root:    share.DataHash(h.DataHash),
+	}, nil
+}
+
+func (it *checkpointIterator) Cursor() uint64 {
+	return it.next
+}
+
+// nextHeight returns the lowest height with a recorded outcome not below the cursor.
+func (it *checkpointIterator) nextHeight() (uint64, bool) {
+	if it.next < it.cp.SampleFrom {
+		return it.next, true
+	}
+	i := sort.Search(len(it.ahead), func(i int) bool { return it.ahead[i] >= it.next })
+	if i == len(it.ahead) {
+		return 0, false
+	}
+	return it.ahead[i], true
+}
@@ -0,0 +1,91 @@
+package das
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyReservoirSize is the amount of sample durations kept to estimate latency percentiles.
+const latencyReservoirSize = 1024
+
+// LatencyStats summarizes durations of successful samples.
+type LatencyStats struct {
+	// Mean is the average duration over all successful samples
+	Mean time.Duration `json:"mean"`
+	// P50 is the estimated median duration
+	P50 time.Duration `json:"p50"`
+	// P99 is the estimated 99th percentile duration
+	P99 time.Duration `json:"p99"`
+}
+
+// latencies keeps a uniform random sample of durations of successful samples (reservoir sampling),
+// so latency percentiles could be estimated in constant memory without a metrics backend.
+type latencies struct {
+	lock      sync.Mutex
+	reservoir []time.Duration
+	// count is the amount of durations observed, including the ones not kept in the reservoir
+	count uint64
+	sum   time.Duration
+	rand  *rand.Rand
+}
+
+func newLatencies() *latencies {
+	return &latencies{
+		reservoir: make([]time.Duration, 0, latencyReservoirSize),
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec
+	}
+}
+
+// observe records the duration of the successful sample. Failed samples are not accounted for, as
+// their duration is dominated by timeouts.
+func (l *latencies) observe(o sampleOutcome) {
+	if o.err != nil {
+		return
+	}
+	l.add(o.duration)
+}
+
+func (l *latencies) add(d time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.count++
+	l.sum += d
+	if len(l.reservoir) < latencyReservoirSize {
+		l.reservoir = append(l.reservoir, d)
+		return
+	}
+	// replace a random kept duration with probability size/count, so every observed duration is kept
+	// with the same probability
+	if i := l.rand.Int63n(int64(l.count)); i < latencyReservoirSize {
+		l.reservoir[i] = d
+	}
+}
+
+// get computes latency stats over the observed durations. Stats are zero if none were observed.
+func (l *latencies) get() LatencyStats {
+	l.lock.Lock()
+	if l.count == 0 {
+		l.lock.Unlock()
+		return LatencyStats{}
+	}
+	mean := l.sum / time.Duration(l.count)
+	sorted := make([]time.Duration, len(l.reservoir))
+	copy(sorted, l.reservoir)
+	l.lock.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return LatencyStats{
+		Mean: mean,
+		P50:  percentile(sorted, 0.5),
+		P99:  percentile(sorted, 0.99),
+	}
+}
+
+// percentile returns the nearest-rank percentile of the sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
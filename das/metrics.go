@@ -2,10 +2,13 @@ package das
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync/atomic"
 	"time"
 
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
@@ -17,10 +20,19 @@ const (
 	jobTypeLabel     = "job_type"
 	headerWidthLabel = "header_width"
 	failedLabel      = "failed"
+	storeOpLabel     = "op"
+	pauseReasonLabel = "reason"
+	sourceLabel      = "source"
+)
+
+const (
+	storeOpLoad  = "load"
+	storeOpStore = "store"
 )
 
 var (
-	meter = otel.Meter("das")
+	meter  = otel.Meter("das")
+	tracer = otel.Tracer("das")
 )
 
 type metrics struct {
@@ -28,7 +40,18 @@ type metrics struct {
 	sampleTime    metric.Float64Histogram
 	getHeaderTime metric.Float64Histogram
 	newHead       metric.Int64Counter
+	headRollback  metric.Int64Counter
+	reorgResample metric.Int64Counter
+	equivocation  metric.Int64Counter
+	storeOpTime   metric.Float64Histogram
+	storeOpErrors metric.Int64Counter
+	paused        metric.Int64Counter
+	faultyAvail   metric.Int64Counter
+
+	// exemplars link recorded sample times to traces of the samples
+	exemplars *exemplars
 
+	clock         clock.Clock
 	lastSampledTS uint64
 }
 
@@ -57,6 +80,50 @@ func (d *DASer) InitMetrics() error {
 		return err
 	}
 
+	headRollback, err := meter.Int64Counter("das_head_rollback_counter",
+		metric.WithDescription("amount of times network head was ignored for being lower than the checkpoint"))
+	if err != nil {
+		return err
+	}
+
+	reorgResample, err := meter.Int64Counter("das_reorg_resampled_counter",
+		metric.WithDescription("amount of recent headers resampled due to a header with the same height "+
+			"and different data root"))
+	if err != nil {
+		return err
+	}
+
+	equivocation, err := meter.Int64Counter("das_equivocation_counter",
+		metric.WithDescription("amount of headers received with a data root different from the one "+
+			"seen before for the same height"))
+	if err != nil {
+		return err
+	}
+
+	storeOpTime, err := meter.Float64Histogram("das_store_op_time_hist",
+		metric.WithDescription("duration of loading or storing the checkpoint in the datastore"))
+	if err != nil {
+		return err
+	}
+
+	storeOpErrors, err := meter.Int64Counter("das_store_op_errors_counter",
+		metric.WithDescription("amount of failed checkpoint loads or stores in the datastore"))
+	if err != nil {
+		return err
+	}
+
+	paused, err := meter.Int64Counter("das_paused_counter",
+		metric.WithDescription("amount of times sampling was paused by reason"))
+	if err != nil {
+		return err
+	}
+
+	faultyAvail, err := meter.Int64Counter("das_faulty_availability_counter",
+		metric.WithDescription("amount of squares reported available, but failing the spot check of post-verification"))
+	if err != nil {
+		return err
+	}
+
 	lastSampledTS, err := meter.Int64ObservableGauge("das_latest_sampled_ts",
 		metric.WithDescription("latest sampled timestamp"))
 	if err != nil {
@@ -88,12 +155,50 @@ func (d *DASer) InitMetrics() error {
 		return err
 	}
 
+	droppedFailures, err := meter.Int64ObservableGauge("das_dropped_failure_events",
+		metric.WithDescription("amount of failure events dropped due to slow subscribers"),
+	)
+	if err != nil {
+		return err
+	}
+
+	droppedRecent, err := meter.Int64ObservableGauge("das_dropped_recent_headers",
+		metric.WithDescription("amount of headers dropped from the full recent headers buffer"),
+	)
+	if err != nil {
+		return err
+	}
+
+	duplicateRecent, err := meter.Int64ObservableGauge("das_duplicate_recent_headers",
+		metric.WithDescription("amount of headers delivered by the subscription more than once"),
+	)
+	if err != nil {
+		return err
+	}
+
+	dedupedHeaders, err := meter.Int64ObservableGauge("das_deduped_header_requests",
+		metric.WithDescription("amount of header requests served by a concurrent request of the same height"),
+	)
+	if err != nil {
+		return err
+	}
+
 	d.sampler.metrics = &metrics{
 		sampled:       sampled,
 		sampleTime:    sampleTime,
 		getHeaderTime: getHeaderTime,
 		newHead:       newHead,
+		headRollback:  headRollback,
+		reorgResample: reorgResample,
+		equivocation:  equivocation,
+		storeOpTime:   storeOpTime,
+		storeOpErrors: storeOpErrors,
+		paused:        paused,
+		faultyAvail:   faultyAvail,
+		exemplars:     newExemplars(),
+		clock:         d.clock,
 	}
+	d.store.metrics = d.sampler.metrics
 
 	callback := func(ctx context.Context, observer metric.Observer) error {
 		stats, err := d.sampler.stats(ctx)
@@ -117,6 +222,13 @@ func (d *DASer) InitMetrics() error {
 		}
 
 		observer.ObserveInt64(totalSampled, int64(stats.totalSampled()))
+		observer.ObserveInt64(droppedFailures, int64(d.failures.dropped.Load()))
+		observer.ObserveInt64(droppedRecent, int64(d.subscriber.dropped.Load()))
+		observer.ObserveInt64(duplicateRecent, int64(d.subscriber.duplicates.Load()))
+		// the header getter deduplicates concurrent requests, if wrapped by the node
+		if deduper, ok := d.getter.(dedupedCounter); ok {
+			observer.ObserveInt64(dedupedHeaders, int64(deduper.Deduped()))
+		}
 		return nil
 	}
 
@@ -126,6 +238,10 @@ func (d *DASer) InitMetrics() error {
 		networkHead,
 		sampledChainHead,
 		totalSampled,
+		droppedFailures,
+		droppedRecent,
+		duplicateRecent,
+		dedupedHeaders,
 	)
 	if err != nil {
 		return fmt.Errorf("registering metrics callback: %w", err)
@@ -134,21 +250,41 @@ func (d *DASer) InitMetrics() error {
 	return nil
 }
 
+// RegisterExemplars registers a Prometheus histogram of sample times with the registerer. Sample
+// times of traced samples are observed in it together with trace and span IDs of the samples, so
+// they are exposed as OpenMetrics exemplars and could be looked up from the slow buckets of the
+// histogram. It must be called after InitMetrics.
+func (d *DASer) RegisterExemplars(reg prometheus.Registerer) error {
+	if d.sampler.metrics == nil {
+		return errors.New("das: metrics are not initialized")
+	}
+
+	hist := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "das_sample_time_seconds",
+		Help:    "duration of sampling a single header with exemplars linking to traces of samples",
+		Buckets: sampleTimeBuckets,
+	})
+	if err := reg.Register(hist); err != nil {
+		return fmt.Errorf("registering sample time histogram: %w", err)
+	}
+	d.sampler.metrics.exemplars.setHistogram(hist)
+	return nil
+}
+
 // observeSample records the time it took to sample a header +
 // the amount of sampled contiguous headers
 func (m *metrics) observeSample(
 	ctx context.Context,
 	h *header.ExtendedHeader,
 	sampleTime time.Duration,
-	jobType jobType,
+	jobType SampleSource,
 	err error,
 ) {
 	if m == nil {
 		return
 	}
-	if ctx.Err() != nil {
-		ctx = context.Background()
-	}
+	// keep the span of the sample, so the recorded time could be linked to the trace
+	ctx = context.WithoutCancel(ctx)
 	m.sampleTime.Record(ctx, sampleTime.Seconds(),
 		metric.WithAttributes(
 			attribute.Bool(failedLabel, err != nil),
@@ -163,7 +299,9 @@ func (m *metrics) observeSample(
 			attribute.String(jobTypeLabel, string(jobType)),
 		))
 
-	atomic.StoreUint64(&m.lastSampledTS, uint64(time.Now().UTC().Unix()))
+	now := m.clock.Now()
+	m.exemplars.observe(ctx, h.Height(), sampleTime, now)
+	atomic.StoreUint64(&m.lastSampledTS, uint64(now.UTC().Unix()))
 }
 
 // observeGetHeader records the time it took to get a header from the header store.
@@ -187,3 +325,85 @@ func (m *metrics) observeNewHead(ctx context.Context) {
 	}
 	m.newHead.Add(ctx, 1)
 }
+
+// observeHeadRollback records a network head ignored for being lower than the checkpoint.
+func (m *metrics) observeHeadRollback(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	if ctx.Err() != nil {
+		ctx = context.Background()
+	}
+	m.headRollback.Add(ctx, 1)
+}
+
+// observeFaultyAvailability records a square reported available by the Availability failing the
+// spot check of post-verification.
+func (m *metrics) observeFaultyAvailability(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	if ctx.Err() != nil {
+		ctx = context.Background()
+	}
+	m.faultyAvail.Add(ctx, 1)
+}
+
+// observeReorgResampled records a recent header resampled due to reorg.
+func (m *metrics) observeReorgResampled(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	if ctx.Err() != nil {
+		ctx = context.Background()
+	}
+	m.reorgResample.Add(ctx, 1)
+}
+
+// observeEquivocation records an equivocating header received from the source.
+func (m *metrics) observeEquivocation(ctx context.Context, source headerSource) {
+	if m == nil {
+		return
+	}
+	if ctx.Err() != nil {
+		ctx = context.Background()
+	}
+	m.equivocation.Add(ctx, 1, metric.WithAttributes(attribute.String(sourceLabel, string(source))))
+}
+
+// observePaused records sampling being paused for the reason.
+func (m *metrics) observePaused(ctx context.Context, reason PauseReason) {
+	if m == nil {
+		return
+	}
+	if ctx.Err() != nil {
+		ctx = context.Background()
+	}
+	m.paused.Add(ctx, 1, metric.WithAttributes(attribute.String(pauseReasonLabel, string(reason))))
+}
+
+// observeStoreOp records the time it took to perform a checkpoint datastore operation and whether
+// it failed.
+func (m *metrics) observeStoreOp(ctx context.Context, op string, d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	if ctx.Err() != nil {
+		ctx = context.Background()
+	}
+	m.storeOpTime.Record(ctx, d.Seconds(),
+		metric.WithAttributes(
+			attribute.String(storeOpLabel, op),
+			attribute.Bool(failedLabel, err != nil),
+		))
+	if err != nil {
+		m.storeOpErrors.Add(ctx, 1, metric.WithAttributes(attribute.String(storeOpLabel, op)))
+	}
+}
+
+// dedupedCounter is implemented by getters deduplicating concurrent identical requests, e.g.
+// getters.SingleFlightHeaderGetter.
+type dedupedCounter interface {
+	// Deduped returns the amount of requests served by a concurrent identical request.
+	Deduped() uint64
+}
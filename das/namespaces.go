@@ -0,0 +1,94 @@
+package das
+
+import (
+	"context"
+	"sync"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+// namespaceHistoryLimit bounds the amount of heights for which per-namespace availability is
+// retained.
+const namespaceHistoryLimit = 1024
+
+// namespaceChecker verifies presence of required namespaces in sampled headers and records
+// per-namespace availability for each of them.
+type namespaceChecker struct {
+	getter     share.Getter
+	namespaces []share.Namespace
+
+	lock sync.RWMutex
+	// heights keeps insertion order of results to evict the oldest ones
+	heights []uint64
+	results map[uint64]map[string]bool
+}
+
+func newNamespaceChecker(getter share.Getter, namespaces []share.Namespace) *namespaceChecker {
+	return &namespaceChecker{
+		getter:     getter,
+		namespaces: namespaces,
+		results:    make(map[uint64]map[string]bool),
+	}
+}
+
+// check requests shares of every required namespace for the given header and records whether
+// each namespace is available. Unavailable namespaces are reported with a WARN log, but do not
+// affect the sampling verdict.
+func (nc *namespaceChecker) check(ctx context.Context, h *header.ExtendedHeader) {
+	results := make(map[string]bool, len(nc.namespaces))
+	for _, ns := range nc.namespaces {
+		available, err := nc.isAvailable(ctx, h, ns)
+		if !available {
+			log.Warnw("required namespace is not available",
+				"height", h.Height(),
+				"namespace", ns.String(),
+				"err", err,
+			)
+		}
+		results[ns.String()] = available
+	}
+
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+	if _, ok := nc.results[h.Height()]; !ok {
+		nc.heights = append(nc.heights, h.Height())
+	}
+	nc.results[h.Height()] = results
+	if len(nc.heights) > namespaceHistoryLimit {
+		delete(nc.results, nc.heights[0])
+		nc.heights = nc.heights[1:]
+	}
+}
+
+func (nc *namespaceChecker) isAvailable(
+	ctx context.Context,
+	h *header.ExtendedHeader,
+	namespace share.Namespace,
+) (bool, error) {
+	shares, err := nc.getter.GetSharesByNamespace(ctx, h, namespace)
+	if err != nil {
+		return false, err
+	}
+	status, err := shares.Status(h.DAH, namespace)
+	if err != nil {
+		return false, err
+	}
+	return status == share.NamespacePresent, nil
+}
+
+// availability returns a copy of per-namespace availability recorded for the given height.
+func (nc *namespaceChecker) availability(height uint64) map[string]bool {
+	nc.lock.RLock()
+	defer nc.lock.RUnlock()
+
+	results, ok := nc.results[height]
+	if !ok {
+		return nil
+	}
+	out := make(map[string]bool, len(results))
+	for ns, available := range results {
+		out[ns] = available
+	}
+	return out
+}
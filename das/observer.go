@@ -0,0 +1,23 @@
+package das
+
+import (
+	"time"
+
+	"github.com/celestiaorg/celestia-node/header"
+)
+
+// observeFn is notified about the outcome of every sampling attempt.
+type observeFn func(sampleOutcome)
+
+// sampleOutcome describes the outcome of a single sampling attempt.
+type sampleOutcome struct {
+	height uint64
+	// header is nil if it could not be retrieved
+	header   *header.ExtendedHeader
+	source   SampleSource
+	attempt  int
+	duration time.Duration
+	err      error
+	// metadata is set only for samples requested on demand
+	metadata SampleMetadata
+}
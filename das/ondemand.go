@@ -0,0 +1,63 @@
+package das
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// onDemandGroup tracks samples requested on demand, so Stop waits for them before closing
+// subscriptions their outcomes are reported to. Samples are only admitted while the DASer is
+// running and are aborted once it stops.
+type onDemandGroup struct {
+	lk     sync.Mutex
+	wg     sync.WaitGroup
+	runCtx context.Context
+}
+
+// open starts admitting samples, aborting them once runCtx is done.
+func (g *onDemandGroup) open(runCtx context.Context) {
+	g.lk.Lock()
+	defer g.lk.Unlock()
+	g.runCtx = runCtx
+}
+
+// add admits a sample, returning the context to perform it with, which is done once either the
+// given one or the run context is done. The returned release func must be called once the
+// outcome of the sample is reported. It returns errSamplingStopped if the DASer is not running.
+func (g *onDemandGroup) add(ctx context.Context) (context.Context, func(), error) {
+	g.lk.Lock()
+	defer g.lk.Unlock()
+	if g.runCtx == nil || g.runCtx.Err() != nil {
+		return nil, nil, errSamplingStopped
+	}
+	g.wg.Add(1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(g.runCtx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+		g.wg.Done()
+	}, nil
+}
+
+// close stops admitting samples and waits for admitted ones to finish. Admitted samples are
+// aborted by cancellation of the run context, so it has to be canceled before.
+func (g *onDemandGroup) close(ctx context.Context) error {
+	g.lk.Lock()
+	g.runCtx = nil
+	g.lk.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("on demand samples stuck: %w", ctx.Err())
+	}
+}
@@ -1,8 +1,19 @@
 package das
 
 import (
+	"context"
 	"fmt"
+	"hash"
+	"io"
 	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/ipfs/go-datastore"
+
+	"github.com/celestiaorg/go-fraud"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
 )
 
 // ErrInvalidOption is an error that is returned by Parameters.Validate
@@ -32,6 +43,12 @@ type Parameters struct {
 	// checkpoint backup.
 	BackgroundStoreInterval time.Duration
 
+	// CheckpointFlushInterval is the minimum period of time between checkpoint backups of the
+	// background checkpointStore, to reduce writes to disk. Checkpoints advancing within the interval
+	// are coalesced, so only the latest one is written once the interval passes. The checkpoint is
+	// always written on Stop. If set to 0, every advanced checkpoint is written.
+	CheckpointFlushInterval time.Duration
+
 	// SampleFrom is the height sampling will start from if no previous checkpoint was saved
 	SampleFrom uint64
 
@@ -45,6 +62,67 @@ type Parameters struct {
 	// in order to be sampled. If set to 0, the sampling window will include
 	// all headers.
 	SamplingWindow time.Duration
+
+	// SampleTTL is the period of time after which records of heights sampled ahead of the
+	// contiguous sampled prefix expire. Expired heights will be sampled again by catchup. If set
+	// to 0, records never expire.
+	SampleTTL time.Duration
+
+	// WorkerSplit is the fraction of workers reserved for sampling recent headers while catchup has
+	// work as well. The rest of workers is reserved for catchup and retries. Whichever side has no
+	// work gives its workers to the other. If set to 0, recent headers are sampled immediately on
+	// arrival, regardless of catchup.
+	WorkerSplit float64
+
+	// BlockTime is the expected interval between blocks of the network. If set, recent headers
+	// received while sampling is at the network head are sampled no more often than once per block
+	// time, smoothing bursts of headers. A header is delayed by at most four block times after its
+	// arrival, so sampling keeps up if blocks are produced faster. Headers received while sampling is
+	// behind are sampled as usual. If set to 0, recent headers are sampled immediately on arrival.
+	BlockTime time.Duration
+
+	// RetryOrder is the order failed heights are retried in.
+	RetryOrder RetryOrder
+
+	// MaxRetries is the maximum amount of times a failed height is retried. Once exceeded, the
+	// height is parked in the failed set and is not retried anymore, but can still be sampled with
+	// Resample. If set to 0, failed heights are retried indefinitely.
+	MaxRetries int
+
+	// StrictContiguity makes the stored checkpoint stall at the lowest failed height until it is
+	// sampled successfully, so sampling resumes from the first gap after restart. Catchup jobs are not
+	// dispatched meanwhile, so catchup does not advance far past the gap. Otherwise, failed heights are
+	// only tracked in the failed set and do not hold back the checkpoint or catchup.
+	StrictContiguity bool
+
+	// AdaptiveBackfill limits the amount of parallel catchup and retry workers according to the
+	// outcomes of their jobs. Once sampling of old heights fails, e.g. because few peers still serve
+	// them, fewer workers are dispatched for them until sampling succeeds again.
+	AdaptiveBackfill bool
+
+	// CatchupChunkSize is the maximum amount of catchup heights dispatched to workers and not
+	// finished yet. Catchup is dispatched in chunks up to this size, refilled as workers finish,
+	// rather than spreading the whole backlog over the workers at once. If set to 0, the amount is
+	// only limited by SamplingRange and ConcurrencyLimit.
+	CatchupChunkSize uint64
+
+	// BacklogInterval is the period of time the catchup backlog is recorded in for BacklogHistory.
+	// If set to 0, the backlog is not recorded.
+	BacklogInterval time.Duration
+
+	// HealthWarmup is the period of time after start during which the DASer is reported as healthy
+	// regardless of its sampling backlog, giving it time to begin catching up.
+	HealthWarmup time.Duration
+
+	// RecentBuffer is the maximum amount of headers received via subscription that are buffered
+	// until they are handed over for sampling. Once the buffer is full, the oldest header is dropped
+	// and left to be sampled by catchup.
+	RecentBuffer int
+
+	// FraudHandleTimeout limits the time spent on handling a received bad encoding fraud proof,
+	// e.g. verifying it against the header from the getter. Sampling is halted once the proof is
+	// handled or the timeout is exceeded.
+	FraudHandleTimeout time.Duration
 }
 
 // DefaultParameters returns the default configuration values for the daser parameters
@@ -60,6 +138,12 @@ func DefaultParameters() Parameters {
 		// SampleTimeout = approximate block time (with a bit of wiggle room) * max amount of catchup
 		// workers
 		SampleTimeout: 15 * time.Second * time.Duration(concurrencyLimit),
+		RetryOrder:    RetryOldestFirst,
+		RecentBuffer:  64,
+		// BacklogInterval = a day of history is retained
+		BacklogInterval: time.Minute,
+		// FraudHandleTimeout = a few block times to get the header of the proof
+		FraudHandleTimeout: time.Minute,
 	}
 }
 
@@ -95,6 +179,12 @@ func (p *Parameters) Validate() error {
 			"negative or 0",
 		)
 	}
+	if p.SampleFrom > maxHeight {
+		return errInvalidOptionValue(
+			"SampleFrom",
+			"above the maximum height",
+		)
+	}
 
 	// SampleTimeout = 0 would fail every sample operation with timeout error
 	if p.SampleTimeout <= 0 {
@@ -104,9 +194,107 @@ func (p *Parameters) Validate() error {
 		)
 	}
 
+	if p.SampleTTL < 0 {
+		return errInvalidOptionValue(
+			"SampleTTL",
+			"negative",
+		)
+	}
+
+	if p.WorkerSplit < 0 || p.WorkerSplit > 1 {
+		return errInvalidOptionValue(
+			"WorkerSplit",
+			"outside of [0, 1]",
+		)
+	}
+
+	if !p.RetryOrder.isValid() {
+		return errInvalidOptionValue(
+			"RetryOrder",
+			fmt.Sprintf("%q", p.RetryOrder),
+		)
+	}
+
+	if p.MaxRetries < 0 {
+		return errInvalidOptionValue(
+			"MaxRetries",
+			"negative",
+		)
+	}
+
+	if p.HealthWarmup < 0 {
+		return errInvalidOptionValue(
+			"HealthWarmup",
+			"negative",
+		)
+	}
+
+	if p.RecentBuffer <= 0 {
+		return errInvalidOptionValue(
+			"RecentBuffer",
+			"negative or 0",
+		)
+	}
+
+	if p.BlockTime < 0 {
+		return errInvalidOptionValue(
+			"BlockTime",
+			"negative",
+		)
+	}
+
+	if p.BacklogInterval < 0 {
+		return errInvalidOptionValue(
+			"BacklogInterval",
+			"negative",
+		)
+	}
+
+	if p.CheckpointFlushInterval < 0 {
+		return errInvalidOptionValue(
+			"CheckpointFlushInterval",
+			"negative",
+		)
+	}
+
+	// FraudHandleTimeout = 0 would give up on every received fraud proof immediately
+	if p.FraudHandleTimeout <= 0 {
+		return errInvalidOptionValue(
+			"FraudHandleTimeout",
+			"negative or 0",
+		)
+	}
+
 	return nil
 }
 
+// DASConfig is a snapshot of the effective DASer configuration with defaults applied.
+type DASConfig struct {
+	Parameters
+
+	// RecentSampling indicates whether new headers received via subscription are sampled
+	RecentSampling bool
+	// SelfTest indicates whether SelfTest is run on start
+	SelfTest bool
+	// Warmup indicates whether Warmup is run on start
+	Warmup bool
+	// RequiredNamespaces are verified to be present in every sampled header
+	RequiredNamespaces []share.Namespace
+	// AuditLog indicates whether sampling verdicts are written to the audit log
+	AuditLog bool
+	// RootIndex indicates whether data roots of sampled heights are indexed
+	RootIndex bool
+	// PostVerify indicates whether squares reported available are spot checked
+	PostVerify bool
+	// PublishTopic is the topic sampled heights are published to. Empty if publishing is disabled.
+	PublishTopic string
+	// CheckpointCodec is the type of Codec the checkpoint is stored with
+	CheckpointCodec string
+	// CheckpointCompression is the type of Compression the checkpoint is stored with. Empty if the
+	// checkpoint is stored uncompressed.
+	CheckpointCompression string
+}
+
 // WithSamplingRange is a functional option to configure the daser's `SamplingRange` parameter
 //
 //	Usage:
@@ -168,3 +356,387 @@ func WithSamplingWindow(samplingWindow time.Duration) Option {
 		d.params.SamplingWindow = samplingWindow
 	}
 }
+
+// WithCheckpointFlushInterval is a functional option to configure the DASer's
+// `CheckpointFlushInterval` parameter.
+func WithCheckpointFlushInterval(interval time.Duration) Option {
+	return func(d *DASer) {
+		d.params.CheckpointFlushInterval = interval
+	}
+}
+
+// WithBlockTime is a functional option to configure the DASer's `BlockTime` parameter.
+func WithBlockTime(blockTime time.Duration) Option {
+	return func(d *DASer) {
+		d.params.BlockTime = blockTime
+	}
+}
+
+// WithCatchupChunkSize is a functional option to configure the DASer's `CatchupChunkSize`
+// parameter.
+func WithCatchupChunkSize(size uint64) Option {
+	return func(d *DASer) {
+		d.params.CatchupChunkSize = size
+	}
+}
+
+// WithBacklogInterval is a functional option to configure the DASer's `BacklogInterval` parameter.
+func WithBacklogInterval(interval time.Duration) Option {
+	return func(d *DASer) {
+		d.params.BacklogInterval = interval
+	}
+}
+
+// WithSampleTTL is a functional option to configure the DASer's `SampleTTL` parameter.
+func WithSampleTTL(ttl time.Duration) Option {
+	return func(d *DASer) {
+		d.params.SampleTTL = ttl
+	}
+}
+
+// WithWorkerSplit is a functional option to configure the DASer's `WorkerSplit` parameter, e.g.
+// WithWorkerSplit(0.7) reserves 70% of workers for recent headers and 30% for catchup.
+func WithWorkerSplit(recentFraction float64) Option {
+	return func(d *DASer) {
+		d.params.WorkerSplit = recentFraction
+	}
+}
+
+// WithFailedRetryOrder is a functional option to configure the DASer's `RetryOrder` parameter.
+func WithFailedRetryOrder(order RetryOrder) Option {
+	return func(d *DASer) {
+		d.params.RetryOrder = order
+	}
+}
+
+// WithMaxRetries is a functional option to configure the DASer's `MaxRetries` parameter.
+func WithMaxRetries(n int) Option {
+	return func(d *DASer) {
+		d.params.MaxRetries = n
+	}
+}
+
+// WithStrictContiguity is a functional option to configure the DASer's `StrictContiguity`
+// parameter.
+func WithStrictContiguity(strict bool) Option {
+	return func(d *DASer) {
+		d.params.StrictContiguity = strict
+	}
+}
+
+// WithAdaptiveBackfill is a functional option to configure the DASer's `AdaptiveBackfill`
+// parameter.
+func WithAdaptiveBackfill(enabled bool) Option {
+	return func(d *DASer) {
+		d.params.AdaptiveBackfill = enabled
+	}
+}
+
+// WithHealthWarmup is a functional option to configure the DASer's `HealthWarmup` parameter.
+func WithHealthWarmup(warmup time.Duration) Option {
+	return func(d *DASer) {
+		d.params.HealthWarmup = warmup
+	}
+}
+
+// WithRecentBuffer is a functional option to configure the DASer's `RecentBuffer` parameter.
+func WithRecentBuffer(size int) Option {
+	return func(d *DASer) {
+		d.params.RecentBuffer = size
+	}
+}
+
+// WithFraudHandleTimeout is a functional option to configure the DASer's `FraudHandleTimeout`
+// parameter.
+func WithFraudHandleTimeout(timeout time.Duration) Option {
+	return func(d *DASer) {
+		d.params.FraudHandleTimeout = timeout
+	}
+}
+
+// WithRecentSampling is a functional option to enable or disable sampling of new headers
+// received via subscription. If disabled, the DASer only catches up to the network head known at
+// start. Recent sampling is enabled by default.
+func WithRecentSampling(enabled bool) Option {
+	return func(d *DASer) {
+		d.recentSampling = enabled
+	}
+}
+
+// WithHeaderPriority is a functional option to assign a priority to headers received via
+// subscription. Headers waiting to be sampled are sampled in order of descending priority
+// regardless of their arrival order, while headers with the same priority are sampled in arrival
+// order. Once the recent buffer is full, the oldest header with the lowest priority is dropped and
+// left to be sampled by catchup. The function is called on every dispatch, so it must be cheap.
+func WithHeaderPriority(priority func(*header.ExtendedHeader) int) Option {
+	return func(d *DASer) {
+		d.priority = priority
+	}
+}
+
+// WithSelfTest is a functional option to run SelfTest on start. If the self test fails, the DASer
+// is not started and the error is returned. The self test is disabled by default.
+func WithSelfTest(enabled bool) Option {
+	return func(d *DASer) {
+		d.selfTest = enabled
+	}
+}
+
+// WithWarmup is a functional option to run Warmup on start, before sampling begins. A failed warmup
+// is logged and does not prevent the DASer from starting. The warmup is disabled by default.
+func WithWarmup(enabled bool) Option {
+	return func(d *DASer) {
+		d.warmup = enabled
+	}
+}
+
+// FraudHaltPolicy decides whether sampling is halted on the received fraud proof.
+type FraudHaltPolicy func(fraud.Proof[*header.ExtendedHeader]) bool
+
+// WithFraudHaltPolicy is a functional option to decide whether sampling is halted on a received
+// bad encoding fraud proof. If the policy returns false, the proof is logged and kept for
+// DeclinedFraudProof, while sampling continues. It is meant for controlled environments only, such
+// as testnets or forensic forks, as sampling data of a chain with proven fraud is pointless
+// otherwise. By default, sampling is always halted.
+func WithFraudHaltPolicy(policy FraudHaltPolicy) Option {
+	return func(d *DASer) {
+		d.fraudHaltPolicy = policy
+	}
+}
+
+// WithEquivocationHaltPolicy is a functional option to decide whether sampling is halted once a
+// header with a data root different from the one seen before for the same height is received. If
+// the policy returns true, sampling is halted with ErrEquivocation. By default, equivocation is
+// only reported via logs, metrics and SubscribeEquivocations.
+func WithEquivocationHaltPolicy(policy EquivocationHaltPolicy) Option {
+	return func(d *DASer) {
+		d.equivocationHaltPolicy = policy
+	}
+}
+
+// FraudValidation is the mode received fraud proofs are validated in before sampling is halted.
+type FraudValidation uint8
+
+const (
+	// FraudValidationStrict validates the proof against the local header of its height, e.g. the data
+	// root a bad encoding fraud proof is verified against. It is the default mode.
+	FraudValidationStrict FraudValidation = iota
+	// FraudValidationLenient only checks the proof is consistent in itself, without the local header,
+	// for proofs supporting it. Other proofs are accepted.
+	FraudValidationLenient
+	// FraudValidationNone accepts any proof. It is meant for testing only.
+	FraudValidationNone
+)
+
+func (v FraudValidation) String() string {
+	switch v {
+	case FraudValidationStrict:
+		return "strict"
+	case FraudValidationLenient:
+		return "lenient"
+	case FraudValidationNone:
+		return "none"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(v))
+	}
+}
+
+// WithFraudValidation is a functional option to configure how received fraud proofs are validated
+// before sampling is halted. Proofs failing validation are logged and ignored. Strict validation of
+// proofs whose header could not be retrieved within FraudHandleTimeout is skipped, so the proof is
+// accepted. By default, proofs are validated strictly.
+func WithFraudValidation(mode FraudValidation) Option {
+	return func(d *DASer) {
+		d.fraudValidation = mode
+	}
+}
+
+// WithOnSampledDetailed is a functional option to get the SampleDetail of every sampling attempt,
+// including peers that provided the shares and the amount of bytes fetched. The callback is called
+// synchronously by the sampling routine, so it must not block.
+func WithOnSampledDetailed(fn func(SampleDetail)) Option {
+	return func(d *DASer) {
+		d.onSampledDetailed = fn
+	}
+}
+
+// WithCheckpointCodec is a functional option to configure the Codec the checkpoint is stored with.
+// Checkpoints stored with any of the built-in codecs are detected and migrated on load.
+func WithCheckpointCodec(codec Codec) Option {
+	return func(d *DASer) {
+		d.store.codec = codec
+	}
+}
+
+// WithCheckpointCompression is a functional option to compress the stored checkpoint, which saves
+// space for checkpoints with many failed heights, e.g. with GzipCompression or ZstdCompression.
+// Checkpoints are decompressed transparently on load, so compression could be enabled, disabled or
+// changed between restarts.
+func WithCheckpointCompression(compression Compression) Option {
+	return func(d *DASer) {
+		d.store.compression = compression
+	}
+}
+
+// WithAuditLog is a functional option to write an audit record for every sampled or failed height
+// to the given io.Writer. Records are JSON lines with the timestamp, height, data root, outcome and
+// duration of sampling. Writes are buffered and asynchronous, so sampling is never blocked; records
+// are dropped if the writer does not keep up. The log is flushed on Stop.
+func WithAuditLog(w io.Writer) Option {
+	return func(d *DASer) {
+		if w == nil {
+			d.audit = nil
+			return
+		}
+		d.audit = newAuditLog(w)
+	}
+}
+
+// WithPublisher is a functional option to publish a JSON encoded event for every sampled height to
+// the given topic of the Publisher. Publishing is asynchronous, so sampling is never blocked. Failed
+// publishes are retried with bounded exponential backoff; events are dropped if the publisher does
+// not keep up or keeps failing. Events queued on Stop are still published for up to 5 seconds.
+func WithPublisher(pub Publisher, topic string) Option {
+	return func(d *DASer) {
+		if pub == nil {
+			d.publisher = nil
+			return
+		}
+		d.publisher = newSamplePublisher(pub, topic)
+	}
+}
+
+// WithRequiredNamespaces is a functional option that makes the DASer additionally verify presence
+// of each given namespace in every successfully sampled header using the given share.Getter.
+// Per-namespace availability can be retrieved with DASer.NamespaceAvailability.
+func WithRequiredNamespaces(getter share.Getter, namespaces []share.Namespace) Option {
+	return func(d *DASer) {
+		if len(namespaces) == 0 {
+			d.namespaces = nil
+			return
+		}
+		d.namespaces = newNamespaceChecker(getter, namespaces)
+	}
+}
+
+// WithExpectedRoots is a functional option to verify sampled headers against known data roots by
+// height. If a header sampled at any of the given heights commits to a different data root,
+// sampling is halted with ErrRootMismatch, as it indicates a potential equivocation. Heights
+// without an expected root are not verified.
+func WithExpectedRoots(roots map[uint64]share.DataHash) Option {
+	return func(d *DASer) {
+		d.expectedRoots = make(map[uint64]share.DataHash, len(roots))
+		for height, root := range roots {
+			d.expectedRoots[height] = root
+		}
+	}
+}
+
+// LocalStore reports whether the square of the given data root is stored locally. eds.Store
+// implements it.
+type LocalStore interface {
+	Has(ctx context.Context, root share.DataHash) (bool, error)
+}
+
+// WithLocalStoreSkip is a functional option to skip sampling heights whose squares are already
+// available in the given local store, as stored squares are verified. The store is only checked
+// for heights sampled by catchup, while recent heights and samples on demand are always sampled.
+// Such heights are marked sampled without any share requests, so required namespaces are not
+// checked for them either. Errors of the local store are ignored and the height is sampled as
+// usual.
+func WithLocalStoreSkip(store LocalStore) Option {
+	return func(d *DASer) {
+		d.localStore = store
+	}
+}
+
+// HeadSource reports the height of the network head.
+type HeadSource func(ctx context.Context) (uint64, error)
+
+// WithHeadSource is a functional option to determine the network head catchup is bound by on start
+// with the given source, e.g. a dedicated sync service, instead of the getter's Head. Headers are
+// still retrieved from the getter. By default, the getter's Head is used.
+func WithHeadSource(source HeadSource) Option {
+	return func(d *DASer) {
+		d.headSource = source
+	}
+}
+
+// WithRootIndex is a functional option to maintain the index of data roots of sampled heights in
+// the given datastore, queryable with RootAt. The root of a height is indexed before it is
+// considered sampled, so a height whose root fails to be indexed is retried like a failed sample.
+func WithRootIndex(store datastore.Datastore) Option {
+	return func(d *DASer) {
+		d.rootIndex = newRootIndex(store)
+	}
+}
+
+// WithDiskGuard is a functional option to pause sampling while free disk space on the filesystem of
+// the given path is below minFreeBytes. Free space is checked periodically and sampling resumes
+// once enough space is freed. Already running sampling jobs are not interrupted. The guard is only
+// supported on darwin, freebsd and linux, and never pauses sampling on other platforms.
+func WithDiskGuard(path string, minFreeBytes uint64) Option {
+	return func(d *DASer) {
+		d.diskGuard = newDiskGuard(path, minFreeBytes)
+	}
+}
+
+// WithClock is a functional option to set the clock used for all time-based logic of the DASer,
+// such as timeouts, retry backoff and intervals. It allows tests to control time with a mock
+// clock.
+func WithClock(clk clock.Clock) Option {
+	return func(d *DASer) {
+		d.clock = clk
+	}
+}
+
+// WithRecentGetter is a functional option to retrieve shares of headers received from the
+// subscription with the given share.Getter instead of the one of the Availability, e.g. to sample
+// the network head with a fast getter. The Availability must support getters carried by the
+// context, see getters.WithGetter.
+func WithRecentGetter(getter share.Getter) Option {
+	return func(d *DASer) {
+		d.recentGetter = getter
+	}
+}
+
+// WithCatchupGetter is a functional option to retrieve shares of headers sampled by catchup and
+// retries with the given share.Getter instead of the one of the Availability, e.g. to backfill
+// with a cheaper but slower getter. The Availability must support getters carried by the context,
+// see getters.WithGetter.
+func WithCatchupGetter(getter share.Getter) Option {
+	return func(d *DASer) {
+		d.catchupGetter = getter
+	}
+}
+
+// WithPostVerify is a functional option to spot check a random share of every square reported
+// available by the Availability, independently of it, with the share.Getter given by
+// WithPostVerifyGetter. It guards against faulty Availability implementations returning without
+// actually verifying availability. Squares whose spot checked share is not found or fails
+// verification are counted as failed with ErrFaultyAvailability. Spot checks failing with other
+// getter errors, e.g. transient network ones, are retried up to 3 times and are inconclusive
+// afterwards, so the verdict of the Availability stands.
+func WithPostVerify(enabled bool) Option {
+	return func(d *DASer) {
+		d.postVerify = enabled
+	}
+}
+
+// WithPostVerifyGetter is a functional option to set the share.Getter spot checks of WithPostVerify
+// retrieve shares with. If the getter serves shares with proofs, they are verified against the row
+// roots of the header.
+func WithPostVerifyGetter(getter share.Getter) Option {
+	return func(d *DASer) {
+		d.postVerifyGetter = getter
+	}
+}
+
+// WithPostVerifyNMTHasher is a functional option to set the function creating hashers of nmt nodes
+// spot checked shares of WithPostVerify are verified with. It should match the NMTHasher of the
+// Availability. If not set, share.DefaultNMTHasher is used.
+func WithPostVerifyNMTHasher(hasher func() hash.Hash) Option {
+	return func(d *DASer) {
+		d.postVerifyHasher = hasher
+	}
+}
@@ -0,0 +1,61 @@
+package das
+
+import (
+	"sync"
+
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+// partialRowsHistory is the maximum amount of heights confirmed rows are kept for.
+const partialRowsHistory = 1024
+
+// partialRows keeps rows confirmed available for heights whose data was only partially available.
+type partialRows struct {
+	lock    sync.Mutex
+	heights map[uint64]share.RowBitmap
+}
+
+func newPartialRows() *partialRows {
+	return &partialRows{heights: make(map[uint64]share.RowBitmap)}
+}
+
+// set records confirmed rows for the height. Once the history is full, the lowest height is
+// evicted.
+func (p *partialRows) set(height uint64, rows share.RowBitmap) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.heights[height] = rows
+	if len(p.heights) <= partialRowsHistory {
+		return
+	}
+
+	lowest := height
+	for h := range p.heights {
+		if h < lowest {
+			lowest = h
+		}
+	}
+	delete(p.heights, lowest)
+}
+
+// remove forgets confirmed rows of the height, once it is sampled successfully.
+func (p *partialRows) remove(height uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.heights, height)
+}
+
+// get returns a copy of confirmed rows per height.
+func (p *partialRows) get() map[uint64]share.RowBitmap {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(p.heights) == 0 {
+		return nil
+	}
+
+	heights := make(map[uint64]share.RowBitmap, len(p.heights))
+	for h, rows := range p.heights {
+		heights[h] = rows
+	}
+	return heights
+}
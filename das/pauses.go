@@ -0,0 +1,58 @@
+package das
+
+import (
+	"time"
+)
+
+// PauseState is the state of sampling a PauseEvent reports a transition to.
+type PauseState string
+
+const (
+	// Paused means sampling was paused for the reason.
+	Paused PauseState = "paused"
+	// Resumed means sampling was resumed for the reason. Sampling is still paused while any other
+	// reason is not resumed.
+	Resumed PauseState = "resumed"
+	// Throttled means the amount of parallel workers was reduced due to backpressure from
+	// availability. Sampling is not paused, but continues with fewer workers.
+	Throttled PauseState = "throttled"
+	// Unthrottled means the amount of parallel workers was restored after backpressure.
+	Unthrottled PauseState = "unthrottled"
+)
+
+// PauseEvent describes sampling being paused or resumed for a reason, or being throttled due to
+// backpressure.
+type PauseEvent struct {
+	State PauseState
+	// Reason is set for Paused and Resumed events only
+	Reason PauseReason
+	Time   time.Time
+}
+
+// pauseFeed fans out PauseEvents to subscribers.
+type pauseFeed struct {
+	*eventFeed[PauseEvent]
+}
+
+func newPauseFeed() *pauseFeed {
+	return &pauseFeed{newEventFeed[PauseEvent]()}
+}
+
+// observe publishes an event for sampling being paused or resumed for the reason.
+func (f *pauseFeed) observe(reason PauseReason, pause bool, at time.Time) {
+	state := Resumed
+	if pause {
+		state = Paused
+	}
+	f.publish(PauseEvent{State: state, Reason: reason, Time: at})
+}
+
+// observeThrottled publishes an event for sampling being throttled due to backpressure or
+// restored.
+func (f *pauseFeed) observeThrottled(throttled bool, at time.Time) {
+	state := Unthrottled
+	if throttled {
+		state = Throttled
+	}
+	f.publish(PauseEvent{State: state, Time: at})
+}
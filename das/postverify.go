@@ -0,0 +1,92 @@
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"math/rand"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/celestiaorg/celestia-node/share/eds/byzantine"
+	"github.com/celestiaorg/celestia-node/share/ipld"
+)
+
+// ErrFaultyAvailability is returned when the Availability reported a square available, but the
+// spot check of the post-verification could not confirm it. The height is counted as failed.
+var ErrFaultyAvailability = errors.New("das: availability verdict contradicted by spot check")
+
+// errShareNotIncluded is returned by the spot check for a share failing verification of its proof.
+var errShareNotIncluded = errors.New("share is not included under the row root")
+
+// postVerifyAttempts is the maximum amount of spot checks of a square failing with transient getter
+// errors. If all of them fail, the spot check is inconclusive and the verdict of the Availability
+// stands.
+const postVerifyAttempts = 3
+
+// shareProofGetter is implemented by share.Getters able to retrieve shares together with their
+// Merkle proofs against the row root.
+type shareProofGetter interface {
+	GetShareWithProof(ctx context.Context, h *header.ExtendedHeader, row, col int) (*byzantine.ShareWithProof, error)
+}
+
+// postVerifier spot checks a random share of squares the Availability reported available, to catch
+// Availability implementations returning without actually verifying availability.
+type postVerifier struct {
+	getter share.Getter
+	// newHasher creates hashers of nmt nodes to verify proofs of spot checked shares with
+	newHasher func() hash.Hash
+	// randIntn picks the coordinates of spot checks
+	randIntn func(n int) int
+}
+
+func newPostVerifier(getter share.Getter, newHasher func() hash.Hash) *postVerifier {
+	return &postVerifier{getter: getter, newHasher: newHasher, randIntn: rand.Intn}
+}
+
+// verify retrieves a random share of the square with the own getter of the verifier, independently
+// of the Availability. If the getter serves proofs, the share is verified against the row root. The
+// Availability is faulty only if the share is not found or fails verification, while spot checks
+// failing with other getter errors are retried with another share.
+func (pv *postVerifier) verify(ctx context.Context, h *header.ExtendedHeader) error {
+	width := len(h.DAH.RowRoots)
+	var err error
+	for attempt := 1; attempt <= postVerifyAttempts; attempt++ {
+		row, col := pv.randIntn(width), pv.randIntn(width)
+		err = pv.spotCheck(ctx, h, row, col)
+		switch {
+		case err == nil:
+			return nil
+		case ctx.Err() != nil:
+			// the spot check was aborted, which says nothing about the Availability
+			return err
+		case errors.Is(err, errShareNotIncluded), errors.Is(err, share.ErrNotFound),
+			errors.Is(err, share.ErrNotAvailable):
+			return fmt.Errorf("%w: height %d, share (%d, %d): %w", ErrFaultyAvailability, h.Height(), row, col, err)
+		}
+		log.Debugw("spot check failed, retrying", "height", h.Height(), "attempt", attempt, "err", err)
+	}
+
+	log.Warnw("spot check is inconclusive, keeping the availability verdict",
+		"height", h.Height(), "attempts", postVerifyAttempts, "err", err)
+	return nil
+}
+
+// spotCheck retrieves the share at the coordinates, verifying it if the getter serves proofs.
+func (pv *postVerifier) spotCheck(ctx context.Context, h *header.ExtendedHeader, row, col int) error {
+	getter, ok := pv.getter.(shareProofGetter)
+	if !ok {
+		_, err := pv.getter.GetShare(ctx, h, row, col)
+		return err
+	}
+
+	sh, err := getter.GetShareWithProof(ctx, h, row, col)
+	if err != nil {
+		return err
+	}
+	if !sh.ValidateWithHasher(ipld.MustCidFromNamespacedSha256(h.DAH.RowRoots[row]), pv.newHasher()) {
+		return errShareNotIncluded
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package das
+
+import (
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// providersHistory is the maximum amount of heights providers are kept for.
+const providersHistory = 1024
+
+// heightProviders keeps peers that served shares to sample each of the most recent heights.
+type heightProviders struct {
+	lock    sync.Mutex
+	heights map[uint64][]peer.ID
+}
+
+func newHeightProviders() *heightProviders {
+	return &heightProviders{heights: make(map[uint64][]peer.ID)}
+}
+
+// add records peers that served shares for the height. Once the history is full, the lowest height
+// is evicted.
+func (p *heightProviders) add(height uint64, peers []peer.ID) {
+	if len(peers) == 0 {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, id := range peers {
+		if !containsPeer(p.heights[height], id) {
+			p.heights[height] = append(p.heights[height], id)
+		}
+	}
+	if len(p.heights) <= providersHistory {
+		return
+	}
+
+	lowest := height
+	for h := range p.heights {
+		if h < lowest {
+			lowest = h
+		}
+	}
+	delete(p.heights, lowest)
+}
+
+// get returns a copy of peers that served shares for the height.
+func (p *heightProviders) get(height uint64) []peer.ID {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	peers, ok := p.heights[height]
+	if !ok {
+		return nil
+	}
+	return append([]peer.ID(nil), peers...)
+}
+
+func containsPeer(peers []peer.ID, id peer.ID) bool {
+	for _, p := range peers {
+		if p == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package das
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+const (
+	// publishQueueSize is the amount of sample events queued for publishing. Events are dropped
+	// once the queue is full, so a slow publisher never stalls sampling.
+	publishQueueSize = 1024
+	// publishAttempts is the maximum amount of attempts to publish a single event.
+	publishAttempts = 5
+	// defaultPublishBackoff is the delay before the first retry, doubled for every next one.
+	defaultPublishBackoff = 100 * time.Millisecond
+	// maxPublishBackoff bounds the delay between retries.
+	maxPublishBackoff = 5 * time.Second
+	// publishFlushTimeout bounds publishing of events still queued on Stop. Events not published in
+	// time are dropped.
+	publishFlushTimeout = 5 * time.Second
+)
+
+// Publisher publishes messages to a topic of a message bus.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, msg []byte) error
+}
+
+// publishedSample is the message published for every sampled height. Messages are JSON encoded.
+type publishedSample struct {
+	Height uint64 `json:"height"`
+	// Root is the hex encoded data root
+	Root       string       `json:"root,omitempty"`
+	Source     SampleSource `json:"source"`
+	DurationNs int64        `json:"duration_ns"`
+	// Metadata is the SampleMetadata of samples requested on demand.
+	Metadata SampleMetadata `json:"metadata,omitempty"`
+}
+
+// samplePublisher asynchronously publishes an event for every sampled height to the Publisher.
+type samplePublisher struct {
+	pub   Publisher
+	topic string
+	queue chan publishedSample
+	done  chan struct{}
+	// cancel aborts publishing of queued events
+	cancel context.CancelFunc
+	clock  clock.Clock
+
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	flushTimeout time.Duration
+
+	// dropped counts events that were not published due to the full queue or exhausted retries
+	dropped atomic.Uint64
+}
+
+func newSamplePublisher(pub Publisher, topic string) *samplePublisher {
+	return &samplePublisher{
+		pub:          pub,
+		topic:        topic,
+		queue:        make(chan publishedSample, publishQueueSize),
+		done:         make(chan struct{}),
+		clock:        clock.New(),
+		backoff:      defaultPublishBackoff,
+		maxBackoff:   maxPublishBackoff,
+		flushTimeout: publishFlushTimeout,
+	}
+}
+
+// start starts publishing queued events.
+func (p *samplePublisher) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	go p.run(ctx)
+}
+
+// run publishes queued events until the queue is closed. Once the context is canceled, the rest of
+// the events are dropped.
+func (p *samplePublisher) run(ctx context.Context) {
+	defer close(p.done)
+
+	for ev := range p.queue {
+		if ctx.Err() != nil {
+			p.dropped.Add(1)
+			continue
+		}
+		p.publish(ctx, ev)
+	}
+
+	if dropped := p.dropped.Load(); dropped > 0 {
+		log.Warnw("sample events were not published", "amount", dropped)
+	}
+}
+
+// publish publishes the event, retrying failures with exponential backoff.
+func (p *samplePublisher) publish(ctx context.Context, ev publishedSample) {
+	msg, err := json.Marshal(ev)
+	if err != nil {
+		log.Errorw("encoding sample event", "height", ev.Height, "err", err)
+		p.dropped.Add(1)
+		return
+	}
+
+	backoff := p.backoff
+	for attempt := 1; ; attempt++ {
+		err = p.pub.Publish(ctx, p.topic, msg)
+		if err == nil {
+			return
+		}
+		if attempt == publishAttempts {
+			log.Errorw("publishing sample event", "height", ev.Height, "attempts", attempt, "err", err)
+			p.dropped.Add(1)
+			return
+		}
+		log.Debugw("publishing sample event, retrying", "height", ev.Height, "backoff", backoff, "err", err)
+
+		timer := p.clock.Timer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			p.dropped.Add(1)
+			return
+		}
+		backoff = min(2*backoff, p.maxBackoff)
+	}
+}
+
+// observe queues an event for the successfully sampled height without blocking.
+func (p *samplePublisher) observe(o sampleOutcome) {
+	if o.err != nil {
+		return
+	}
+
+	ev := publishedSample{
+		Height:     o.height,
+		Source:     o.source,
+		DurationNs: o.duration.Nanoseconds(),
+		Metadata:   o.metadata,
+	}
+	if o.header != nil {
+		ev.Root = o.header.DataHash.String()
+	}
+
+	select {
+	case p.queue <- ev:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+// close stops queueing events and waits for the queued ones to be published. Publishing is aborted
+// once flushTimeout passes or the context is done, and the rest of the events are dropped. It must
+// be called only after all observers are done.
+func (p *samplePublisher) close(ctx context.Context) error {
+	close(p.queue)
+	defer p.cancel()
+
+	timer := p.clock.Timer(p.flushTimeout)
+	defer timer.Stop()
+	select {
+	case <-p.done:
+		return nil
+	case <-timer.C:
+		log.Warnw("sample events were not published in time, dropping the rest", "queued", len(p.queue))
+	case <-ctx.Done():
+	}
+
+	p.cancel()
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
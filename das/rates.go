@@ -0,0 +1,123 @@
+package das
+
+import (
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+const (
+	// rateBucketWidth is the time span of a single bucket of sliding success rate window
+	rateBucketWidth = 10 * time.Second
+	// rateBuckets is the amount of buckets kept to cover the largest window
+	rateBuckets = int(15 * time.Minute / rateBucketWidth)
+)
+
+// maxRateSources bounds the amount of sources success rates are tracked for separately, as sources
+// of samples on demand are chosen by callers.
+const maxRateSources = 16
+
+// otherSource is the source success rates of samples are tracked under once maxRateSources
+// sources are tracked already.
+const otherSource SampleSource = "other"
+
+// successRateWindows are the sliding windows success rates are computed over.
+var successRateWindows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// successRates tracks sampling success rates per source over sliding windows.
+type successRates struct {
+	lock    sync.Mutex
+	sources map[SampleSource]*slidingRate
+	clock   clock.Clock
+}
+
+func newSuccessRates() *successRates {
+	return &successRates{
+		sources: make(map[SampleSource]*slidingRate),
+		clock:   clock.New(),
+	}
+}
+
+func (r *successRates) observe(o sampleOutcome) {
+	r.add(o.source, r.clock.Now(), o.err == nil)
+}
+
+func (r *successRates) add(source SampleSource, now time.Time, success bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	rate, ok := r.sources[source]
+	if !ok && len(r.sources) >= maxRateSources-1 {
+		// keep a slot for otherSource
+		source = otherSource
+		rate, ok = r.sources[source]
+	}
+	if !ok {
+		rate = &slidingRate{}
+		r.sources[source] = rate
+	}
+	rate.add(now, success)
+}
+
+// get returns success rates for each source and window with at least one sampling attempt.
+func (r *successRates) get(now time.Time) map[SampleSource]map[time.Duration]float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	out := make(map[SampleSource]map[time.Duration]float64, len(r.sources))
+	for source, rate := range r.sources {
+		for _, window := range successRateWindows {
+			value, ok := rate.rate(now, window)
+			if !ok {
+				continue
+			}
+			if out[source] == nil {
+				out[source] = make(map[time.Duration]float64, len(successRateWindows))
+			}
+			out[source][window] = value
+		}
+	}
+	return out
+}
+
+// slidingRate counts successful and total attempts in a ring of fixed-width time buckets.
+type slidingRate struct {
+	buckets [rateBuckets]rateBucket
+}
+
+type rateBucket struct {
+	// idx is the index of the time span the bucket counts attempts for
+	idx            int64
+	success, total uint64
+}
+
+func (r *slidingRate) add(now time.Time, success bool) {
+	idx := now.UnixNano() / int64(rateBucketWidth)
+	b := &r.buckets[idx%int64(rateBuckets)]
+	if b.idx != idx {
+		*b = rateBucket{idx: idx}
+	}
+	b.total++
+	if success {
+		b.success++
+	}
+}
+
+// rate returns the share of successful attempts within the window ending at now. It returns false
+// if there were no attempts within the window.
+func (r *slidingRate) rate(now time.Time, window time.Duration) (float64, bool) {
+	idx := now.UnixNano() / int64(rateBucketWidth)
+	oldest := idx - int64(window/rateBucketWidth)
+
+	var success, total uint64
+	for _, b := range r.buckets {
+		if b.idx > oldest && b.idx <= idx {
+			success += b.success
+			total += b.total
+		}
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return float64(success) / float64(total), true
+}
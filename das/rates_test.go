@@ -0,0 +1,55 @@
+package das
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuccessRates(t *testing.T) {
+	rates := newSuccessRates()
+	now := time.Now()
+
+	// 10 minutes ago catchup was failing
+	for i := 0; i < 10; i++ {
+		rates.add(catchupJob, now.Add(-10*time.Minute), false)
+	}
+	// recently 3 out of 4 attempts succeeded
+	for i := 0; i < 3; i++ {
+		rates.add(catchupJob, now.Add(-30*time.Second), true)
+	}
+	rates.add(catchupJob, now, false)
+	rates.add(recentJob, now, true)
+
+	got := rates.get(now)
+	require.Contains(t, got, catchupJob)
+	require.Contains(t, got, recentJob)
+
+	const tolerance = 0.001
+	assert.InDelta(t, 0.75, got[catchupJob][time.Minute], tolerance)
+	assert.InDelta(t, 0.75, got[catchupJob][5*time.Minute], tolerance)
+	assert.InDelta(t, 3.0/14.0, got[catchupJob][15*time.Minute], tolerance)
+	assert.InDelta(t, 1.0, got[recentJob][time.Minute], tolerance)
+
+	// all attempts fall out of the windows eventually
+	assert.Empty(t, rates.get(now.Add(time.Hour)))
+}
+
+func TestSuccessRates_BoundedSources(t *testing.T) {
+	rates := newSuccessRates()
+	now := time.Now()
+
+	for i := 0; i < 2*maxRateSources; i++ {
+		rates.add(SampleSource(fmt.Sprintf("source-%d", i)), now, true)
+	}
+
+	got := rates.get(now)
+	assert.Len(t, got, maxRateSources)
+	assert.Contains(t, got, SampleSource("source-0"))
+	// sources beyond the limit are aggregated
+	assert.Contains(t, got, otherSource)
+	assert.NotContains(t, got, SampleSource(fmt.Sprintf("source-%d", 2*maxRateSources-1)))
+}
@@ -0,0 +1,54 @@
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+// rootIndexPrefix keeps the index apart from other data of the given datastore, e.g. the checkpoint.
+var rootIndexPrefix = datastore.NewKey("das_roots")
+
+// rootIndex maintains the index of data roots of sampled heights in the datastore.
+type rootIndex struct {
+	ds datastore.Datastore
+}
+
+func newRootIndex(ds datastore.Datastore) *rootIndex {
+	return &rootIndex{ds: namespace.Wrap(ds, rootIndexPrefix)}
+}
+
+// put indexes the data root of the sampled header. A root indexed for the height before, e.g.
+// before a reorg, is replaced.
+func (idx *rootIndex) put(ctx context.Context, h *header.ExtendedHeader) error {
+	if idx == nil {
+		return nil
+	}
+	if err := idx.ds.Put(ctx, rootIndexKey(h.Height()), h.DataHash); err != nil {
+		return fmt.Errorf("das: indexing root of height %d: %w", h.Height(), err)
+	}
+	return nil
+}
+
+// get returns the data root indexed for the height.
+func (idx *rootIndex) get(ctx context.Context, height uint64) (share.DataHash, error) {
+	root, err := idx.ds.Get(ctx, rootIndexKey(height))
+	if errors.Is(err, datastore.ErrNotFound) {
+		return nil, fmt.Errorf("das: root of height %d is not indexed: %w", height, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func rootIndexKey(height uint64) datastore.Key {
+	return datastore.NewKey(strconv.FormatUint(height, 10))
+}
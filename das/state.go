@@ -5,9 +5,14 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/benbjohnson/clock"
+
 	"github.com/celestiaorg/celestia-node/header"
 )
 
+// maxPacedBlocks is the maximum amount of block times a recent job is delayed by pacing.
+const maxPacedBlocks = 4
+
 // coordinatorState represents the current state of sampling process
 type coordinatorState struct {
 	// sampleFrom is the height from which the DASer will start sampling
@@ -20,11 +25,29 @@ type coordinatorState struct {
 
 	// retryStrategy implements retry backoff
 	retryStrategy retryStrategy
+	// retryOrder defines the order failed heights are retried in
+	retryOrder RetryOrder
+	// maxRetries is the amount of retries after which failed heights are parked. 0 means unlimited
+	maxRetries int
+	// strictContiguity holds back the checkpoint and stops catchup at failed heights until they are
+	// sampled successfully
+	strictContiguity bool
+	// backpressureDelay is the delay before heights throttled by backpressure are sampled again
+	backpressureDelay time.Duration
 	// stores heights of failed headers with amount of retry attempt as value
 	failed map[uint64]retryAttempt
 	// inRetry stores (height -> attempt count) of failed headers that are currently being retried by
 	// workers
 	inRetry map[uint64]retryAttempt
+	// throttled stores heights that were not sampled due to backpressure from availability, so they
+	// are requeued after backpressureDelay. The attempt count is kept for heights that were retried.
+	throttled map[uint64]retryAttempt
+
+	// sampled stores heights that were successfully sampled ahead of catchup with the time they
+	// were sampled at. Catchup does not sample them again.
+	sampled map[uint64]time.Time
+	// sampleTTL is the period after which records in sampled expire
+	sampleTTL time.Duration
 
 	// nextJobID is a unique identifier that will be used for creation of next job
 	nextJobID int
@@ -32,6 +55,21 @@ type coordinatorState struct {
 	next uint64
 	// networkHead is the height of the latest known network head
 	networkHead uint64
+	// catchupChunkSize is the maximum amount of catchup heights in progress. 0 means unlimited
+	catchupChunkSize uint64
+	// catchupQueued is the amount of catchup heights in progress
+	catchupQueued uint64
+
+	// blockTime is the minimum interval between recent jobs started at the network head
+	blockTime time.Duration
+	// pacedAt is the time the last recent job paced by blockTime starts at
+	pacedAt time.Time
+	// paced keeps recent jobs waiting to be dispatched at their paced start time, ordered by it.
+	// They are not run by workers yet, but are reported in stats, so they are resumed from the
+	// checkpoint.
+	paced []job
+
+	clock clock.Clock
 
 	// catchUpDone indicates if all headers are sampled
 	catchUpDone atomic.Bool
@@ -45,6 +83,8 @@ type retryAttempt struct {
 	count int
 	// after specifies the time for the next retry attempt.
 	after time.Time
+	// unparked allows one more attempt for the height exceeding the maximum amount of retries.
+	unparked bool
 }
 
 // newCoordinatorState initiates state for samplingCoordinator
@@ -57,12 +97,22 @@ func newCoordinatorState(params Parameters) coordinatorState {
 			defaultBackoffInitialInterval,
 			defaultBackoffMultiplier,
 			defaultBackoffMaxRetryCount)),
-		failed:        make(map[uint64]retryAttempt),
-		inRetry:       make(map[uint64]retryAttempt),
-		nextJobID:     0,
-		next:          params.SampleFrom,
-		networkHead:   params.SampleFrom,
-		catchUpDoneCh: make(chan struct{}),
+		retryOrder:        params.RetryOrder,
+		maxRetries:        params.MaxRetries,
+		strictContiguity:  params.StrictContiguity,
+		backpressureDelay: defaultBackpressureDelay,
+		failed:            make(map[uint64]retryAttempt),
+		inRetry:           make(map[uint64]retryAttempt),
+		throttled:         make(map[uint64]retryAttempt),
+		sampled:           make(map[uint64]time.Time),
+		sampleTTL:         params.SampleTTL,
+		nextJobID:         0,
+		next:              params.SampleFrom,
+		networkHead:       params.SampleFrom,
+		catchupChunkSize:  params.CatchupChunkSize,
+		blockTime:         params.BlockTime,
+		clock:             clock.New(),
+		catchUpDoneCh:     make(chan struct{}),
 	}
 }
 
@@ -74,13 +124,30 @@ func (s *coordinatorState) resumeFromCheckpoint(c checkpoint) {
 		// resumed retries should start without backoff delay
 		s.failed[h] = retryAttempt{
 			count: count,
-			after: time.Now(),
+			after: s.clock.Now(),
+		}
+	}
+
+	now := s.clock.Now()
+	for h, at := range c.Sampled {
+		if !s.isExpired(at, now) {
+			s.sampled[h] = at
 		}
 	}
+
+	// the checkpoint could have nothing left to sample, e.g. after the head rolled back
+	s.checkDone()
 }
 
 func (s *coordinatorState) handleResult(res result) {
+	if _, ok := s.inProgress[res.id]; !ok {
+		// result of a canceled job
+		return
+	}
 	delete(s.inProgress, res.id)
+	if res.jobType == catchupJob {
+		s.catchupQueued -= res.to - res.from + 1
+	}
 
 	switch res.jobType {
 	case recentJob, catchupJob:
@@ -106,9 +173,43 @@ func (s *coordinatorState) handleRecentOrCatchupResult(res result) {
 
 	// update failed heights
 	for h := range res.failed {
-		nextRetry, _ := s.retryStrategy.nextRetry(retryAttempt{}, time.Now())
+		nextRetry, _ := s.retryStrategy.nextRetry(retryAttempt{}, s.clock.Now())
 		s.failed[h] = nextRetry
 	}
+
+	s.handleThrottled(res)
+
+	// remember recent heights sampled ahead of catchup, so catchup doesn't sample them again
+	if res.jobType == recentJob && len(res.failed) == 0 && len(res.throttled) == 0 && res.from >= s.next {
+		s.sampled[res.from] = s.clock.Now()
+	}
+	s.pruneSampled(s.clock.Now())
+}
+
+// handleThrottled requeues heights that were not sampled due to backpressure. They are sampled
+// again after backpressureDelay keeping the amount of attempts, so backpressure does not count as
+// sampling failure.
+func (s *coordinatorState) handleThrottled(res result) {
+	for _, h := range res.throttled {
+		// inRetry is only set for retried heights, others start with zero attempts
+		attempt := s.inRetry[h]
+		attempt.after = s.clock.Now().Add(s.backpressureDelay)
+		s.throttled[h] = attempt
+	}
+}
+
+// pruneSampled removes records of heights that were either passed by catchup or expired.
+func (s *coordinatorState) pruneSampled(now time.Time) {
+	for h, at := range s.sampled {
+		if h < s.next || s.isExpired(at, now) {
+			delete(s.sampled, h)
+		}
+	}
+}
+
+// isExpired indicates whether a sampled record created at the given time has expired.
+func (s *coordinatorState) isExpired(sampledAt, now time.Time) bool {
+	return s.sampleTTL > 0 && now.Sub(sampledAt) > s.sampleTTL
 }
 
 func (s *coordinatorState) handleRetryResult(res result) {
@@ -117,8 +218,15 @@ func (s *coordinatorState) handleRetryResult(res result) {
 	for h := range res.failed {
 		lastRetry := s.inRetry[h]
 		// height will be retried after backoff
-		nextRetry, retryExceeded := s.retryStrategy.nextRetry(lastRetry, time.Now())
-		if retryExceeded {
+		nextRetry, retryExceeded := s.retryStrategy.nextRetry(lastRetry, s.clock.Now())
+		// the extra attempt of the unparked height is used up
+		nextRetry.unparked = false
+		switch {
+		case s.isParked(nextRetry):
+			log.Warnw("header exceeded maximum amount of retries, it will not be retried anymore",
+				"height", h,
+				"attempts", nextRetry.count)
+		case retryExceeded:
 			log.Warnw("header exceeded maximum amount of sampling attempts",
 				"height", h,
 				"attempts", nextRetry.count)
@@ -126,6 +234,8 @@ func (s *coordinatorState) handleRetryResult(res result) {
 		s.failed[h] = nextRetry
 	}
 
+	s.handleThrottled(res)
+
 	// processed height are either already moved to failed map or succeeded, cleanup inRetry
 	for h := res.from; h <= res.to; h++ {
 		delete(s.inRetry, h)
@@ -133,6 +243,10 @@ func (s *coordinatorState) handleRetryResult(res result) {
 }
 
 func (s *coordinatorState) isNewHead(newHead uint64) bool {
+	if newHead > maxHeight {
+		log.Warnw("received head height above the maximum height, ignoring", "height", newHead)
+		return false
+	}
 	// seen this header before
 	if newHead <= s.networkHead {
 		log.Warnf("received head height: %v, which is lower or the same as previously known: %v", newHead, s.networkHead)
@@ -153,69 +267,222 @@ func (s *coordinatorState) updateHead(newHead uint64) {
 
 // recentJob creates a job to process a recent header.
 func (s *coordinatorState) recentJob(header *header.ExtendedHeader) job {
+	notBefore := s.paceRecent(header.Height())
 	// move next, to prevent catchup job from processing same height
 	if s.next == header.Height() {
 		s.next++
 	}
 	s.nextJobID++
 	return job{
-		id:      s.nextJobID,
-		jobType: recentJob,
-		header:  header,
-		from:    header.Height(),
-		to:      header.Height(),
+		id:        s.nextJobID,
+		jobType:   recentJob,
+		header:    header,
+		from:      header.Height(),
+		to:        header.Height(),
+		notBefore: notBefore,
 	}
 }
 
-// nextJob will return next catchup or retry job according to priority (retry -> catchup)
+// paceRecent returns the time the recent job of the height should start at, so that recent jobs
+// are spaced by blockTime while sampling is at the network head, i.e. all heights before the given
+// one were already dispatched. Otherwise, the job starts immediately. The start is delayed by at
+// most maxPacedBlocks block times from the arrival of the header, so pacing does not fall behind
+// the network if blocks are produced faster than blockTime.
+func (s *coordinatorState) paceRecent(height uint64) time.Time {
+	if s.blockTime == 0 || s.next != height || height < s.networkHead {
+		return time.Time{}
+	}
+
+	arrival := s.clock.Now()
+	notBefore := arrival
+	if next := s.pacedAt.Add(s.blockTime); next.After(notBefore) {
+		notBefore = next
+	}
+	if limit := arrival.Add(maxPacedBlocks * s.blockTime); notBefore.After(limit) {
+		notBefore = limit
+	}
+	s.pacedAt = notBefore
+	return notBefore
+}
+
+// duePaced removes and returns paced jobs whose start time has come.
+func (s *coordinatorState) duePaced() []job {
+	now := s.clock.Now()
+	due := 0
+	for due < len(s.paced) && !s.paced[due].notBefore.After(now) {
+		due++
+	}
+	jobs := s.paced[:due:due]
+	s.paced = s.paced[due:]
+	return jobs
+}
+
+// nextJob will return next catchup or retry job according to priority (retry -> throttled ->
+// catchup)
 func (s *coordinatorState) nextJob() (next job, found bool) {
 	// check for if any retry jobs are available
 	if job, found := s.retryJob(); found {
 		return job, found
 	}
 
+	if job, found := s.requeueJob(); found {
+		return job, found
+	}
+
 	// if no retry jobs, make a catchup job
 	return s.catchupJob()
 }
 
-// catchupJob creates a catchup job if catchup is not finished
+// hasNextJob indicates whether nextJob would return a job.
+func (s *coordinatorState) hasNextJob() bool {
+	if s.next <= s.networkHead && s.catchupRoom() > 0 && !s.stalled() {
+		return true
+	}
+	now := s.clock.Now()
+	for _, attempt := range s.failed {
+		if !s.isParked(attempt) && attempt.canRetry(now) {
+			return true
+		}
+	}
+	for _, attempt := range s.throttled {
+		if attempt.canRetry(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// catchupJob creates a catchup job if catchup is not finished and the chunk of catchup heights in
+// progress is not full
 func (s *coordinatorState) catchupJob() (next job, found bool) {
-	if s.next > s.networkHead {
+	room := s.catchupRoom()
+	if s.next > s.networkHead || room == 0 || s.stalled() {
 		return job{}, false
 	}
 
-	to := s.next + s.samplingRange - 1
-	if to > s.networkHead {
-		to = s.networkHead
+	// the range is bounded by the network head without computing heights past it, so it never
+	// overflows
+	to := s.networkHead
+	if span := min(s.samplingRange, room) - 1; s.networkHead-s.next > span {
+		to = s.next + span
 	}
 	j := s.newJob(catchupJob, s.next, to)
+	j.sampled = s.sampledWithin(j.from, j.to)
 	s.next = to + 1
 	return j, true
 }
 
-// retryJob creates a job to retry previously failed header
+// resumeJob creates a job to resume the worker stored in the checkpoint. Like with catchup jobs,
+// heights of its range that were sampled ahead of catchup are skipped.
+func (s *coordinatorState) resumeJob(wk workerCheckpoint) job {
+	j := s.newJob(wk.JobType, wk.From, wk.To)
+	j.sampled = s.sampledWithin(j.from, j.to)
+	return j
+}
+
+// sampledWithin returns heights within the range that were sampled ahead of catchup and have not
+// expired yet, with the time they were sampled at.
+func (s *coordinatorState) sampledWithin(from, to uint64) map[uint64]time.Time {
+	var sampled map[uint64]time.Time
+	now := s.clock.Now()
+	for h, at := range s.sampled {
+		if h < from || h > to || s.isExpired(at, now) {
+			continue
+		}
+		if sampled == nil {
+			sampled = make(map[uint64]time.Time)
+		}
+		sampled[h] = at
+	}
+	return sampled
+}
+
+// retryJob creates a job to retry previously failed header. Out of the headers ready for retry, the
+// first one according to the retry order is picked.
 func (s *coordinatorState) retryJob() (next job, found bool) {
-	for h, attempt := range s.failed {
-		if !attempt.canRetry() {
-			// height will be retried later
+	var (
+		h       uint64
+		attempt retryAttempt
+	)
+	now := s.clock.Now()
+	for height, a := range s.failed {
+		if s.isParked(a) || !a.canRetry(now) {
+			// height will be retried later or not at all
 			continue
 		}
+		if !found || s.retryOrder.before(height, a, h, attempt) {
+			h, attempt, found = height, a, true
+		}
+	}
+	if !found {
+		return job{}, false
+	}
+
+	// move header from failed into retry
+	delete(s.failed, h)
+	s.inRetry[h] = attempt
+	j := s.newJob(retryJob, h, h)
+	j.attempt = attempt.count + 1
+	return j, true
+}
 
-		// move header from failed into retry
-		delete(s.failed, h)
-		s.inRetry[h] = attempt
-		j := s.newJob(retryJob, h, h)
-		return j, true
+// requeueJob creates a job to sample the lowest height throttled by backpressure once its delay
+// has passed. Heights that were retried are retried again, others are sampled by catchup.
+func (s *coordinatorState) requeueJob() (next job, found bool) {
+	var (
+		h       uint64
+		attempt retryAttempt
+	)
+	now := s.clock.Now()
+	for height, a := range s.throttled {
+		if a.canRetry(now) && (!found || height < h) {
+			h, attempt, found = height, a, true
+		}
+	}
+	if !found {
+		return job{}, false
 	}
 
-	return job{}, false
+	delete(s.throttled, h)
+	if attempt.count == 0 {
+		return s.newJob(catchupJob, h, h), true
+	}
+	s.inRetry[h] = attempt
+	j := s.newJob(retryJob, h, h)
+	j.attempt = attempt.count + 1
+	return j, true
+}
+
+// stalled indicates whether catchup is stopped at a gap of failed heights in strict contiguity mode.
+// Jobs already in progress are not affected, so catchup may be ahead of the gap by their ranges.
+func (s *coordinatorState) stalled() bool {
+	return s.strictContiguity && (len(s.failed) > 0 || len(s.inRetry) > 0)
+}
+
+// catchupRoom returns the amount of catchup heights that can be dispatched before the chunk is full.
+func (s *coordinatorState) catchupRoom() uint64 {
+	if s.catchupChunkSize == 0 {
+		return s.samplingRange
+	}
+	if s.catchupQueued >= s.catchupChunkSize {
+		return 0
+	}
+	return s.catchupChunkSize - s.catchupQueued
 }
 
 func (s *coordinatorState) putInProgress(jobID int, getState func() workerState) {
 	s.inProgress[jobID] = getState
 }
 
-func (s *coordinatorState) newJob(jobType jobType, from, to uint64) job {
+// dropInProgress stops tracking of the canceled job. The result of the job, if any, is ignored.
+func (s *coordinatorState) dropInProgress(jobID int) {
+	delete(s.inProgress, jobID)
+}
+
+func (s *coordinatorState) newJob(jobType SampleSource, from, to uint64) job {
+	if jobType == catchupJob {
+		s.catchupQueued += to - from + 1
+	}
 	s.nextJobID++
 	return job{
 		id:      s.nextJobID,
@@ -230,6 +497,17 @@ func (s *coordinatorState) unsafeStats() SamplingStats {
 	workers := make([]WorkerStats, 0, len(s.inProgress))
 	lowestFailedOrInProgress := s.next
 	failed := make(map[uint64]int)
+	now := s.clock.Now()
+	var sampled map[uint64]time.Time
+	addSampled := func(h uint64, at time.Time) {
+		if s.isExpired(at, now) {
+			return
+		}
+		if sampled == nil {
+			sampled = make(map[uint64]time.Time)
+		}
+		sampled[h] = at
+	}
 
 	// gather worker stats
 	for _, getStats := range s.inProgress {
@@ -256,6 +534,40 @@ func (s *coordinatorState) unsafeStats() SamplingStats {
 		if wstats.curr < lowestFailedOrInProgress {
 			lowestFailedOrInProgress = wstats.curr
 		}
+
+		// heights sampled ahead of catchup are kept until the worker passes them, so the worker
+		// resumed from the checkpoint skips them too
+		for h, at := range wstats.sampled {
+			if h >= wstats.curr {
+				addSampled(h, at)
+			}
+		}
+	}
+
+	// paced jobs are reported like workers that haven't sampled anything yet
+	for _, j := range s.paced {
+		workers = append(workers, WorkerStats{
+			JobType: j.jobType,
+			Curr:    j.from,
+			From:    j.from,
+			To:      j.to,
+		})
+		if j.from < lowestFailedOrInProgress {
+			lowestFailedOrInProgress = j.from
+		}
+	}
+
+	// throttled heights are reported like catchup workers that haven't sampled anything yet, so they
+	// are resumed from the checkpoint, unless they were retried and are resumed as failed
+	for h, attempt := range s.throttled {
+		if attempt.count > 0 {
+			failed[h] += attempt.count
+		} else {
+			workers = append(workers, WorkerStats{JobType: catchupJob, Curr: h, From: h, To: h})
+		}
+		if h < lowestFailedOrInProgress {
+			lowestFailedOrInProgress = h
+		}
 	}
 
 	// set lowestFailedOrInProgress to minimum failed - 1
@@ -270,11 +582,18 @@ func (s *coordinatorState) unsafeStats() SamplingStats {
 		failed[h] += retry.count
 	}
 
+	for h, at := range s.sampled {
+		if h >= s.next {
+			addSampled(h, at)
+		}
+	}
+
 	return SamplingStats{
-		SampledChainHead: lowestFailedOrInProgress - 1,
-		CatchupHead:      s.next - 1,
+		SampledChainHead: prevHeight(lowestFailedOrInProgress),
+		CatchupHead:      prevHeight(s.next),
 		NetworkHead:      s.networkHead,
 		Failed:           failed,
+		Sampled:          sampled,
 		Workers:          workers,
 		Concurrency:      len(workers),
 		CatchUpDone:      s.catchUpDone.Load(),
@@ -282,8 +601,22 @@ func (s *coordinatorState) unsafeStats() SamplingStats {
 	}
 }
 
+// checkpoint creates a checkpoint out of the given stats. In strict contiguity mode, the checkpoint
+// does not advance past the lowest failed height.
+func (s *coordinatorState) checkpoint(stats SamplingStats) checkpoint {
+	cp := newCheckpoint(stats)
+	if s.strictContiguity {
+		for h := range cp.Failed {
+			cp.SampleFrom = min(cp.SampleFrom, h)
+		}
+	}
+	return cp
+}
+
 func (s *coordinatorState) checkDone() {
-	if len(s.inProgress) == 0 && len(s.failed) == 0 && s.next > s.networkHead {
+	// parked heights are not retried anymore, so catchup is done without them
+	if len(s.inProgress) == 0 && len(s.paced) == 0 && !s.hasRetries() && len(s.throttled) == 0 &&
+		s.next > s.networkHead {
 		if s.catchUpDone.CompareAndSwap(false, true) {
 			close(s.catchUpDoneCh)
 		}
@@ -310,7 +643,43 @@ func (s *coordinatorState) waitCatchUp(ctx context.Context) error {
 	return nil
 }
 
-// canRetry returns true if the time stored in the "after" has passed.
-func (r retryAttempt) canRetry() bool {
-	return r.after.Before(time.Now())
+// retryAllFailed makes all failed heights ready for retry immediately, regardless of their backoff,
+// and allows one more attempt for parked ones. It returns the amount of attempts made so far for
+// each failed height, including the ones being retried at the moment.
+func (s *coordinatorState) retryAllFailed() map[uint64]int {
+	attempts := make(map[uint64]int, len(s.failed)+len(s.inRetry))
+	for h, attempt := range s.failed {
+		if s.isParked(attempt) {
+			attempt.unparked = true
+		}
+		attempt.after = time.Time{}
+		s.failed[h] = attempt
+		attempts[h] = attempt.count
+	}
+	for h, attempt := range s.inRetry {
+		attempts[h] = attempt.count
+	}
+	s.checkDone()
+	return attempts
+}
+
+// hasRetries reports whether any of the failed heights is still to be retried.
+func (s *coordinatorState) hasRetries() bool {
+	for _, attempt := range s.failed {
+		if !s.isParked(attempt) {
+			return true
+		}
+	}
+	return false
+}
+
+// isParked reports whether the failed height exceeded the maximum amount of retries and should not
+// be retried anymore. The initial sampling attempt is counted as well.
+func (s *coordinatorState) isParked(r retryAttempt) bool {
+	return s.maxRetries > 0 && r.count > s.maxRetries && !r.unparked
+}
+
+// canRetry returns true if the time stored in the "after" has passed by now.
+func (r retryAttempt) canRetry(now time.Time) bool {
+	return r.after.Before(now)
 }
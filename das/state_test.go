@@ -2,10 +2,16 @@ package das
 
 import (
 	"errors"
+	"math"
 	"sort"
 	"testing"
+	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/celestia-node/header/headertest"
 )
 
 func Test_coordinatorStats(t *testing.T) {
@@ -55,6 +61,7 @@ func Test_coordinatorStats(t *testing.T) {
 				nextJobID:   0,
 				next:        31,
 				networkHead: 100,
+				clock:       clock.New(),
 			},
 			SamplingStats{
 				SampledChainHead: 11,
@@ -93,3 +100,254 @@ func Test_coordinatorStats(t *testing.T) {
 		})
 	}
 }
+
+func Test_coordinatorState_sampleTTL(t *testing.T) {
+	params := DefaultParameters()
+	params.SampleTTL = time.Minute
+	state := newCoordinatorState(params)
+	state.resumeFromCheckpoint(checkpoint{SampleFrom: 10, NetworkHead: 30})
+
+	now := time.Now()
+	state.sampled[5] = now                  // part of the contiguous prefix
+	state.sampled[15] = now.Add(-time.Hour) // expired
+	state.sampled[20] = now
+
+	stats := state.unsafeStats()
+	assert.EqualValues(t, 9, stats.CatchupHead)
+	assert.Equal(t, map[uint64]time.Time{20: now}, stats.Sampled)
+
+	cp := newCheckpoint(stats)
+	assert.EqualValues(t, 10, cp.SampleFrom)
+	assert.Len(t, cp.Sampled, 1)
+
+	state.pruneSampled(now)
+	assert.Len(t, state.sampled, 1)
+	assert.Contains(t, state.sampled, uint64(20))
+
+	// catchup should skip the height that has been already sampled
+	j, found := state.catchupJob()
+	assert.True(t, found)
+	assert.Equal(t, map[uint64]time.Time{20: now}, j.sampled)
+}
+
+func Test_coordinatorState_resumeSampled(t *testing.T) {
+	params := DefaultParameters()
+	params.SamplingRange = 10
+	state := newCoordinatorState(params)
+	state.resumeFromCheckpoint(checkpoint{SampleFrom: 1, NetworkHead: 10})
+	now := state.clock.Now()
+	state.sampled[5] = now
+
+	j, found := state.catchupJob()
+	require.True(t, found)
+	require.Contains(t, j.sampled, uint64(5))
+	state.putInProgress(j.id, func() workerState { return workerState{curr: 3, result: result{job: j}} })
+	// catchup passed the height, but the worker did not yet
+	state.pruneSampled(now)
+	require.Empty(t, state.sampled)
+
+	cp := newCheckpoint(state.unsafeStats())
+	require.Equal(t, []workerCheckpoint{{From: 3, To: 10, JobType: catchupJob}}, cp.Workers)
+	assert.Contains(t, cp.Sampled, uint64(5))
+
+	// the resumed worker skips the height sampled ahead of catchup
+	resumed := newCoordinatorState(params)
+	resumed.resumeFromCheckpoint(cp)
+	j = resumed.resumeJob(cp.Workers[0])
+	assert.EqualValues(t, 3, j.from)
+	assert.EqualValues(t, 10, j.to)
+	assert.Contains(t, j.sampled, uint64(5))
+}
+
+func Test_coordinatorState_retryOrder(t *testing.T) {
+	failed := map[uint64]int{3: 3, 5: 1, 9: 2, 12: 1}
+
+	tests := []struct {
+		order    RetryOrder
+		expected []uint64
+	}{
+		{order: RetryOldestFirst, expected: []uint64{3, 5, 9, 12}},
+		{order: RetryNewestFirst, expected: []uint64{12, 9, 5, 3}},
+		{order: RetryFewestAttemptsFirst, expected: []uint64{5, 12, 9, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			params := DefaultParameters()
+			params.RetryOrder = tt.order
+			state := newCoordinatorState(params)
+			// failed heights are re-enqueued on restart
+			state.resumeFromCheckpoint(checkpoint{SampleFrom: 20, NetworkHead: 20, Failed: failed})
+			// resumed heights are ready for retry immediately
+			time.Sleep(time.Millisecond)
+
+			retried := make([]uint64, 0, len(failed))
+			for {
+				j, found := state.retryJob()
+				if !found {
+					break
+				}
+				assert.Equal(t, failed[j.from]+1, j.attempt)
+				retried = append(retried, j.from)
+			}
+			assert.Equal(t, tt.expected, retried)
+		})
+	}
+}
+
+func Test_coordinatorState_backoffWithMockClock(t *testing.T) {
+	mock := clock.NewMock()
+	state := newCoordinatorState(DefaultParameters())
+	state.clock = mock
+	state.resumeFromCheckpoint(checkpoint{SampleFrom: 1, NetworkHead: 1})
+
+	// fail runs the job and reports the height as failed
+	fail := func(j job) {
+		state.putInProgress(j.id, func() workerState { return workerState{} })
+		state.handleResult(result{job: j, failed: map[uint64]int{j.from: 1}})
+	}
+
+	j, found := state.nextJob()
+	require.True(t, found)
+	fail(j)
+
+	interval := defaultBackoffInitialInterval
+	for attempt := 2; attempt <= 3; attempt++ {
+		mock.Add(interval - time.Second)
+		_, found = state.retryJob()
+		require.False(t, found, "height is retried before backoff elapsed")
+
+		mock.Add(2 * time.Second)
+		j, found = state.retryJob()
+		require.True(t, found, "height is not retried after backoff elapsed")
+		assert.Equal(t, attempt, j.attempt)
+
+		fail(j)
+		interval *= time.Duration(defaultBackoffMultiplier)
+	}
+}
+
+func Test_coordinatorState_paceRecent(t *testing.T) {
+	const blockTime = time.Second
+	mock := clock.NewMock()
+	params := DefaultParameters()
+	params.BlockTime = blockTime
+	state := newCoordinatorState(params)
+	state.clock = mock
+	state.next, state.networkHead = 10, 9
+
+	for i := 0; i < 12; i++ {
+		h := headertest.RandExtendedHeader(t)
+		h.RawHeader.Height = int64(state.next)
+		j := state.recentJob(h)
+		state.updateHead(h.Height())
+
+		// headers arrive twice as often as the block time, so the delay grows until it is capped
+		expected := min(time.Duration(i)*blockTime/2, maxPacedBlocks*blockTime)
+		assert.Equal(t, expected, j.notBefore.Sub(mock.Now()), "height %d", h.Height())
+		mock.Add(blockTime / 2)
+	}
+}
+
+func Test_coordinatorState_requeueThrottled(t *testing.T) {
+	mock := clock.NewMock()
+	params := DefaultParameters()
+	params.SamplingRange = 10
+	state := newCoordinatorState(params)
+	state.clock = mock
+	state.resumeFromCheckpoint(checkpoint{SampleFrom: 1, NetworkHead: 10})
+
+	j, found := state.nextJob()
+	require.True(t, found)
+	state.putInProgress(j.id, func() workerState { return workerState{} })
+	state.handleResult(result{job: j, failed: map[uint64]int{}, throttled: []uint64{3}})
+
+	// throttled heights are not failed, but are resumed from the checkpoint
+	assert.Empty(t, state.failed)
+	stats := state.unsafeStats()
+	assert.Empty(t, stats.Failed)
+	assert.EqualValues(t, 2, stats.SampledChainHead)
+	cp := newCheckpoint(stats)
+	assert.Equal(t, []workerCheckpoint{{From: 3, To: 3, JobType: catchupJob}}, cp.Workers)
+	assert.False(t, state.catchUpDone.Load())
+
+	// the height is requeued once the backpressure delay passes
+	_, found = state.nextJob()
+	assert.False(t, found)
+	mock.Add(state.backpressureDelay + time.Nanosecond)
+	j, found = state.nextJob()
+	require.True(t, found)
+	assert.Equal(t, catchupJob, j.jobType)
+	assert.EqualValues(t, 3, j.from)
+	assert.EqualValues(t, 3, j.to)
+}
+
+func Test_coordinatorState_parked(t *testing.T) {
+	params := DefaultParameters()
+	params.MaxRetries = 2
+	state := newCoordinatorState(params)
+	state.resumeFromCheckpoint(checkpoint{SampleFrom: 11, NetworkHead: 10, Failed: map[uint64]int{3: 5}})
+
+	// parked heights are not retried, so they don't hold back catchup
+	assert.True(t, state.catchUpDone.Load())
+	_, found := state.nextJob()
+	assert.False(t, found)
+
+	// the parked height is retried once more with its attempts kept
+	assert.Equal(t, map[uint64]int{3: 5}, state.retryAllFailed())
+	assert.False(t, state.catchUpDone.Load())
+	j, found := state.nextJob()
+	require.True(t, found)
+	assert.Equal(t, retryJob, j.jobType)
+	state.putInProgress(j.id, func() workerState { return workerState{} })
+	state.handleResult(result{job: j, failed: map[uint64]int{3: 1}})
+
+	assert.Equal(t, map[uint64]int{3: 6}, state.unsafeStats().Failed)
+	assert.True(t, state.catchUpDone.Load())
+	_, found = state.nextJob()
+	assert.False(t, found)
+}
+
+func Test_coordinatorState_heightBounds(t *testing.T) {
+	t.Run("genesis", func(t *testing.T) {
+		state := newCoordinatorState(DefaultParameters())
+		// nothing was sampled below the height 0
+		state.next = 0
+		state.networkHead = 0
+
+		stats := state.unsafeStats()
+		assert.EqualValues(t, 0, stats.SampledChainHead)
+		assert.EqualValues(t, 0, stats.CatchupHead)
+		assert.EqualValues(t, 0, stats.totalSampled())
+		cp := newCheckpoint(stats)
+		assert.EqualValues(t, 1, cp.SampleFrom)
+		require.NoError(t, cp.validate())
+	})
+
+	t.Run("near max", func(t *testing.T) {
+		params := DefaultParameters()
+		params.SamplingRange = 100
+		state := newCoordinatorState(params)
+		state.resumeFromCheckpoint(checkpoint{SampleFrom: maxHeight - 5, NetworkHead: maxHeight})
+
+		// the range ends at the network head rather than wrapping around
+		j, found := state.catchupJob()
+		require.True(t, found)
+		assert.EqualValues(t, uint64(maxHeight-5), j.from)
+		assert.EqualValues(t, uint64(maxHeight), j.to)
+		_, found = state.catchupJob()
+		assert.False(t, found)
+
+		state.putInProgress(j.id, func() workerState { return workerState{} })
+		state.handleResult(result{job: j})
+		stats := state.unsafeStats()
+		assert.EqualValues(t, uint64(maxHeight), stats.SampledChainHead)
+		assert.EqualValues(t, uint64(maxHeight), stats.CatchupHead)
+		cp := newCheckpoint(stats)
+		assert.EqualValues(t, uint64(maxHeight+1), cp.SampleFrom)
+		require.NoError(t, cp.validate())
+
+		// heads past the maximum height are rejected
+		assert.False(t, state.isNewHead(math.MaxUint64))
+	})
+}
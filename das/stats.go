@@ -1,5 +1,11 @@
 package das
 
+import (
+	"time"
+
+	"github.com/celestiaorg/celestia-node/share"
+)
+
 // SamplingStats collects information about the DASer process.
 type SamplingStats struct {
 	// all headers before SampledChainHead were successfully sampled
@@ -11,6 +17,8 @@ type SamplingStats struct {
 	NetworkHead uint64 `json:"network_head_height"`
 	// Failed contains all skipped headers heights with corresponding try count
 	Failed map[uint64]int `json:"failed,omitempty"`
+	// Sampled contains heights sampled ahead of CatchupHead with the time they were sampled at
+	Sampled map[uint64]time.Time `json:"sampled,omitempty"`
 	// Workers has information about each currently running worker stats
 	Workers []WorkerStats `json:"workers,omitempty"`
 	// Concurrency amount of currently running parallel workers
@@ -19,13 +27,21 @@ type SamplingStats struct {
 	CatchUpDone bool `json:"catch_up_done"`
 	// IsRunning tracks whether the DASer service is running
 	IsRunning bool `json:"is_running"`
+	// FetchedBytes is the total amount of bytes fetched from the network for sampling
+	FetchedBytes uint64 `json:"fetched_bytes"`
+	// FetchedBytesPerHeight contains the amount of bytes fetched for each of the most recently
+	// sampled heights, including failed attempts
+	FetchedBytesPerHeight map[uint64]uint64 `json:"fetched_bytes_per_height,omitempty"`
+	// ConfirmedRows contains rows confirmed available for heights whose data was only partially
+	// available, as reported by availability
+	ConfirmedRows map[uint64]share.RowBitmap `json:"confirmed_rows,omitempty"`
 }
 
 type WorkerStats struct {
-	JobType jobType `json:"job_type"`
-	Curr    uint64  `json:"current"`
-	From    uint64  `json:"from"`
-	To      uint64  `json:"to"`
+	JobType SampleSource `json:"job_type"`
+	Curr    uint64       `json:"current"`
+	From    uint64       `json:"from"`
+	To      uint64       `json:"to"`
 
 	ErrMsg string `json:"error,omitempty"`
 }
@@ -39,12 +55,13 @@ func (s SamplingStats) totalSampled() uint64 {
 			inProgress += w.To - w.Curr + 1
 		}
 	}
-	return s.CatchupHead - inProgress - uint64(len(s.Failed))
+	// failed heights of recent jobs could be above catchup head, so the amount is not assumed to fit
+	return heightsBetween(inProgress+uint64(len(s.Failed)), s.CatchupHead)
 }
 
 // workersByJobType returns a map of job types to the number of workers assigned to those types.
-func (s SamplingStats) workersByJobType() map[jobType]int64 {
-	workers := make(map[jobType]int64)
+func (s SamplingStats) workersByJobType() map[SampleSource]int64 {
+	workers := make(map[SampleSource]int64)
 	for _, w := range s.Workers {
 		workers[w.JobType]++
 	}
@@ -0,0 +1,49 @@
+package das
+
+import (
+	"math"
+	"sync"
+
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+// minStorageSamples is the amount of sampled heights required to establish the average size of
+// the extended square.
+const minStorageSamples = 10
+
+// storageEstimator tracks the average size of extended squares of sampled heights to estimate the
+// storage required for the rest of the heights.
+type storageEstimator struct {
+	lock    sync.Mutex
+	total   uint64
+	sampled uint64
+}
+
+// observe accounts the size of the extended square of every successfully sampled height.
+func (s *storageEstimator) observe(o sampleOutcome) {
+	if o.err != nil || o.header == nil || o.header.DAH == nil {
+		return
+	}
+
+	width := uint64(len(o.header.DAH.RowRoots))
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.total += width * width * share.Size
+	s.sampled++
+}
+
+// estimate returns the storage required for the given amount of heights, based on the average size
+// of extended squares. It returns false until the average is established. The estimate saturates at
+// math.MaxUint64 instead of overflowing.
+func (s *storageEstimator) estimate(heights uint64) (uint64, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.sampled < minStorageSamples {
+		return 0, false
+	}
+	average := s.total / s.sampled
+	if average != 0 && heights > math.MaxUint64/average {
+		return math.MaxUint64, true
+	}
+	return average * heights, true
+}
@@ -0,0 +1,56 @@
+package das
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+func TestStorageEstimator(t *testing.T) {
+	headerOfWidth := func(width int) *header.ExtendedHeader {
+		return &header.ExtendedHeader{DAH: &share.Root{RowRoots: make([][]byte, width)}}
+	}
+
+	s := &storageEstimator{}
+	// squares of 16 and 32 shares wide alternate
+	widths := []int{16, 32}
+	for i := 0; i < minStorageSamples-1; i++ {
+		s.observe(sampleOutcome{height: uint64(i + 1), header: headerOfWidth(widths[i%2])})
+	}
+	// failed samples are not accounted
+	s.observe(sampleOutcome{height: 100, header: headerOfWidth(128), err: errors.New("failed")})
+	_, ok := s.estimate(100)
+	require.False(t, ok, "average is not established yet")
+
+	s.observe(sampleOutcome{height: minStorageSamples, header: headerOfWidth(32)})
+	estimate, ok := s.estimate(100)
+	require.True(t, ok)
+
+	average := float64(16*16+32*32) / 2 * share.Size
+	assert.InEpsilon(t, average*100, float64(estimate), 0.01)
+}
+
+func TestStorageEstimator_Saturates(t *testing.T) {
+	s := &storageEstimator{}
+	for i := 0; i < minStorageSamples; i++ {
+		s.observe(sampleOutcome{
+			height: uint64(i + 1),
+			header: &header.ExtendedHeader{DAH: &share.Root{RowRoots: make([][]byte, 4)}},
+		})
+	}
+
+	estimate, ok := s.estimate(heightsBetween(0, maxHeight))
+	require.True(t, ok)
+	assert.Equal(t, uint64(math.MaxUint64), estimate)
+
+	// the network head behind the sampled chain head leaves nothing to store
+	estimate, ok = s.estimate(heightsBetween(10, 5))
+	require.True(t, ok)
+	assert.Zero(t, estimate)
+}
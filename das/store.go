@@ -2,10 +2,12 @@ package das
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-datastore/namespace"
 )
@@ -13,6 +15,10 @@ import (
 var (
 	storePrefix   = datastore.NewKey("das")
 	checkpointKey = datastore.NewKey("checkpoint")
+	// checkpointTmpKey keeps the checkpoint being stored until it replaces the one at checkpointKey
+	checkpointTmpKey = datastore.NewKey("checkpoint_tmp")
+	// checkpointPrevKey keeps the previously stored checkpoint as a fallback
+	checkpointPrevKey = datastore.NewKey("checkpoint_prev")
 )
 
 // The checkpointStore stores/loads the DASer's checkpoint to/from
@@ -21,25 +27,93 @@ var (
 type checkpointStore struct {
 	datastore.Datastore
 	done
+
+	codec Codec
+	// compression compresses the encoded checkpoint, if set
+	compression Compression
+	metrics     *metrics
+	clock       clock.Clock
+	// flushInterval is the minimum period between checkpoints stored in background
+	flushInterval time.Duration
+
+	// snapshot is the copy of the last loaded or stored checkpoint. It is never modified, so it is
+	// safe to read concurrently with stores.
+	snapshot atomic.Pointer[checkpoint]
 }
 
 // newCheckpointStore wraps the given datastore.Datastore with the `das` prefix.
 func newCheckpointStore(ds datastore.Datastore) checkpointStore {
 	return checkpointStore{
-		namespace.Wrap(ds, storePrefix),
-		newDone("checkpoint store")}
+		Datastore: namespace.Wrap(ds, storePrefix),
+		done:      newDone("checkpoint store"),
+		codec:     JSONCodec{},
+		clock:     clock.New(),
+	}
 }
 
-// load loads the DAS checkpoint from disk and returns it.
+// load loads the DAS checkpoint from disk and returns it. If the checkpoint is corrupted, e.g.
+// because it was only partially written on crash, the checkpoint of the interrupted store or the
+// previously stored checkpoint is loaded instead, whichever is intact.
 func (s *checkpointStore) load(ctx context.Context) (checkpoint, error) {
-	bs, err := s.Get(ctx, checkpointKey)
+	start := s.clock.Now()
+	cp, err := s.get(ctx)
+	opErr := err
+	if errors.Is(err, datastore.ErrNotFound) {
+		// missing checkpoint is expected on the first start, so it's not counted as failure
+		opErr = nil
+	}
+	s.metrics.observeStoreOp(ctx, storeOpLoad, s.clock.Since(start), opErr)
 	if err != nil {
 		return checkpoint{}, err
 	}
+	s.setSnapshot(cp)
+	return cp, nil
+}
+
+// get returns the first intact checkpoint out of the stored one, the one of an interrupted store and
+// the previously stored one.
+func (s *checkpointStore) get(ctx context.Context) (checkpoint, error) {
+	var errs error
+	for _, key := range []datastore.Key{checkpointKey, checkpointTmpKey, checkpointPrevKey} {
+		bs, err := s.Get(ctx, key)
+		if errors.Is(err, datastore.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return checkpoint{}, err
+		}
+
+		cp, err := s.decode(bs)
+		if err != nil {
+			log.Warnw("stored checkpoint is corrupted", "key", key.String(), "err", err)
+			errs = errors.Join(errs, err)
+			continue
+		}
+		if key != checkpointKey {
+			log.Warnw("recovered checkpoint from fallback", "key", key.String(), "checkpoint", cp.String())
+		}
+		return cp, nil
+	}
+	if errs != nil {
+		return checkpoint{}, errs
+	}
+	return checkpoint{}, datastore.ErrNotFound
+}
 
-	cp := checkpoint{}
-	err = json.Unmarshal(bs, &cp)
-	return cp, err
+func (s *checkpointStore) decode(bs []byte) (checkpoint, error) {
+	bs, err := decompressCheckpoint(s.compression, bs)
+	if err != nil {
+		return checkpoint{}, err
+	}
+	cp, err := decodeCheckpoint(s.codec, bs)
+	if err != nil {
+		return checkpoint{}, err
+	}
+	// checkpoints with heights out of range are treated as corrupted
+	if err = cp.validate(); err != nil {
+		return checkpoint{}, err
+	}
+	return cp, nil
 }
 
 // checkpointStore stores the given DAS checkpoint to disk.
@@ -47,19 +121,95 @@ func (s *checkpointStore) store(ctx context.Context, cp checkpoint) error {
 	// checkpointStore latest DASed checkpoint to disk here to ensure that if DASer is not yet
 	// fully caught up to network head, it will resume DASing from this checkpoint
 	// up to current network head
-	bs, err := json.Marshal(cp)
+	bs, err := s.codec.Encode(cp)
 	if err != nil {
 		return fmt.Errorf("marshal checkpoint: %w", err)
 	}
+	if s.compression != nil {
+		bs, err = s.compression.Compress(bs)
+		if err != nil {
+			return fmt.Errorf("compress checkpoint: %w", err)
+		}
+	}
 
-	if err = s.Put(ctx, checkpointKey, bs); err != nil {
+	start := s.clock.Now()
+	err = s.put(ctx, bs)
+	s.metrics.observeStoreOp(ctx, storeOpStore, s.clock.Since(start), err)
+	if err != nil {
 		return err
 	}
 
+	s.setSnapshot(cp)
 	log.Info("stored checkpoint to disk: ", cp.String())
 	return nil
 }
 
+// put replaces the stored checkpoint with the encoded one, so that a write interrupted at any
+// point leaves an intact checkpoint behind. The new checkpoint is written to a temporary key first,
+// then the intact current checkpoint is kept as the previous one and is replaced. If the datastore
+// supports batching, the replacement is atomic.
+func (s *checkpointStore) put(ctx context.Context, bs []byte) error {
+	if err := s.Put(ctx, checkpointTmpKey, bs); err != nil {
+		return err
+	}
+	prev, err := s.Get(ctx, checkpointKey)
+	switch {
+	case errors.Is(err, datastore.ErrNotFound):
+		prev = nil
+	case err != nil:
+		return err
+	default:
+		if _, err = s.decode(prev); err != nil {
+			// never replace the intact fallback with a corrupted checkpoint
+			prev = nil
+		}
+	}
+
+	swap := func(w datastore.Write) error {
+		if prev != nil {
+			if err := w.Put(ctx, checkpointPrevKey, prev); err != nil {
+				return err
+			}
+		}
+		if err := w.Put(ctx, checkpointKey, bs); err != nil {
+			return err
+		}
+		return w.Delete(ctx, checkpointTmpKey)
+	}
+
+	batching, ok := s.Datastore.(datastore.Batching)
+	if !ok {
+		return swap(s.Datastore)
+	}
+	batch, err := batching.Batch(ctx)
+	if errors.Is(err, datastore.ErrBatchUnsupported) {
+		return swap(s.Datastore)
+	}
+	if err != nil {
+		return err
+	}
+	if err = swap(batch); err != nil {
+		return err
+	}
+	return batch.Commit(ctx)
+}
+
+// loadSnapshot returns a copy of the last loaded or stored checkpoint without accessing the
+// datastore. It is safe to call concurrently with load and store. The returned bool is false if
+// no checkpoint was loaded or stored yet.
+func (s *checkpointStore) loadSnapshot() (checkpoint, bool) {
+	cp := s.snapshot.Load()
+	if cp == nil {
+		return checkpoint{}, false
+	}
+	return cp.clone(), true
+}
+
+func (s *checkpointStore) setSnapshot(cp checkpoint) {
+	cp = cp.clone()
+	s.snapshot.Store(&cp)
+}
+
 // runBackgroundStore periodically saves current sampling state in case of DASer force quit before
 // being able to store state on exit. The routine can be disabled by passing storeInterval = 0.
 func (s *checkpointStore) runBackgroundStore(
@@ -74,10 +224,13 @@ func (s *checkpointStore) runBackgroundStore(
 		return
 	}
 
-	ticker := time.NewTicker(storeInterval)
+	ticker := s.clock.Ticker(storeInterval)
 	defer ticker.Stop()
 
-	var prev uint64
+	var (
+		prev     uint64
+		storedAt time.Time
+	)
 	for {
 		// blocked by ticker to perform storing only once in a period
 		select {
@@ -92,10 +245,15 @@ func (s *checkpointStore) runBackgroundStore(
 			continue
 		}
 		if cp.SampleFrom > prev {
+			// the advanced checkpoint is stored with one of the next ticks once the flush interval passes
+			if s.flushInterval > 0 && !storedAt.IsZero() && s.clock.Since(storedAt) < s.flushInterval {
+				continue
+			}
 			if err = s.store(ctx, cp); err != nil {
 				log.Errorw("storing checkpoint to disk", "err", err)
 			}
 			prev = cp.SampleFrom
+			storedAt = s.clock.Now()
 		}
 	}
 }
@@ -1,27 +1,90 @@
 package das
 
 import (
+	"bytes"
 	"context"
+	"sync"
+	"sync/atomic"
 
 	libhead "github.com/celestiaorg/go-header"
 
 	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
 )
 
 // subscriber subscribes to notifications about new headers in the network to keep
 // sampling process up-to-date with current network state.
 type subscriber struct {
 	done
+
+	// bufferSize is the maximum amount of received headers waiting to be emitted
+	bufferSize int
+	// priority defines the order buffered headers are emitted in, if set
+	priority priorityFn
+	// dropped counts headers dropped from the full buffer
+	dropped atomic.Uint64
+	// duplicates counts headers delivered by the subscription more than once
+	duplicates atomic.Uint64
+
+	// delivered are data roots of the most recent heights delivered by the subscription. It is only
+	// accessed by run.
+	delivered map[uint64]share.DataHash
+	// highest is the highest height delivered by the subscription
+	highest uint64
+	// undelivered is the highest height received, but not emitted for sampling before run returned.
+	// It is set once run is done.
+	undelivered uint64
 }
 
-func newSubscriber() subscriber {
-	return subscriber{newDone("subscriber")}
+func newSubscriber(bufferSize int, priority priorityFn) *subscriber {
+	return &subscriber{
+		done:       newDone("subscriber"),
+		bufferSize: bufferSize,
+		priority:   priority,
+		delivered:  make(map[uint64]share.DataHash),
+	}
 }
 
 func (s *subscriber) run(ctx context.Context, sub libhead.Subscription[*header.ExtendedHeader], emit listenFn) {
 	defer s.indicateDone()
 	defer sub.Cancel()
 
+	// emit headers asynchronously, so slow sampling never blocks the subscription
+	buf := newHeaderBuffer(s.bufferSize, s.priority)
+	emitterDone := make(chan struct{})
+	// interrupted is the header whose emission could be interrupted by the context
+	var interrupted *header.ExtendedHeader
+	go func() {
+		defer close(emitterDone)
+		for {
+			h, ok := buf.pop(ctx)
+			if !ok {
+				return
+			}
+			if ctx.Err() != nil {
+				interrupted = h
+				return
+			}
+			emit(ctx, h)
+			if ctx.Err() != nil {
+				interrupted = h
+				return
+			}
+		}
+	}()
+	defer func() {
+		buf.close()
+		<-emitterDone
+		// headers left in the buffer on stop are remembered, so they are not lost
+		leftover := buf.drain()
+		if interrupted != nil {
+			leftover = append(leftover, interrupted)
+		}
+		for _, h := range leftover {
+			s.undelivered = max(s.undelivered, h.Height())
+		}
+	}()
+
 	for {
 		h, err := sub.NextHeader(ctx)
 		if err != nil {
@@ -33,7 +96,161 @@ func (s *subscriber) run(ctx context.Context, sub libhead.Subscription[*header.E
 			continue
 		}
 		log.Debugw("new header received via subscription", "height", h.Height())
+		if s.duplicate(h) {
+			// harmless, but indicates a bug upstream, so it is counted rather than sampled again
+			s.duplicates.Add(1)
+			log.Debugw("header delivered by subscription more than once", "height", h.Height())
+			continue
+		}
+
+		if dropped := buf.push(h); dropped != nil {
+			s.dropped.Add(1)
+			log.Debugw("recent headers buffer is full, header will be sampled by catchup",
+				"height", dropped.Height())
+		}
+	}
+}
+
+// duplicate reports whether the header with the same data root was delivered before and remembers
+// its data root otherwise. Headers with a different data root are not duplicates and are left for
+// the sampler to handle as reorgs. Heights leaving the window of equivocationWindow most recent
+// heights are forgotten in batches to amortize the cost of pruning.
+func (s *subscriber) duplicate(h *header.ExtendedHeader) bool {
+	height, root := h.Height(), share.DataHash(h.DataHash)
+	if seen, ok := s.delivered[height]; ok && bytes.Equal(seen, root) {
+		return true
+	}
+	if height+equivocationWindow <= s.highest {
+		return false
+	}
+	s.delivered[height] = root
+	s.highest = max(s.highest, height)
+
+	if len(s.delivered) > 2*equivocationWindow {
+		for height := range s.delivered {
+			if height+equivocationWindow <= s.highest {
+				delete(s.delivered, height)
+			}
+		}
+	}
+	return false
+}
+
+// priorityFn assigns a priority to the header. Headers with higher priority are sampled first.
+type priorityFn func(*header.ExtendedHeader) int
+
+// highestPriority returns the index of the oldest header with the highest priority. Without
+// priority, the oldest header is returned.
+func highestPriority(headers []*header.ExtendedHeader, priority priorityFn) int {
+	if priority == nil {
+		return 0
+	}
+	idx, best := 0, priority(headers[0])
+	for i := 1; i < len(headers); i++ {
+		if p := priority(headers[i]); p > best {
+			idx, best = i, p
+		}
+	}
+	return idx
+}
+
+// lowestPriority returns the index of the oldest header with the lowest priority. Without
+// priority, the oldest header is returned.
+func lowestPriority(headers []*header.ExtendedHeader, priority priorityFn) int {
+	if priority == nil {
+		return 0
+	}
+	idx, worst := 0, priority(headers[0])
+	for i := 1; i < len(headers); i++ {
+		if p := priority(headers[i]); p < worst {
+			idx, worst = i, p
+		}
+	}
+	return idx
+}
+
+// headerBuffer is a bounded queue of headers. Headers are popped in FIFO order, unless priority is
+// set, in which case headers with higher priority are popped first. Once full, the oldest header
+// with the lowest priority is dropped.
+type headerBuffer struct {
+	lock     sync.Mutex
+	headers  []*header.ExtendedHeader
+	size     int
+	priority priorityFn
+	closed   bool
+	// notify signals that headers were pushed or the buffer was closed
+	notify chan struct{}
+}
+
+func newHeaderBuffer(size int, priority priorityFn) *headerBuffer {
+	return &headerBuffer{
+		size:     size,
+		priority: priority,
+		notify:   make(chan struct{}, 1),
+	}
+}
+
+// push adds the header to the buffer and returns the dropped header, if the buffer was full.
+func (b *headerBuffer) push(h *header.ExtendedHeader) (dropped *header.ExtendedHeader) {
+	b.lock.Lock()
+	b.headers = append(b.headers, h)
+	if len(b.headers) > b.size {
+		idx := lowestPriority(b.headers, b.priority)
+		dropped = b.headers[idx]
+		b.headers = append(b.headers[:idx], b.headers[idx+1:]...)
+	}
+	b.lock.Unlock()
+
+	b.signal()
+	return dropped
+}
+
+// pop blocks until there is a header in the buffer and returns it. It returns false once the
+// buffer is closed and drained, or the context is done.
+func (b *headerBuffer) pop(ctx context.Context) (*header.ExtendedHeader, bool) {
+	for {
+		b.lock.Lock()
+		if len(b.headers) > 0 {
+			idx := highestPriority(b.headers, b.priority)
+			h := b.headers[idx]
+			b.headers = append(b.headers[:idx], b.headers[idx+1:]...)
+			b.lock.Unlock()
+			return h, true
+		}
+		closed := b.closed
+		b.lock.Unlock()
+
+		if closed {
+			return nil, false
+		}
+		select {
+		case <-b.notify:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// drain removes and returns all headers in the buffer.
+func (b *headerBuffer) drain() []*header.ExtendedHeader {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	headers := b.headers
+	b.headers = nil
+	return headers
+}
+
+// close makes pop return once the buffer is drained.
+func (b *headerBuffer) close() {
+	b.lock.Lock()
+	b.closed = true
+	b.lock.Unlock()
+	b.signal()
+}
 
-		emit(ctx, h)
+func (b *headerBuffer) signal() {
+	select {
+	case b.notify <- struct{}{}:
+	default:
 	}
 }
@@ -0,0 +1,103 @@
+package das
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/header/headertest"
+)
+
+func TestSubscriber_RecentBuffer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	const (
+		amount     = 50
+		bufferSize = 5
+	)
+	sub := new(headertest.Subscriber)
+	for height := 1; height <= amount; height++ {
+		h := headertest.RandExtendedHeader(t)
+		h.RawHeader.Height = int64(height)
+		sub.Headers = append(sub.Headers, h)
+	}
+
+	// stalled sampler doesn't accept headers until released
+	release := make(chan struct{})
+	var (
+		lk      sync.Mutex
+		emitted []uint64
+	)
+	emit := func(ctx context.Context, h *header.ExtendedHeader) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return
+		}
+		lk.Lock()
+		defer lk.Unlock()
+		emitted = append(emitted, h.Height())
+	}
+
+	s := newSubscriber(bufferSize, nil)
+	go s.run(ctx, sub, emit)
+
+	// at most one header is held by the stalled sampler, the rest is either buffered or dropped
+	require.Eventually(t, func() bool {
+		return s.dropped.Load() >= amount-bufferSize-1
+	}, timeout, 10*time.Millisecond)
+	close(release)
+	require.NoError(t, s.wait(ctx))
+
+	lk.Lock()
+	defer lk.Unlock()
+	assert.LessOrEqual(t, len(emitted), bufferSize+1)
+	assert.EqualValues(t, amount, len(emitted)+int(s.dropped.Load()))
+	// the newest headers are kept
+	assert.EqualValues(t, amount, emitted[len(emitted)-1])
+	for i := 1; i < len(emitted); i++ {
+		assert.Less(t, emitted[i-1], emitted[i])
+	}
+}
+
+func TestHeaderBuffer_Priority(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+
+	// heights 5 and 6 have high priority
+	priority := func(h *header.ExtendedHeader) int {
+		if h.Height() >= 5 {
+			return 1
+		}
+		return 0
+	}
+	buf := newHeaderBuffer(5, priority)
+	for height := 1; height <= 6; height++ {
+		h := headertest.RandExtendedHeader(t)
+		h.RawHeader.Height = int64(height)
+		dropped := buf.push(h)
+		if height == 6 {
+			// the oldest header with the lowest priority is dropped once full
+			require.NotNil(t, dropped)
+			assert.EqualValues(t, 1, dropped.Height())
+		}
+	}
+	buf.close()
+
+	var popped []uint64
+	for {
+		h, ok := buf.pop(ctx)
+		if !ok {
+			break
+		}
+		popped = append(popped, h.Height())
+	}
+	// headers with high priority are popped first despite being pushed later
+	assert.Equal(t, []uint64{5, 6, 2, 3, 4}, popped)
+}
@@ -0,0 +1,74 @@
+package das
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+const (
+	// throughputBucketWidth is the time span of a single bucket of the throughput window
+	throughputBucketWidth = time.Second
+	// throughputBuckets is the amount of buckets the throughput is averaged over
+	throughputBuckets = 10
+)
+
+// throughput counts completed samples in a ring of fixed-width time buckets to compute a moving
+// average of samples per second.
+type throughput struct {
+	lock    sync.Mutex
+	buckets [throughputBuckets]throughputBucket
+	clock   clock.Clock
+}
+
+type throughputBucket struct {
+	// idx is the index of the time span the bucket counts samples for
+	idx   int64
+	count uint64
+}
+
+func newThroughput() *throughput {
+	return &throughput{clock: clock.New()}
+}
+
+// observe counts the completed sample. Samples canceled before completion are not counted.
+func (t *throughput) observe(o sampleOutcome) {
+	if errors.Is(o.err, context.Canceled) {
+		return
+	}
+	t.add(t.clock.Now())
+}
+
+func (t *throughput) add(now time.Time) {
+	idx := now.UnixNano() / int64(throughputBucketWidth)
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	b := &t.buckets[idx%throughputBuckets]
+	if b.idx != idx {
+		*b = throughputBucket{idx: idx}
+	}
+	b.count++
+}
+
+// get returns the average amount of samples completed per second over the window ending at now.
+// The current bucket is accounted for only by its elapsed part.
+func (t *throughput) get(now time.Time) float64 {
+	idx := now.UnixNano() / int64(throughputBucketWidth)
+	oldest := idx - throughputBuckets
+
+	var count uint64
+	t.lock.Lock()
+	for _, b := range t.buckets {
+		if b.idx > oldest && b.idx <= idx {
+			count += b.count
+		}
+	}
+	t.lock.Unlock()
+
+	elapsed := time.Duration(now.UnixNano() % int64(throughputBucketWidth))
+	span := (throughputBuckets-1)*throughputBucketWidth + elapsed
+	return float64(count) / span.Seconds()
+}
@@ -0,0 +1,30 @@
+package das
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThroughput(t *testing.T) {
+	clk := clock.NewMock()
+	tp := newThroughput()
+	tp.clock = clk
+	assert.Zero(t, tp.get(clk.Now()))
+
+	// sample at a steady rate of 20 samples per second
+	const rate = 20
+	for i := 0; i < 30*rate; i++ {
+		clk.Add(time.Second / rate)
+		tp.observe(sampleOutcome{height: uint64(i)})
+		if i > throughputBuckets*rate {
+			assert.InDelta(t, rate, tp.get(clk.Now()), rate*0.1)
+		}
+	}
+
+	// throughput drops once sampling stops
+	clk.Add(throughputBuckets * throughputBucketWidth)
+	assert.Zero(t, tp.get(clk.Now()))
+}
@@ -7,18 +7,44 @@ import (
 	"sync"
 	"time"
 
+	"github.com/benbjohnson/clock"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	libhead "github.com/celestiaorg/go-header"
 
 	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/libs/utils"
+	"github.com/celestiaorg/celestia-node/share"
 	"github.com/celestiaorg/celestia-node/share/p2p/shrexsub"
 )
 
+// errNilDAH is returned when a header without DAH is received for sampling.
+var errNilDAH = errors.New("das: header has nil DAH")
+
+// ErrHeaderNotFound is returned when the header getter returns neither a header nor an error. The
+// height is counted as failed and retried.
+var ErrHeaderNotFound = errors.New("das: header not found")
+
+// ErrSampleTimeout is returned when sampling of a header is aborted by SampleTimeout. The height is
+// counted as failed and retried.
+var ErrSampleTimeout = errors.New("das: sample timed out")
+
+// SampleSource identifies what requested a sample: the type of the background job it was dispatched
+// by, i.e. "catchup", "recent" or "retry", or the source of a sample on demand, which is "manual"
+// unless tagged otherwise.
+type SampleSource string
+
 const (
-	catchupJob jobType = "catchup"
-	recentJob  jobType = "recent"
-	retryJob   jobType = "retry"
+	catchupJob SampleSource = "catchup"
+	recentJob  SampleSource = "recent"
+	retryJob   SampleSource = "retry"
 )
 
+// skipFn reports whether sampling of the header can be skipped, marking it sampled. The returned
+// error is the verdict of the skipped header.
+type skipFn func(context.Context, *header.ExtendedHeader) (bool, error)
+
 type worker struct {
 	lock  sync.Mutex
 	state workerState
@@ -27,6 +53,10 @@ type worker struct {
 	sampleFn  sampleFn
 	broadcast shrexsub.BroadcastFn
 	metrics   *metrics
+	observe   observeFn
+	clock     clock.Clock
+	// skip skips sampling of headers that don't need it, if set
+	skip skipFn
 }
 
 // workerState contains important information about the state of a
@@ -37,17 +67,31 @@ type workerState struct {
 	curr uint64
 }
 
-type jobType string
+type jobTypeKey struct{}
+
+// jobTypeFrom returns the type of the job the sample was dispatched by, if any.
+func jobTypeFrom(ctx context.Context) (SampleSource, bool) {
+	jt, ok := ctx.Value(jobTypeKey{}).(SampleSource)
+	return jt, ok
+}
 
 // job represents headers interval to be processed by worker
 type job struct {
 	id      int
-	jobType jobType
+	jobType SampleSource
 	from    uint64
 	to      uint64
 
 	// header is set only for recentJobs, avoiding an unnecessary call to the header store
 	header *header.ExtendedHeader
+	// sampled contains heights within the job range that have already been sampled and should be
+	// skipped, with the time they were sampled at
+	sampled map[uint64]time.Time
+	// attempt is the number of the sampling attempt for heights of the job, starting from 1
+	attempt int
+	// notBefore is the time the job is dispatched to a worker at, if set. It is used to pace recent
+	// jobs.
+	notBefore time.Time
 }
 
 func newWorker(j job,
@@ -55,12 +99,16 @@ func newWorker(j job,
 	sample sampleFn,
 	broadcast shrexsub.BroadcastFn,
 	metrics *metrics,
+	observe observeFn,
+	clk clock.Clock,
 ) worker {
 	return worker{
 		getter:    getter,
 		sampleFn:  sample,
 		broadcast: broadcast,
 		metrics:   metrics,
+		observe:   observe,
+		clock:     clk,
 		state: workerState{
 			curr: j.from,
 			result: result{
@@ -71,17 +119,41 @@ func newWorker(j job,
 	}
 }
 
-func (w *worker) run(ctx context.Context, timeout time.Duration, resultCh chan<- result) {
-	jobStart := time.Now()
+// run samples headers of the job. The timeout of every sample is read from timeout at the time the
+// sample starts, so it can be changed while the worker is running.
+func (w *worker) run(ctx context.Context, timeout func() time.Duration, resultCh chan<- result) {
+	jobStart := w.clock.Now()
 	log.Debugw("start sampling worker", "from", w.state.from, "to", w.state.to)
 
 	for curr := w.state.from; curr <= w.state.to; curr++ {
-		err := w.sample(ctx, timeout, curr)
+		if _, ok := w.state.sampled[curr]; ok {
+			w.setResult(curr, nil)
+			continue
+		}
+
+		start := w.clock.Now()
+		h, deduplicated, err := w.sample(ctx, timeout(), curr)
 		if errors.Is(err, context.Canceled) {
 			// sampling worker will resume upon restart
 			return
 		}
+		if errors.Is(err, share.ErrBackpressure) {
+			// not a sampling verdict, the height will be sampled again later
+			w.setThrottled(curr)
+			continue
+		}
 		w.setResult(curr, err)
+		// the outcome of a deduplicated sample is reported by the sample it shares the verdict of
+		if w.observe != nil && !deduplicated {
+			w.observe(sampleOutcome{
+				height:   curr,
+				header:   h,
+				source:   w.state.jobType,
+				attempt:  max(w.state.attempt, 1),
+				duration: w.clock.Since(start),
+				err:      err,
+			})
+		}
 	}
 
 	if w.state.jobType != recentJob {
@@ -91,7 +163,7 @@ func (w *worker) run(ctx context.Context, timeout time.Duration, resultCh chan<-
 			"from", w.state.from,
 			"to", w.state.curr,
 			"errors", len(w.state.failed),
-			"finished (s)", time.Since(jobStart),
+			"finished (s)", w.clock.Since(jobStart),
 		)
 	}
 
@@ -101,18 +173,40 @@ func (w *worker) run(ctx context.Context, timeout time.Duration, resultCh chan<-
 	}
 }
 
-func (w *worker) sample(ctx context.Context, timeout time.Duration, height uint64) error {
+// sample samples the header at the given height. It returns the sampled header, if it was
+// retrieved, and whether the verdict was shared by a concurrent sample of the same height.
+func (w *worker) sample(
+	ctx context.Context,
+	timeout time.Duration,
+	height uint64,
+) (*header.ExtendedHeader, bool, error) {
 	h, err := w.getHeader(ctx, height)
 	if err != nil {
-		return err
+		return nil, false, err
+	}
+	if w.skip != nil {
+		if skipped, err := w.skip(ctx, h); skipped {
+			return h, false, err
+		}
 	}
 
-	start := time.Now()
-	ctx, cancel := context.WithTimeout(ctx, timeout)
+	start := w.clock.Now()
+	sampleCtx, cancel := w.clock.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	err = w.sampleFn(ctx, h)
-	w.metrics.observeSample(ctx, h, time.Since(start), w.state.jobType, err)
+	sampleCtx, span := tracer.Start(sampleCtx, "das/sample", trace.WithAttributes(
+		attribute.Int64("height", int64(h.Height())),
+		attribute.String("job_type", string(w.state.jobType)),
+	))
+	sampleCtx = context.WithValue(sampleCtx, jobTypeKey{}, w.state.jobType)
+	deduplicated, err := unwrapShared(w.sampleFn(sampleCtx, h))
+	err = classifyAborted(ctx, sampleCtx, err)
+	span.SetAttributes(attribute.Bool("deduplicated", deduplicated))
+	utils.SetStatusAndEnd(span, err)
+	if !errors.Is(err, context.Canceled) && !deduplicated {
+		// abandoned samples are neither succeeded nor failed, so they are not accounted
+		w.metrics.observeSample(sampleCtx, h, w.clock.Since(start), w.state.jobType, err)
+	}
 	if err != nil {
 		if !errors.Is(err, context.Canceled) {
 			log.Debugw(
@@ -123,17 +217,18 @@ func (w *worker) sample(ctx context.Context, timeout time.Duration, height uint6
 				"square width", len(h.DAH.RowRoots),
 				"data root", h.DAH.String(),
 				"err", err,
-				"finished (s)", time.Since(start),
+				"deduplicated", deduplicated,
+				"finished (s)", w.clock.Since(start),
 			)
 		}
-		return err
+		return h, deduplicated, err
 	}
 
 	logout := log.Debugw
 
 	// notify network about availability of new block data (note: only full nodes can notify)
 	if w.state.job.jobType == recentJob {
-		err = w.broadcast(ctx, shrexsub.Notification{
+		err = w.broadcast(sampleCtx, shrexsub.Notification{
 			DataHash: h.DataHash.Bytes(),
 			Height:   h.Height(),
 		})
@@ -152,28 +247,60 @@ func (w *worker) sample(ctx context.Context, timeout time.Duration, height uint6
 		"hash", h.Hash(),
 		"square width", len(h.DAH.RowRoots),
 		"data root", h.DAH.String(),
-		"finished (s)", time.Since(start),
+		"deduplicated", deduplicated,
+		"finished (s)", w.clock.Since(start),
 	)
-	return nil
+	return h, deduplicated, nil
+}
+
+// classifyAborted distinguishes samples aborted by a deadline, either of the sample context or the
+// parent one, from the ones abandoned because the parent context is canceled, e.g. on Stop,
+// regardless of the error the sample failed with. Abandoned samples are reported with
+// context.Canceled, while timed out ones are reported with ErrSampleTimeout.
+func classifyAborted(parent, sampleCtx context.Context, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(parent.Err(), context.Canceled):
+		return fmt.Errorf("%w: %w", context.Canceled, err)
+	case errors.Is(sampleCtx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", ErrSampleTimeout, err)
+	default:
+		return err
+	}
 }
 
 func (w *worker) getHeader(ctx context.Context, height uint64) (*header.ExtendedHeader, error) {
 	if w.state.header != nil {
+		if w.state.header.DAH == nil {
+			return nil, fmt.Errorf("%w: height %d", errNilDAH, height)
+		}
 		return w.state.header, nil
 	}
 
 	// TODO: get headers in batches
-	start := time.Now()
+	start := w.clock.Now()
 	h, err := w.getter.GetByHeight(ctx, height)
 	if err != nil {
 		if !errors.Is(err, context.Canceled) {
 			log.Errorw("failed to get header from header store", "height", height,
-				"finished (s)", time.Since(start))
+				"finished (s)", w.clock.Since(start))
 		}
 		return nil, err
 	}
 
-	w.metrics.observeGetHeader(ctx, time.Since(start))
+	w.metrics.observeGetHeader(ctx, w.clock.Since(start))
+
+	if h == nil {
+		log.Errorw("got nil header from header store", "height", height)
+		return nil, fmt.Errorf("%w: height %d", ErrHeaderNotFound, height)
+	}
+	// guard against getters returning malformed headers, so the height is counted as failed
+	// instead of crashing the worker
+	if h.DAH == nil {
+		log.Errorw("got header with nil DAH from header store", "height", height)
+		return nil, fmt.Errorf("%w: height %d", errNilDAH, height)
+	}
 
 	log.Debugw(
 		"got header from header store",
@@ -181,7 +308,7 @@ func (w *worker) getHeader(ctx context.Context, height uint64) (*header.Extended
 		"hash", h.Hash(),
 		"square width", len(h.DAH.RowRoots),
 		"data root", h.DAH.String(),
-		"finished (s)", time.Since(start),
+		"finished (s)", w.clock.Since(start),
 	)
 	return h, nil
 }
@@ -196,6 +323,13 @@ func (w *worker) setResult(curr uint64, err error) {
 	w.state.curr = curr
 }
 
+func (w *worker) setThrottled(curr uint64) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.state.throttled = append(w.state.throttled, curr)
+	w.state.curr = curr
+}
+
 func (w *worker) getState() workerState {
 	w.lock.Lock()
 	defer w.lock.Unlock()
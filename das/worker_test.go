@@ -0,0 +1,195 @@
+package das
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/celestiaorg/celestia-node/header"
+)
+
+func TestWorker_NilDAH(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	brokenHeight := uint64(2)
+	getter := nilDAHGetterStub{brokenHeight: brokenHeight}
+	sampled := make(map[uint64]bool)
+	sampleFn := func(_ context.Context, h *header.ExtendedHeader) error {
+		sampled[h.Height()] = true
+		return nil
+	}
+
+	w := newWorker(job{id: 1, jobType: catchupJob, from: 1, to: 3},
+		getter, sampleFn, newBroadcastMock(1), nil, nil, clock.New())
+
+	resultCh := make(chan result, 1)
+	require.NotPanics(t, func() {
+		w.run(ctx, func() time.Duration { return time.Second }, resultCh)
+	})
+
+	res := <-resultCh
+	assert.Equal(t, map[uint64]int{brokenHeight: 1}, res.failed)
+	assert.ErrorIs(t, res.err, errNilDAH)
+	assert.True(t, sampled[1])
+	assert.False(t, sampled[brokenHeight])
+	assert.True(t, sampled[3])
+}
+
+// nilDAHGetterStub returns a header without DAH for brokenHeight.
+type nilDAHGetterStub struct {
+	getterStub
+	brokenHeight uint64
+}
+
+func (m nilDAHGetterStub) GetByHeight(ctx context.Context, height uint64) (*header.ExtendedHeader, error) {
+	h, err := m.getterStub.GetByHeight(ctx, height)
+	if height == m.brokenHeight {
+		h.DAH = nil
+	}
+	return h, err
+}
+
+func TestWorker_NilHeader(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	// mockGetter returns nil header without error for heights absent from its map
+	getter := &mockGetter{
+		headers: make(map[int64]*header.ExtendedHeader),
+		doneCh:  make(chan struct{}),
+	}
+	for _, height := range []uint64{1, 3} {
+		h, err := getterStub{}.GetByHeight(ctx, height)
+		require.NoError(t, err)
+		getter.headers[int64(height)] = h
+	}
+
+	sampled := make(map[uint64]bool)
+	sampleFn := func(_ context.Context, h *header.ExtendedHeader) error {
+		sampled[h.Height()] = true
+		return nil
+	}
+
+	w := newWorker(job{id: 1, jobType: catchupJob, from: 1, to: 3},
+		getter, sampleFn, newBroadcastMock(1), nil, nil, clock.New())
+
+	resultCh := make(chan result, 1)
+	require.NotPanics(t, func() {
+		w.run(ctx, func() time.Duration { return time.Second }, resultCh)
+	})
+
+	res := <-resultCh
+	// the height is counted as failed, so it is retried
+	assert.Equal(t, map[uint64]int{2: 1}, res.failed)
+	assert.ErrorIs(t, res.err, ErrHeaderNotFound)
+	assert.True(t, sampled[1])
+	assert.True(t, sampled[3])
+}
+
+func TestWorker_Exemplars(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	exporter := tracetest.NewInMemoryExporter()
+	prevProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSyncer(exporter),
+	))
+	t.Cleanup(func() { otel.SetTracerProvider(prevProvider) })
+
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader())).Meter("test")
+	sampled, err := meter.Int64Counter("das_sampled_headers_counter")
+	require.NoError(t, err)
+	sampleTime, err := meter.Float64Histogram("das_sample_time_hist")
+	require.NoError(t, err)
+	getHeaderTime, err := meter.Float64Histogram("das_get_header_time_hist")
+	require.NoError(t, err)
+	m := &metrics{
+		sampled:       sampled,
+		sampleTime:    sampleTime,
+		getHeaderTime: getHeaderTime,
+		exemplars:     newExemplars(),
+		clock:         clock.New(),
+	}
+	reg := prometheus.NewRegistry()
+	hist := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "sample_time", Buckets: sampleTimeBuckets})
+	require.NoError(t, reg.Register(hist))
+	m.exemplars.setHistogram(hist)
+
+	sampleFn := func(context.Context, *header.ExtendedHeader) error { return nil }
+	w := newWorker(job{id: 1, jobType: catchupJob, from: 1, to: 1},
+		getterStub{}, sampleFn, newBroadcastMock(1), m, nil, clock.New())
+	resultCh := make(chan result, 1)
+	w.run(ctx, func() time.Duration { return time.Second }, resultCh)
+	require.NoError(t, (<-resultCh).err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes, attribute.Int64("height", 1))
+
+	exemplars := m.exemplars.get()
+	require.Len(t, exemplars, 1)
+	assert.EqualValues(t, 1, exemplars[0].Height)
+	assert.Equal(t, spans[0].SpanContext.TraceID(), exemplars[0].TraceID)
+	assert.Equal(t, spans[0].SpanContext.SpanID(), exemplars[0].SpanID)
+
+	// the sample time is exposed with the trace ID as an OpenMetrics exemplar
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	labels := make(map[string]string)
+	for _, bucket := range families[0].GetMetric()[0].GetHistogram().GetBucket() {
+		for _, label := range bucket.GetExemplar().GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+	}
+	assert.Equal(t, spans[0].SpanContext.TraceID().String(), labels[traceIDLabel])
+	assert.Equal(t, spans[0].SpanContext.SpanID().String(), labels[spanIDLabel])
+}
+
+func Test_classifyAborted(t *testing.T) {
+	errSample := errors.New("sample failed")
+
+	t.Run("parent canceled", func(t *testing.T) {
+		parent, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := classifyAborted(parent, parent, errSample)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NotErrorIs(t, err, ErrSampleTimeout)
+	})
+
+	t.Run("parent deadline exceeded", func(t *testing.T) {
+		parent, cancel := context.WithDeadline(context.Background(), time.Now())
+		t.Cleanup(cancel)
+		<-parent.Done()
+		err := classifyAborted(parent, parent, errSample)
+		assert.ErrorIs(t, err, ErrSampleTimeout)
+		assert.NotErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("sample timed out", func(t *testing.T) {
+		sampleCtx, cancel := context.WithDeadline(context.Background(), time.Now())
+		t.Cleanup(cancel)
+		<-sampleCtx.Done()
+		err := classifyAborted(context.Background(), sampleCtx, errSample)
+		assert.ErrorIs(t, err, ErrSampleTimeout)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		err := classifyAborted(context.Background(), context.Background(), errSample)
+		assert.Equal(t, errSample, err)
+	})
+}
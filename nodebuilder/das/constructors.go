@@ -16,6 +16,7 @@ import (
 	"github.com/celestiaorg/celestia-node/pruner"
 	"github.com/celestiaorg/celestia-node/share"
 	"github.com/celestiaorg/celestia-node/share/eds/byzantine"
+	"github.com/celestiaorg/celestia-node/share/getters"
 	"github.com/celestiaorg/celestia-node/share/p2p/shrexsub"
 )
 
@@ -51,7 +52,9 @@ func newDASer(
 ) (*das.DASer, *modfraud.ServiceBreaker[*das.DASer, *header.ExtendedHeader], error) {
 	options = append(options, das.WithSamplingWindow(time.Duration(availWindow)))
 
-	ds, err := das.NewDASer(da, hsub, store, batching, fraudServ, bFn, options...)
+	// recent, catchup and on demand samples could request the same height concurrently
+	getter := getters.NewSingleFlightHeaderGetter(store)
+	ds, err := das.NewDASer(da, hsub, getter, batching, fraudServ, bFn, options...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1,11 +1,28 @@
 package das
 
 import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+
 	"github.com/celestiaorg/celestia-node/das"
 )
 
+type metricsParams struct {
+	fx.In
+
+	DASer *das.DASer
+	// Registry is provided if Prometheus metrics are enabled, exposing sample times with exemplars
+	Registry prometheus.Registerer `optional:"true"`
+}
+
 // WithMetrics is a utility function that is expected to be
 // "invoked" by the fx lifecycle.
-func WithMetrics(d *das.DASer) error {
-	return d.InitMetrics()
+func WithMetrics(params metricsParams) error {
+	if err := params.DASer.InitMetrics(); err != nil {
+		return err
+	}
+	if params.Registry == nil {
+		return nil
+	}
+	return params.DASer.RegisterExemplars(params.Registry)
 }
@@ -30,7 +30,11 @@ func prometheusMetrics(lifecycle fx.Lifecycle, registerer prometheus.Registerer)
 	registry := registerer.(*prometheus.Registry)
 
 	mux := http.NewServeMux()
-	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registerer})
+	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		Registry: registerer,
+		// exposes exemplars of histograms, linking them to traces
+		EnableOpenMetrics: true,
+	})
 	mux.Handle(promAgentEndpoint, handler)
 
 	// TODO(@Wondertan): Unify all the servers into one (See #2007)
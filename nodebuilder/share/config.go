@@ -27,6 +27,10 @@ type Config struct {
 
 	LightAvailability light.Parameters `toml:",omitempty"`
 	Discovery         *discovery.Parameters
+
+	// ConfirmRows makes full availability of bridge and full nodes confirm which rows of a square
+	// not retrieved as a whole can still be retrieved. See full.Parameters for details.
+	ConfirmRows bool
 }
 
 func DefaultConfig(tp node.Type) Config {
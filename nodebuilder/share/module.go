@@ -81,6 +81,11 @@ func ConstructModule(tp node.Type, cfg *Config, options ...fx.Option) fx.Option
 
 	bridgeAndFullComponents := fx.Options(
 		fx.Provide(getters.NewStoreGetter),
+		fx.Provide(func() []full.Option {
+			return []full.Option{
+				full.WithConfirmRows(cfg.ConfirmRows),
+			}
+		}),
 		fx.Invoke(func(edsSrv *shrexeds.Server, ndSrc *shrexnd.Server) {}),
 		fx.Provide(fx.Annotate(
 			func(host host.Host, store *eds.Store, network modp2p.Network) (*shrexeds.Server, error) {
@@ -2,9 +2,15 @@ package share
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"hash"
+	"math/bits"
 
 	"github.com/celestiaorg/celestia-app/pkg/da"
+	"github.com/celestiaorg/celestia-app/pkg/wrapper"
+	"github.com/celestiaorg/nmt"
 	"github.com/celestiaorg/rsmt2d"
 
 	"github.com/celestiaorg/celestia-node/header"
@@ -13,6 +19,11 @@ import (
 // ErrNotAvailable is returned whenever DA sampling fails.
 var ErrNotAvailable = errors.New("share: data not available")
 
+// ErrBackpressure is returned (possibly wrapped) by Availability when it is overloaded, signaling
+// the caller to slow down. Data is neither considered available nor unavailable and should be
+// validated again later.
+var ErrBackpressure = errors.New("share: availability is overloaded")
+
 // Root represents root commitment to multiple Shares.
 // In practice, it is a commitment to all the Data in a square.
 type Root = da.DataAvailabilityHeader
@@ -27,6 +38,50 @@ func NewRoot(eds *rsmt2d.ExtendedDataSquare) (*Root, error) {
 	return &dah, nil
 }
 
+// NewRootFromShares erasure extends the original square of the given width formed by the shares
+// and generates its Root. Shares are expected in row-major order.
+func NewRootFromShares(shares [][]byte, squareSize int) (*Root, error) {
+	return NewRootFromSharesWithHasher(shares, squareSize, DefaultNMTHasher)
+}
+
+// NewRootFromSharesWithHasher generates the Root like NewRootFromShares, hashing nmt nodes with
+// hashers created by the given function.
+func NewRootFromSharesWithHasher(shares [][]byte, squareSize int, newHasher func() hash.Hash) (*Root, error) {
+	if squareSize <= 0 || squareSize&(squareSize-1) != 0 {
+		return nil, fmt.Errorf("share: square size %d is not a power of two", squareSize)
+	}
+	if len(shares) != squareSize*squareSize {
+		return nil, fmt.Errorf("share: got %d shares for square of size %d, expected %d",
+			len(shares), squareSize, squareSize*squareSize)
+	}
+	for i, sh := range shares {
+		if len(sh) != Size {
+			return nil, fmt.Errorf("share: share %d has size %d, expected %d", i, len(sh), Size)
+		}
+	}
+
+	eds, err := rsmt2d.ComputeExtendedDataSquare(
+		shares,
+		DefaultRSMT2DCodec(),
+		NewNMTConstructor(uint64(squareSize), newHasher),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("share: extending square: %w", err)
+	}
+	return NewRoot(eds)
+}
+
+// NewNMTConstructor returns the rsmt2d.TreeConstructorFn for the original square of the given width,
+// hashing nmt nodes with hashers created by the given function. Every tree gets its own hasher, as
+// roots of the square are computed concurrently.
+func NewNMTConstructor(squareSize uint64, newHasher func() hash.Hash) rsmt2d.TreeConstructorFn {
+	return func(_ rsmt2d.Axis, axisIndex uint) rsmt2d.Tree {
+		hasher := nmt.NewNmtHasher(newHasher(), NamespaceSize, true)
+		tree := wrapper.NewErasuredNamespacedMerkleTree(squareSize, axisIndex, nmt.CustomHasher(hasher))
+		return &tree
+	}
+}
+
 // Availability defines interface for validation of Shares' availability.
 //
 //go:generate mockgen -destination=availability/mocks/availability.go -package=mocks . Availability
@@ -35,3 +90,117 @@ type Availability interface {
 	// the Network.
 	SharesAvailable(context.Context, *header.ExtendedHeader) error
 }
+
+// Reverifier is an optional interface of Availability implementations able to validate availability
+// of Shares again, regardless of previous successful validation.
+type Reverifier interface {
+	// ReverifyAvailable validates availability of Shares committed to the given Root again, reusing
+	// data retrieved previously where it can be verified against the Root.
+	ReverifyAvailable(context.Context, *header.ExtendedHeader) error
+}
+
+// NamespaceAbsenceVerifier is an optional interface of Availability implementations able to prove
+// absence of a namespace from the square.
+type NamespaceAbsenceVerifier interface {
+	// NamespaceAbsent reports whether the namespace is provably absent from the square committed to
+	// the header, together with NamespacedShares proving the verdict against the header roots. An
+	// error is returned if the verdict can't be determined.
+	NamespaceAbsent(context.Context, *header.ExtendedHeader, Namespace) (bool, NamespacedShares, error)
+}
+
+// Warmer is an optional interface of Availability and Getter implementations able to prepare for
+// retrieval before it starts, e.g. by connecting to known providers, so the first retrievals don't
+// pay for it. Warming up must not hold resources beyond the call.
+type Warmer interface {
+	// Warmup prepares for retrieval. It is safe to call multiple times.
+	Warmup(context.Context) error
+}
+
+// PartialAvailabilityError is returned by Availability implementations that can tell which rows of
+// the square were confirmed available, while the data as a whole is not. It matches
+// ErrNotAvailable with errors.Is.
+type PartialAvailabilityError struct {
+	// ConfirmedRows marks rows of the extended square confirmed available.
+	ConfirmedRows RowBitmap
+}
+
+func (e *PartialAvailabilityError) Error() string {
+	return fmt.Sprintf("%s: %d of %d rows confirmed", ErrNotAvailable, e.ConfirmedRows.Count(), e.ConfirmedRows.Len())
+}
+
+func (e *PartialAvailabilityError) Unwrap() error {
+	return ErrNotAvailable
+}
+
+// RowBitmap is a bitmap over rows of the extended square.
+type RowBitmap struct {
+	bits  []byte
+	width int
+}
+
+// NewRowBitmap creates an empty RowBitmap for the square of the given width.
+func NewRowBitmap(width int) RowBitmap {
+	return RowBitmap{
+		bits:  make([]byte, (width+7)/8),
+		width: width,
+	}
+}
+
+// Set marks the row.
+func (b RowBitmap) Set(row int) {
+	b.bits[row/8] |= 1 << (row % 8)
+}
+
+// IsSet reports whether the row is marked.
+func (b RowBitmap) IsSet(row int) bool {
+	if row < 0 || row >= b.width {
+		return false
+	}
+	return b.bits[row/8]&(1<<(row%8)) != 0
+}
+
+// Len returns the amount of rows the bitmap covers.
+func (b RowBitmap) Len() int {
+	return b.width
+}
+
+// Count returns the amount of marked rows.
+func (b RowBitmap) Count() int {
+	var count int
+	for _, v := range b.bits {
+		count += bits.OnesCount8(v)
+	}
+	return count
+}
+
+// Rows returns indexes of marked rows in ascending order.
+func (b RowBitmap) Rows() []int {
+	rows := make([]int, 0, b.Count())
+	for row := 0; row < b.width; row++ {
+		if b.IsSet(row) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+type rowBitmapJSON struct {
+	Bits  []byte `json:"bits"`
+	Width int    `json:"width"`
+}
+
+func (b RowBitmap) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rowBitmapJSON{Bits: b.bits, Width: b.width})
+}
+
+func (b *RowBitmap) UnmarshalJSON(data []byte) error {
+	var v rowBitmapJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if len(v.Bits) != (v.Width+7)/8 {
+		return fmt.Errorf("share: row bitmap of width %d has %d bytes", v.Width, len(v.Bits))
+	}
+	b.bits, b.width = v.Bits, v.Width
+	return nil
+}
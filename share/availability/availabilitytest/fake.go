@@ -0,0 +1,91 @@
+// Package availabilitytest provides a fake share.Availability for testing code depending on it.
+package availabilitytest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+var _ share.Availability = (*Fake)(nil)
+
+// Fake is a share.Availability returning verdicts programmed per data root. Data of roots without
+// a programmed verdict is available. Fake is safe for concurrent use.
+type Fake struct {
+	lock     sync.Mutex
+	verdicts map[string]verdict
+	calls    map[string]int
+}
+
+type verdict struct {
+	err   error
+	delay time.Duration
+}
+
+// NewFake creates a new Fake availability.
+func NewFake() *Fake {
+	return &Fake{
+		verdicts: make(map[string]verdict),
+		calls:    make(map[string]int),
+	}
+}
+
+// SetAvailable programs data committed to the root to be available.
+func (f *Fake) SetAvailable(root share.DataHash) {
+	f.SetError(root, nil)
+}
+
+// SetUnavailable programs data committed to the root to be unavailable, so SharesAvailable returns
+// share.ErrNotAvailable.
+func (f *Fake) SetUnavailable(root share.DataHash) {
+	f.SetError(root, share.ErrNotAvailable)
+}
+
+// SetError programs SharesAvailable to return the error for the root.
+func (f *Fake) SetError(root share.DataHash, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	v := f.verdicts[string(root)]
+	v.err = err
+	f.verdicts[string(root)] = v
+}
+
+// SetDelay programs SharesAvailable to wait for the given duration before returning the verdict
+// for the root. If the context is done earlier, its error is returned.
+func (f *Fake) SetDelay(root share.DataHash, delay time.Duration) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	v := f.verdicts[string(root)]
+	v.delay = delay
+	f.verdicts[string(root)] = v
+}
+
+// Calls returns the amount of SharesAvailable calls for the root.
+func (f *Fake) Calls(root share.DataHash) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.calls[string(root)]
+}
+
+// SharesAvailable returns the verdict programmed for the data root of the header.
+func (f *Fake) SharesAvailable(ctx context.Context, h *header.ExtendedHeader) error {
+	key := string(h.DataHash)
+	f.lock.Lock()
+	f.calls[key]++
+	v := f.verdicts[key]
+	f.lock.Unlock()
+
+	if v.delay > 0 {
+		timer := time.NewTimer(v.delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return v.err
+}
@@ -1,23 +1,47 @@
 package full
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"hash"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/filecoin-project/dagstore"
 	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+
+	"github.com/celestiaorg/rsmt2d"
 
 	"github.com/celestiaorg/celestia-node/header"
 	"github.com/celestiaorg/celestia-node/share"
 	"github.com/celestiaorg/celestia-node/share/eds"
 	"github.com/celestiaorg/celestia-node/share/eds/byzantine"
+	"github.com/celestiaorg/celestia-node/share/getters"
 	"github.com/celestiaorg/celestia-node/share/ipld"
 	"github.com/celestiaorg/celestia-node/share/p2p/discovery"
 )
 
 var log = logging.Logger("share/full")
 
+// ErrRootMismatch is returned when a root of the retrieved square does not match the
+// DataAvailabilityHeader.
+var ErrRootMismatch = errors.New("full availability: root of retrieved square mismatch")
+
+const (
+	// defaultRowCheckTimeout limits the time spent on confirming availability of individual rows
+	// after the square as a whole was not retrieved.
+	defaultRowCheckTimeout = 10 * time.Second
+	// rowCheckConcurrency limits the amount of shares requested in parallel while confirming rows.
+	rowCheckConcurrency = 64
+)
+
 // ShareAvailability implements share.Availability using the full data square
 // recovery technique. It is considered "full" because it is required
 // to download enough shares to fully reconstruct the data square.
@@ -25,6 +49,17 @@ type ShareAvailability struct {
 	store  *eds.Store
 	getter share.Getter
 	disc   *discovery.Discovery
+	params Parameters
+
+	// budget limits memory of simultaneous reconstructions, if MemoryBudget is set
+	budget *semaphore.Weighted
+
+	// randLk guards rand, which selects roots to verify
+	randLk sync.Mutex
+	rand   *rand.Rand
+
+	// rowCheckTimeout limits confirmation of individual rows of unavailable squares
+	rowCheckTimeout time.Duration
 
 	cancel context.CancelFunc
 }
@@ -34,12 +69,34 @@ func NewShareAvailability(
 	store *eds.Store,
 	getter share.Getter,
 	disc *discovery.Discovery,
-) *ShareAvailability {
-	return &ShareAvailability{
-		store:  store,
-		getter: getter,
-		disc:   disc,
+	opts ...Option,
+) (*ShareAvailability, error) {
+	params := DefaultParameters()
+	for _, opt := range opts {
+		opt(&params)
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	if params.RootVerifyFraction < 1 {
+		log.Warnw("only a fraction of roots of retrieved squares is verified, "+
+			"which allows storing squares not matching the header",
+			"fraction", params.RootVerifyFraction)
 	}
+
+	fa := &ShareAvailability{
+		store:           store,
+		getter:          getter,
+		disc:            disc,
+		params:          params,
+		rand:            rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec
+		rowCheckTimeout: defaultRowCheckTimeout,
+	}
+	if params.MemoryBudget > 0 {
+		fa.budget = semaphore.NewWeighted(int64(min(params.MemoryBudget, math.MaxInt64)))
+	}
+	return fa, nil
 }
 
 func (fa *ShareAvailability) Start(context.Context) error {
@@ -55,6 +112,14 @@ func (fa *ShareAvailability) Stop(context.Context) error {
 	return nil
 }
 
+// Warmup warms up the getter, if it implements share.Warmer.
+func (fa *ShareAvailability) Warmup(ctx context.Context) error {
+	if warmer, ok := fa.getter.(share.Warmer); ok {
+		return warmer.Warmup(ctx)
+	}
+	return nil
+}
+
 // SharesAvailable reconstructs the data committed to the given Root by requesting
 // enough Shares from the network.
 func (fa *ShareAvailability) SharesAvailable(ctx context.Context, header *header.ExtendedHeader) error {
@@ -77,20 +142,39 @@ func (fa *ShareAvailability) SharesAvailable(ctx context.Context, header *header
 		return nil
 	}
 
+	release, err := fa.reserveMemory(ctx, len(dah.RowRoots))
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	adder := ipld.NewProofsAdder(len(dah.RowRoots))
 	ctx = ipld.CtxWithProofsAdder(ctx, adder)
 	defer adder.Purge()
 
-	eds, err := fa.getter.GetEDS(ctx, header)
-	if err != nil {
-		if errors.Is(err, context.Canceled) {
+	eds, persisted := fa.getPersisted(ctx, header)
+	if !persisted {
+		eds, err = getters.GetterFrom(ctx, fa.getter).GetEDS(ctx, header)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			log.Errorw("availability validation failed", "root", dah.String(), "err", err.Error())
+			var byzantineErr *byzantine.ErrByzantine
+			if errors.Is(err, share.ErrNotFound) || errors.Is(err, context.DeadlineExceeded) &&
+				!errors.As(err, &byzantineErr) {
+				// rows are confirmed with a budget of their own, unless the request is abandoned
+				if !fa.params.ConfirmRows || errors.Is(ctx.Err(), context.Canceled) {
+					return share.ErrNotAvailable
+				}
+				return fa.confirmRows(ctx, header)
+			}
 			return err
 		}
+	}
+
+	if _, err = fa.verifyRoots(header, eds); err != nil {
 		log.Errorw("availability validation failed", "root", dah.String(), "err", err.Error())
-		var byzantineErr *byzantine.ErrByzantine
-		if errors.Is(err, share.ErrNotFound) || errors.Is(err, context.DeadlineExceeded) && !errors.As(err, &byzantineErr) {
-			return share.ErrNotAvailable
-		}
 		return err
 	}
 
@@ -98,5 +182,189 @@ func (fa *ShareAvailability) SharesAvailable(ctx context.Context, header *header
 	if err != nil && !errors.Is(err, dagstore.ErrShardExists) {
 		return fmt.Errorf("full availability: failed to store eds: %w", err)
 	}
+	if !persisted {
+		fa.persist(ctx, header, eds)
+	}
 	return nil
 }
+
+// getPersisted reads the square of the header from the PersistStore, if configured. It reports
+// whether the square was read.
+func (fa *ShareAvailability) getPersisted(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+) (*rsmt2d.ExtendedDataSquare, bool) {
+	if fa.params.PersistStore == nil {
+		return nil, false
+	}
+	eds, err := fa.params.PersistStore.Get(ctx, header.DAH.Hash())
+	if err != nil {
+		log.Debugw("square is not persisted", "root", header.DAH.String(), "err", err)
+		return nil, false
+	}
+	return eds, true
+}
+
+// persist writes the reconstructed square through to the PersistStore, if configured. Failures
+// to persist the square don't fail availability, as it is already stored in the eds.Store.
+func (fa *ShareAvailability) persist(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+	eds *rsmt2d.ExtendedDataSquare,
+) {
+	if fa.params.PersistStore == nil {
+		return
+	}
+	err := fa.params.PersistStore.Put(ctx, header.DAH.Hash(), eds)
+	if err != nil && !errors.Is(err, dagstore.ErrShardExists) {
+		log.Warnw("failed to persist reconstructed square", "root", header.DAH.String(), "err", err)
+	}
+}
+
+// reserveMemory waits until the estimated size of the extended square of the given width fits into
+// the MemoryBudget and reserves it. Squares larger than the whole budget reserve the whole budget,
+// so they are reconstructed alone. The returned function releases the reserved memory.
+func (fa *ShareAvailability) reserveMemory(ctx context.Context, width int) (func(), error) {
+	if fa.budget == nil {
+		return func() {}, nil
+	}
+
+	size := int64(min(uint64(width*width*share.Size), fa.params.MemoryBudget, math.MaxInt64))
+	if err := fa.budget.Acquire(ctx, size); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: memory budget for reconstruction exhausted: %w", share.ErrBackpressure, err)
+	}
+	return func() { fa.budget.Release(size) }, nil
+}
+
+// verifyRoots recomputes a random RootVerifyFraction of row and column roots of the square and
+// compares them against the header. Only the selected roots are computed. It returns indexes of the
+// verified roots, where row roots go first, followed by column roots.
+func (fa *ShareAvailability) verifyRoots(
+	header *header.ExtendedHeader,
+	eds *rsmt2d.ExtendedDataSquare,
+) ([]int, error) {
+	width := int(eds.Width())
+	rowRoots, colRoots := header.DAH.RowRoots, header.DAH.ColumnRoots
+	if len(rowRoots) != width || len(colRoots) != width {
+		return nil, fmt.Errorf("%w: got %d roots, expected %d", ErrRootMismatch,
+			2*width, len(rowRoots)+len(colRoots))
+	}
+
+	newTree := share.NewNMTConstructor(uint64(width/2), fa.nmtHasher())
+	verified := fa.selectRoots(2 * width)
+	for _, idx := range verified {
+		axis, axisIdx, expected := rsmt2d.Row, idx, rowRoots
+		if idx >= width {
+			axis, axisIdx, expected = rsmt2d.Col, idx-width, colRoots
+		}
+		computed, err := computeRoot(eds, newTree, axis, axisIdx)
+		if err != nil {
+			return verified, fmt.Errorf("full availability: computing %s root %d: %w", axisName(axis), axisIdx, err)
+		}
+		if !bytes.Equal(computed, expected[axisIdx]) {
+			return verified, fmt.Errorf("%w: %s %d", ErrRootMismatch, axisName(axis), axisIdx)
+		}
+	}
+	return verified, nil
+}
+
+// nmtHasher returns the function creating hashers of nmt nodes to recompute roots with.
+func (fa *ShareAvailability) nmtHasher() func() hash.Hash {
+	if fa.params.NMTHasher == nil {
+		return share.DefaultNMTHasher
+	}
+	return fa.params.NMTHasher
+}
+
+// computeRoot computes the root of the row or column of the square at the given index.
+func computeRoot(
+	eds *rsmt2d.ExtendedDataSquare,
+	newTree rsmt2d.TreeConstructorFn,
+	axis rsmt2d.Axis,
+	idx int,
+) ([]byte, error) {
+	shares := eds.Row(uint(idx))
+	if axis == rsmt2d.Col {
+		shares = eds.Col(uint(idx))
+	}
+	tree := newTree(axis, uint(idx))
+	for _, shr := range shares {
+		if err := tree.Push(shr); err != nil {
+			return nil, err
+		}
+	}
+	return tree.Root()
+}
+
+func axisName(axis rsmt2d.Axis) string {
+	if axis == rsmt2d.Row {
+		return "row"
+	}
+	return "column"
+}
+
+// selectRoots returns random indexes of RootVerifyFraction of the given amount of roots. At least
+// one root is always selected.
+func (fa *ShareAvailability) selectRoots(total int) []int {
+	count := int(math.Ceil(fa.params.RootVerifyFraction * float64(total)))
+	count = max(min(count, total), 1)
+
+	fa.randLk.Lock()
+	defer fa.randLk.Unlock()
+	return fa.rand.Perm(total)[:count]
+}
+
+// confirmRows checks which rows of the square not available as a whole can still be retrieved. A
+// row is confirmed if all its shares in the left half of the extended square are retrieved, which
+// is enough to reconstruct the row. The check is bound by rowCheckTimeout rather than the deadline
+// of the given context, as it usually follows the retrieval of the square that used up the time.
+// It is aborted once the given context is canceled. It returns PartialAvailabilityError with the
+// confirmed rows, or share.ErrNotAvailable if the check is not completed, as the rows left unchecked
+// are unknown.
+func (fa *ShareAvailability) confirmRows(ctx context.Context, header *header.ExtendedHeader) error {
+	budgetCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fa.rowCheckTimeout)
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			cancel()
+		}
+	})
+	defer stop()
+
+	getter := getters.GetterFrom(ctx, fa.getter)
+	width := len(header.DAH.RowRoots)
+	missing := make([]atomic.Bool, width)
+	errGroup, checkCtx := errgroup.WithContext(budgetCtx)
+	errGroup.SetLimit(rowCheckConcurrency)
+	for row := 0; row < width; row++ {
+		for col := 0; col < width/2; col++ {
+			row, col := row, col
+			errGroup.Go(func() error {
+				if missing[row].Load() {
+					return nil
+				}
+				if _, err := getter.GetShare(checkCtx, header, row, col); err != nil {
+					missing[row].Store(true)
+				}
+				return nil
+			})
+		}
+	}
+	_ = errGroup.Wait()
+	if budgetCtx.Err() != nil {
+		return share.ErrNotAvailable
+	}
+
+	confirmed := share.NewRowBitmap(width)
+	for row := range missing {
+		if !missing[row].Load() {
+			confirmed.Set(row)
+		}
+	}
+	log.Debugw("confirmed rows of unavailable square", "root", header.DAH.String(),
+		"confirmed", confirmed.Count(), "rows", width)
+	return &share.PartialAvailabilityError{ConfirmedRows: confirmed}
+}
@@ -2,19 +2,28 @@ package full
 
 import (
 	"context"
+	"crypto/sha512"
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/celestiaorg/celestia-app/pkg/da"
+	"github.com/celestiaorg/rsmt2d"
 
+	"github.com/celestiaorg/celestia-node/header"
 	"github.com/celestiaorg/celestia-node/header/headertest"
 	"github.com/celestiaorg/celestia-node/share"
 	availability_test "github.com/celestiaorg/celestia-node/share/availability/test"
 	"github.com/celestiaorg/celestia-node/share/eds/edstest"
 	"github.com/celestiaorg/celestia-node/share/mocks"
+	"github.com/celestiaorg/celestia-node/share/sharetest"
 )
 
 func TestShareAvailableOverMocknet_Full(t *testing.T) {
@@ -72,6 +81,9 @@ func TestSharesAvailable_Full_ErrNotAvailable(t *testing.T) {
 	eh := headertest.RandExtendedHeaderWithRoot(t, &dah)
 	require.NoError(t, err)
 	avail := TestAvailability(t, getter)
+	// rows of the unavailable square are checked individually
+	getter.EXPECT().GetShare(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, share.ErrNotFound).AnyTimes()
 
 	errors := []error{share.ErrNotFound, context.DeadlineExceeded}
 	for _, getterErr := range errors {
@@ -80,3 +92,277 @@ func TestSharesAvailable_Full_ErrNotAvailable(t *testing.T) {
 		require.ErrorIs(t, err, share.ErrNotAvailable)
 	}
 }
+
+func TestSharesAvailable_Full_RootVerifyFraction(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eds := edstest.RandEDS(t, 8)
+	dah, err := da.NewDataAvailabilityHeader(eds)
+	require.NoError(t, err)
+	eh := headertest.RandExtendedHeaderWithRoot(t, &dah)
+	total := len(dah.RowRoots) + len(dah.ColumnRoots)
+
+	ctrl := gomock.NewController(t)
+	getter := mocks.NewMockGetter(ctrl)
+	avail := TestAvailability(t, getter)
+
+	verified, err := avail.verifyRoots(eh, eds)
+	require.NoError(t, err)
+	assert.Len(t, verified, total)
+
+	const seed = 42
+	avail.params.RootVerifyFraction = 0.25
+	avail.rand = rand.New(rand.NewSource(seed))
+	verified, err = avail.verifyRoots(eh, eds)
+	require.NoError(t, err)
+	assert.Len(t, verified, total/4)
+
+	// corrupt one of the verified roots and repeat the same selection
+	corrupted := da.DataAvailabilityHeader{
+		RowRoots:    cloneRoots(dah.RowRoots),
+		ColumnRoots: cloneRoots(dah.ColumnRoots),
+	}
+	if idx := verified[0]; idx < len(dah.RowRoots) {
+		corrupted.RowRoots[idx][len(corrupted.RowRoots[idx])-1] ^= 0xff
+	} else {
+		idx -= len(dah.RowRoots)
+		corrupted.ColumnRoots[idx][len(corrupted.ColumnRoots[idx])-1] ^= 0xff
+	}
+	corruptedEh := headertest.RandExtendedHeaderWithRoot(t, &corrupted)
+
+	avail.rand = rand.New(rand.NewSource(seed))
+	_, err = avail.verifyRoots(corruptedEh, eds)
+	require.ErrorIs(t, err, ErrRootMismatch)
+
+	// squares with a mismatching root are not stored
+	avail.params.RootVerifyFraction = 1
+	getter.EXPECT().GetEDS(gomock.Any(), gomock.Any()).Return(eds, nil)
+	err = avail.SharesAvailable(ctx, corruptedEh)
+	require.ErrorIs(t, err, ErrRootMismatch)
+	has, err := avail.store.Has(ctx, corrupted.Hash())
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestSharesAvailable_Full_NMTHasher(t *testing.T) {
+	const size = 4
+	newHasher := sha512.New512_256
+	eds, err := rsmt2d.ComputeExtendedDataSquare(sharetest.RandShares(t, size*size),
+		share.DefaultRSMT2DCodec(), share.NewNMTConstructor(size, newHasher))
+	require.NoError(t, err)
+	dah, err := share.NewRoot(eds)
+	require.NoError(t, err)
+	eh := headertest.RandExtendedHeaderWithRoot(t, dah)
+
+	getter := mocks.NewMockGetter(gomock.NewController(t))
+	// roots are recomputed with the default hasher unless configured otherwise
+	avail := TestAvailability(t, getter)
+	_, err = avail.verifyRoots(eh, eds)
+	require.ErrorIs(t, err, ErrRootMismatch)
+
+	avail = TestAvailability(t, getter, WithNMTHasher(newHasher))
+	verified, err := avail.verifyRoots(eh, eds)
+	require.NoError(t, err)
+	assert.Len(t, verified, len(dah.RowRoots)+len(dah.ColumnRoots))
+}
+
+func cloneRoots(roots [][]byte) [][]byte {
+	cloned := make([][]byte, len(roots))
+	for i, root := range roots {
+		cloned[i] = append([]byte(nil), root...)
+	}
+	return cloned
+}
+
+func TestSharesAvailable_Full_MemoryBudget(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const squares = 4
+	getter := &slowEDSGetter{squares: make(map[string]*rsmt2d.ExtendedDataSquare)}
+	headers := make([]*header.ExtendedHeader, squares)
+	for i := range headers {
+		eds := edstest.RandEDS(t, 4)
+		dah, err := da.NewDataAvailabilityHeader(eds)
+		require.NoError(t, err)
+		headers[i] = headertest.RandExtendedHeaderWithRoot(t, &dah)
+		getter.squares[dah.String()] = eds
+	}
+
+	// the budget fits a single extended square of 8x8 shares
+	const budget = 8 * 8 * share.Size
+	avail := TestAvailability(t, getter, WithMemoryBudget(budget))
+
+	var wg sync.WaitGroup
+	for _, eh := range headers {
+		wg.Add(1)
+		go func(eh *header.ExtendedHeader) {
+			defer wg.Done()
+			assert.NoError(t, avail.SharesAvailable(ctx, eh))
+		}(eh)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, getter.maxInFlight.Load())
+
+	// reconstruction still waiting for the budget is reported as backpressure
+	require.NoError(t, avail.budget.Acquire(ctx, budget))
+	eds := edstest.RandEDS(t, 4)
+	dah, err := da.NewDataAvailabilityHeader(eds)
+	require.NoError(t, err)
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer timeoutCancel()
+	err = avail.SharesAvailable(timeoutCtx, headertest.RandExtendedHeaderWithRoot(t, &dah))
+	require.ErrorIs(t, err, share.ErrBackpressure)
+}
+
+// slowEDSGetter serves squares by root after a delay and tracks the amount of concurrent requests.
+type slowEDSGetter struct {
+	share.Getter
+	squares map[string]*rsmt2d.ExtendedDataSquare
+
+	inFlight, maxInFlight atomic.Int64
+}
+
+func (g *slowEDSGetter) GetEDS(ctx context.Context, h *header.ExtendedHeader) (*rsmt2d.ExtendedDataSquare, error) {
+	inFlight := g.inFlight.Add(1)
+	defer g.inFlight.Add(-1)
+	for {
+		maxInFlight := g.maxInFlight.Load()
+		if inFlight <= maxInFlight || g.maxInFlight.CompareAndSwap(maxInFlight, inFlight) {
+			break
+		}
+	}
+
+	select {
+	case <-time.After(50 * time.Millisecond):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return g.squares[h.DAH.String()], nil
+}
+
+func TestSharesAvailable_Full_PartialRows(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	getter, dah := GetterWithRandSquare(t, 8)
+	eh := headertest.RandExtendedHeaderWithRoot(t, dah)
+	missing := map[int]bool{1: true, 6: true, 13: true}
+	partial := &partialGetter{Getter: getter, missingRows: missing}
+
+	// rows are not confirmed unless enabled
+	avail := TestAvailability(t, partial)
+	err := avail.SharesAvailable(ctx, eh)
+	require.ErrorIs(t, err, share.ErrNotAvailable)
+	var partialErr *share.PartialAvailabilityError
+	require.False(t, errors.As(err, &partialErr))
+
+	avail = TestAvailability(t, partial, WithConfirmRows(true))
+	err = avail.SharesAvailable(ctx, eh)
+	require.ErrorIs(t, err, share.ErrNotAvailable)
+	require.ErrorAs(t, err, &partialErr)
+	require.Equal(t, len(dah.RowRoots), partialErr.ConfirmedRows.Len())
+	for row := range dah.RowRoots {
+		assert.Equal(t, !missing[row], partialErr.ConfirmedRows.IsSet(row), "row %d", row)
+	}
+
+	// rows are confirmed even though the retrieval of the square used up the time of the request
+	expiring, expire := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer expire()
+	avail = TestAvailability(t, &partialGetter{Getter: getter, missingRows: missing, block: true},
+		WithConfirmRows(true))
+	err = avail.SharesAvailable(expiring, eh)
+	require.ErrorAs(t, err, &partialErr)
+	assert.Equal(t, len(dah.RowRoots)-len(missing), partialErr.ConfirmedRows.Count())
+}
+
+// partialGetter fails to retrieve the whole square and shares of the missing rows. If block is set,
+// the square is requested until the context is done.
+type partialGetter struct {
+	share.Getter
+	missingRows map[int]bool
+	block       bool
+}
+
+func (g *partialGetter) GetEDS(ctx context.Context, _ *header.ExtendedHeader) (*rsmt2d.ExtendedDataSquare, error) {
+	if g.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return nil, share.ErrNotFound
+}
+
+func (g *partialGetter) GetShare(ctx context.Context, h *header.ExtendedHeader, row, col int) (share.Share, error) {
+	if g.missingRows[row] {
+		return nil, share.ErrNotFound
+	}
+	return g.Getter.GetShare(ctx, h, row, col)
+}
+
+func TestSharesAvailable_Full_PersistReconstructed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	getter, dah := GetterWithRandSquare(t, 16)
+	eh := headertest.RandExtendedHeaderWithRoot(t, dah)
+	counter := &getEDSCounter{Getter: getter}
+	persisted := &squareStoreStub{squares: make(map[string]*rsmt2d.ExtendedDataSquare)}
+
+	avail := TestAvailability(t, counter, WithPersistReconstructed(persisted))
+	require.NoError(t, avail.SharesAvailable(ctx, eh))
+	require.EqualValues(t, 1, counter.calls.Load())
+	require.Contains(t, persisted.squares, dah.String())
+
+	// the square is not in the eds.Store of another availability, so it is read from the persist
+	// store instead of being retrieved again
+	avail = TestAvailability(t, counter, WithPersistReconstructed(persisted))
+	require.NoError(t, avail.SharesAvailable(ctx, eh))
+	assert.EqualValues(t, 1, counter.calls.Load())
+	assert.EqualValues(t, 1, persisted.gets.Load())
+
+	has, err := avail.store.Has(ctx, dah.Hash())
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+// getEDSCounter counts GetEDS calls to the wrapped share.Getter.
+type getEDSCounter struct {
+	share.Getter
+	calls atomic.Int64
+}
+
+func (g *getEDSCounter) GetEDS(ctx context.Context, h *header.ExtendedHeader) (*rsmt2d.ExtendedDataSquare, error) {
+	g.calls.Add(1)
+	return g.Getter.GetEDS(ctx, h)
+}
+
+// squareStoreStub keeps squares in memory and counts squares read from it.
+type squareStoreStub struct {
+	lock    sync.Mutex
+	squares map[string]*rsmt2d.ExtendedDataSquare
+	gets    atomic.Int64
+}
+
+func (s *squareStoreStub) Get(_ context.Context, root share.DataHash) (*rsmt2d.ExtendedDataSquare, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	square, ok := s.squares[root.String()]
+	if !ok {
+		return nil, share.ErrNotFound
+	}
+	s.gets.Add(1)
+	return square, nil
+}
+
+func (s *squareStoreStub) Put(_ context.Context, root share.DataHash, square *rsmt2d.ExtendedDataSquare) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.squares[root.String()] = square
+	return nil
+}
+
+func TestNewShareAvailability_InvalidParameters(t *testing.T) {
+	_, err := NewShareAvailability(nil, nil, nil, WithRootVerifyFraction(0))
+	require.Error(t, err)
+}
@@ -0,0 +1,120 @@
+package full
+
+import (
+	"context"
+	"fmt"
+	"hash"
+
+	"github.com/celestiaorg/rsmt2d"
+
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+// DefaultRootVerifyFraction is the default fraction of row and column roots of a retrieved square
+// verified against the DataAvailabilityHeader.
+const DefaultRootVerifyFraction = 1.0
+
+// Parameters is the set of Parameters that must be configured for the full
+// availability implementation
+type Parameters struct {
+	// RootVerifyFraction is the fraction of row and column roots of every retrieved square that are
+	// recomputed and compared against the DataAvailabilityHeader, selected at random for every check.
+	// Values below 1 save time on comparisons, but a square with a fault in an unverified root is
+	// accepted and stored as available, so only the fraction of 1 guarantees the stored data matches
+	// the header.
+	RootVerifyFraction float64
+
+	// MemoryBudget limits the combined estimated size in bytes of extended squares reconstructed
+	// simultaneously. Reconstructions exceeding the budget are queued until memory is released. A
+	// reconstruction still waiting once its context expires is reported as share.ErrBackpressure, so
+	// the DASer reduces the amount of parallel sampling workers. If set to 0, memory is not limited.
+	MemoryBudget uint64
+
+	// PersistStore is the store reconstructed squares are written through to, so later availability
+	// checks of the same data read the square from it instead of retrieving it from the network
+	// again, e.g. after the square was removed from the eds.Store. If nil, squares are only stored
+	// in the eds.Store.
+	PersistStore SquareStore
+
+	// ConfirmRows enables confirming which rows of a square not retrieved as a whole can still be
+	// retrieved, by requesting shares of the left half of every row individually. The rows are
+	// reported with share.PartialAvailabilityError. The check issues up to half of the shares of the
+	// extended square as separate requests, so it is disabled by default.
+	ConfirmRows bool
+
+	// NMTHasher creates hashers of nmt nodes used to recompute roots of retrieved squares. Hashes
+	// must have the size of sha256 hashes. If not set, share.DefaultNMTHasher is used.
+	NMTHasher func() hash.Hash
+}
+
+// SquareStore persists extended data squares by their data root. It is implemented by eds.Store.
+type SquareStore interface {
+	// Get returns the square of the data root. It returns an error if the square is not stored.
+	Get(ctx context.Context, root share.DataHash) (*rsmt2d.ExtendedDataSquare, error)
+	// Put stores the square of the data root.
+	Put(ctx context.Context, root share.DataHash, square *rsmt2d.ExtendedDataSquare) error
+}
+
+// Option is a function that configures full availability Parameters
+type Option func(*Parameters)
+
+// DefaultParameters returns the default Parameters' configuration values
+// for the full availability implementation
+func DefaultParameters() Parameters {
+	return Parameters{
+		RootVerifyFraction: DefaultRootVerifyFraction,
+	}
+}
+
+// Validate validates the values in Parameters
+func (p *Parameters) Validate() error {
+	if p.RootVerifyFraction <= 0 || p.RootVerifyFraction > 1 {
+		return fmt.Errorf(
+			"full availability: invalid option: value %s was %v, where it should be %s",
+			"RootVerifyFraction",
+			p.RootVerifyFraction, // current value
+			"in range (0, 1]",    // what the value should be
+		)
+	}
+	return nil
+}
+
+// WithRootVerifyFraction is a functional option that the Availability interface
+// implementers use to set the RootVerifyFraction configuration param
+func WithRootVerifyFraction(fraction float64) Option {
+	return func(p *Parameters) {
+		p.RootVerifyFraction = fraction
+	}
+}
+
+// WithMemoryBudget is a functional option that the Availability interface
+// implementers use to set the MemoryBudget configuration param
+func WithMemoryBudget(bytes uint64) Option {
+	return func(p *Parameters) {
+		p.MemoryBudget = bytes
+	}
+}
+
+// WithConfirmRows is a functional option that the Availability interface
+// implementers use to set the ConfirmRows configuration param
+func WithConfirmRows(enabled bool) Option {
+	return func(p *Parameters) {
+		p.ConfirmRows = enabled
+	}
+}
+
+// WithNMTHasher is a functional option that the Availability interface
+// implementers use to set the NMTHasher configuration param
+func WithNMTHasher(hasher func() hash.Hash) Option {
+	return func(p *Parameters) {
+		p.NMTHasher = hasher
+	}
+}
+
+// WithPersistReconstructed is a functional option that the Availability interface
+// implementers use to set the PersistStore configuration param
+func WithPersistReconstructed(store SquareStore) Option {
+	return func(p *Parameters) {
+		p.PersistStore = store
+	}
+}
@@ -40,7 +40,7 @@ func Node(dn *availability_test.TestDagNet) *availability_test.TestNode {
 	return nd
 }
 
-func TestAvailability(t *testing.T, getter share.Getter) *ShareAvailability {
+func TestAvailability(t *testing.T, getter share.Getter, opts ...Option) *ShareAvailability {
 	params := discovery.DefaultParameters()
 	params.AdvertiseInterval = time.Second
 	params.PeersLimit = 10
@@ -60,5 +60,9 @@ func TestAvailability(t *testing.T, getter share.Getter) *ShareAvailability {
 		err = store.Stop(context.Background())
 		require.NoError(t, err)
 	})
-	return NewShareAvailability(store, getter, disc)
+	avail, err := NewShareAvailability(store, getter, disc, opts...)
+	require.NoError(t, err)
+	// keep tests of unavailable data fast
+	avail.rowCheckTimeout = time.Second
+	return avail
 }
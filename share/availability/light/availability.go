@@ -3,6 +3,8 @@ package light
 import (
 	"context"
 	"errors"
+	"fmt"
+	"hash"
 	"sync"
 
 	"github.com/ipfs/go-datastore"
@@ -29,6 +31,10 @@ var (
 type ShareAvailability struct {
 	getter share.Getter
 	params Parameters
+	// selectSamples picks the coordinates of samples within the square
+	selectSamples func(squareWidth int, num int, region SampleRegion) ([]Sample, error)
+	// proofs caches sampled shares with proofs, if the getter supports them
+	proofs *proofCache
 
 	// TODO(@Wondertan): Once we come to parallelized DASer, this lock becomes a contention point
 	//  Related to #483
@@ -51,20 +57,68 @@ func NewShareAvailability(
 		opt(&params)
 	}
 
-	return &ShareAvailability{
-		getter: getter,
-		params: params,
-		ds:     autoDS,
+	if params.Mode == HeuristicMode {
+		log.Warnw("availability is verified heuristically, " +
+			"which gives no data availability sampling confidence")
 	}
+
+	if params.SampleRegion != RegionFull {
+		log.Warnw("sampling is restricted to a region of the extended square, "+
+			"which weakens the security guarantees of data availability sampling",
+			"region", params.SampleRegion.String())
+	}
+
+	sampler := params.CoordSampler
+	switch {
+	case sampler != nil:
+		log.Warnw("samples are selected by a custom sampler, " +
+			"non-uniform distributions of samples weaken the security guarantees of data availability sampling")
+		if params.AxisCoverage {
+			log.Warn("axis coverage is ignored, as samples are selected by a custom sampler")
+		}
+	case params.AxisCoverage:
+		sampler = AxisSampler{}
+	default:
+		sampler = UniformSampler{}
+	}
+
+	la := &ShareAvailability{
+		getter:        getter,
+		params:        params,
+		selectSamples: sampler.Sample,
+		ds:            autoDS,
+	}
+	if _, ok := getter.(ProofGetter); ok && params.ProofCacheSize > 0 {
+		proofs, err := newProofCache(params.ProofCacheSize)
+		if err != nil {
+			log.Errorw("creating proof cache, proofs will not be cached", "err", err)
+		}
+		la.proofs = proofs
+	}
+	return la
 }
 
-// SharesAvailable randomly samples `params.SampleAmount` amount of Shares committed to the given
-// ExtendedHeader. This way SharesAvailable subjectively verifies that Shares are available.
+// SharesAvailable randomly samples the amount of Shares given by sampleCount, committed to the
+// given ExtendedHeader. This way SharesAvailable subjectively verifies that Shares are available.
+// In HeuristicMode, it only checks every row serves a share instead, so callers that have to tell
+// the heuristic apart from sampling should use VerifyAvailable.
 func (la *ShareAvailability) SharesAvailable(ctx context.Context, header *header.ExtendedHeader) error {
+	_, err := la.VerifyAvailable(ctx, header)
+	return err
+}
+
+// VerifyAvailable verifies availability of data committed to the given ExtendedHeader like
+// SharesAvailable and returns the confidence it was verified with. It is VerdictHeuristicAvailable
+// if the square was only checked in HeuristicMode, and VerdictAvailable if it was sampled.
+func (la *ShareAvailability) VerifyAvailable(ctx context.Context, header *header.ExtendedHeader) (Verdict, error) {
 	dah := header.DAH
-	// short-circuit if the given root is minimum DAH of an empty data square
-	if share.DataHash(dah.Hash()).IsEmptyRoot() {
-		return nil
+	// short-circuit if the given root is minimum DAH of an empty data square or of a padding square,
+	// if configured
+	if la.trivialAvailable(dah) {
+		return VerdictAvailable, nil
+	}
+	if la.params.Mode == HeuristicMode {
+		return la.heuristicAvailable(ctx, header)
 	}
 
 	// do not sample over Root that has already been sampled
@@ -73,8 +127,11 @@ func (la *ShareAvailability) SharesAvailable(ctx context.Context, header *header
 	la.dsLk.RLock()
 	exists, err := la.ds.Has(ctx, key)
 	la.dsLk.RUnlock()
-	if err != nil || exists {
-		return err
+	if err != nil {
+		return VerdictUnknown, err
+	}
+	if exists {
+		return VerdictAvailable, nil
 	}
 
 	log.Debugw("validate availability", "root", dah.String())
@@ -85,26 +142,177 @@ func (la *ShareAvailability) SharesAvailable(ctx context.Context, header *header
 			"err", err)
 		panic(err)
 	}
-	samples, err := SampleSquare(len(dah.RowRoots), int(la.params.SampleAmount))
+	samples, err := la.sampleSquare(len(dah.RowRoots), la.sampleCount(len(dah.RowRoots)))
 	if err != nil {
-		return err
+		return VerdictUnknown, err
+	}
+
+	if err = la.fetchSamples(ctx, header, samples); err != nil {
+		return VerdictUnknown, err
+	}
+
+	la.dsLk.Lock()
+	err = la.ds.Put(ctx, key, []byte{})
+	la.dsLk.Unlock()
+	if err != nil {
+		log.Errorw("storing root of successful SharesAvailable request to disk", "err", err)
+	}
+	return VerdictAvailable, nil
+}
+
+// sampleSquare selects samples within the square and validates the selection to catch bugs of the
+// selector, as duplicate or missing samples weaken the guarantees of sampling.
+func (la *ShareAvailability) sampleSquare(width int, num int) ([]Sample, error) {
+	samples, err := la.selectSamples(width, num, la.params.SampleRegion)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateSamples(samples, width, num, la.params.SampleRegion); err != nil {
+		log.Errorw("selected samples are invalid", "err", err)
+		return nil, err
+	}
+	return samples, nil
+}
+
+// ReverifyAvailable verifies availability of data committed to the given ExtendedHeader again,
+// even if it was verified before. Cached shares with proofs of previous samples are verified against
+// the header roots without fetching them, and only the remaining amount of samples is fetched.
+func (la *ShareAvailability) ReverifyAvailable(ctx context.Context, header *header.ExtendedHeader) error {
+	dah := header.DAH
+	if la.trivialAvailable(dah) {
+		return nil
+	}
+	if err := dah.ValidateBasic(); err != nil {
+		return fmt.Errorf("light availability: malformed root: %w", err)
+	}
+	if la.params.Mode == HeuristicMode {
+		return la.verifyHeuristic(ctx, header)
+	}
+
+	width := len(dah.RowRoots)
+	verified := make(map[Sample]struct{})
+	if la.proofs != nil {
+		for _, sp := range la.proofs.get(dah) {
+			if err := verifySampleProof(dah, sp, la.nmtHasher()); err != nil {
+				log.Warnw("cached sample proof is invalid", "root", dah.String(), "row", sp.Row, "col", sp.Col, "err", err)
+				continue
+			}
+			verified[sp.Sample] = struct{}{}
+		}
+	}
+
+	// a square can't be sampled more times than it has shares
+	required := min(la.sampleCount(width), width*width)
+	if missing := required - len(verified); missing > 0 {
+		log.Debugw("reverifying availability", "root", dah.String(), "cached", len(verified), "missing", missing)
+		samples, err := la.sampleSquare(width, required)
+		if err != nil {
+			return err
+		}
+
+		toFetch := make([]Sample, 0, missing)
+		for _, s := range samples {
+			if _, ok := verified[s]; !ok && len(toFetch) < missing {
+				toFetch = append(toFetch, s)
+			}
+		}
+		if err = la.fetchSamples(ctx, header, toFetch); err != nil {
+			return err
+		}
+	}
+
+	la.dsLk.Lock()
+	err := la.ds.Put(ctx, rootKey(dah), []byte{})
+	la.dsLk.Unlock()
+	if err != nil {
+		log.Errorw("storing root of successful ReverifyAvailable request to disk", "err", err)
+	}
+	return nil
+}
+
+// NamespaceAvailable retrieves shares of the namespace committed to the given ExtendedHeader
+// together with their range proofs and verifies them against the header roots. It reports whether
+// the namespace is present with all its shares or provably absent from the square.
+func (la *ShareAvailability) NamespaceAvailable(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+	namespace share.Namespace,
+) (share.NamespaceStatus, error) {
+	status, _, err := la.namespaceShares(ctx, header, namespace)
+	return status, err
+}
+
+// NamespaceAbsent verifies whether the namespace is provably absent from the square committed to
+// the given ExtendedHeader. The returned NamespacedShares prove the verdict against the header roots:
+// rows covering the namespace come with absence proofs, while rows not covering it are proven by
+// the roots themselves. If the verdict can't be determined, e.g. the rows can't be retrieved, an
+// error is returned.
+func (la *ShareAvailability) NamespaceAbsent(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+	namespace share.Namespace,
+) (bool, share.NamespacedShares, error) {
+	status, shares, err := la.namespaceShares(ctx, header, namespace)
+	if err != nil {
+		return false, nil, err
+	}
+	return status == share.NamespaceAbsent, shares, nil
+}
+
+// namespaceShares retrieves shares of the namespace with their proofs and classifies presence of
+// the namespace in the square.
+func (la *ShareAvailability) namespaceShares(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+	namespace share.Namespace,
+) (share.NamespaceStatus, share.NamespacedShares, error) {
+	if err := namespace.ValidateForData(); err != nil {
+		return 0, nil, err
+	}
+	dah := header.DAH
+	if share.DataHash(dah.Hash()).IsEmptyRoot() {
+		return share.NamespaceAbsent, nil, nil
+	}
+
+	shares, err := getters.GetterFrom(ctx, la.getter).GetSharesByNamespace(ctx, header, namespace)
+	if err != nil {
+		if errors.Is(err, share.ErrNotFound) || ipldFormat.IsNotFound(err) || errors.Is(err, context.DeadlineExceeded) {
+			return 0, nil, fmt.Errorf("%w: namespace %s: %w", share.ErrNotAvailable, namespace.String(), err)
+		}
+		return 0, nil, err
+	}
+
+	status, err := shares.StatusWithHasher(dah, namespace, la.nmtHasher())
+	if err != nil {
+		log.Errorw("namespace verification failed", "root", dah.String(), "namespace", namespace.String(), "err", err)
+		return 0, nil, fmt.Errorf("light availability: verifying namespace %s: %w", namespace.String(), err)
 	}
+	return status, shares, nil
+}
 
+// fetchSamples fetches shares at the given samples in parallel and returns share.ErrNotAvailable if
+// any of them could not be retrieved. Samples are fetched in a single request if the getter
+// implements share.SampleGetter and no proofs are needed to cache or verify them.
+func (la *ShareAvailability) fetchSamples(ctx context.Context, header *header.ExtendedHeader, samples []Sample) error {
+	dah := header.DAH
 	// indicate to the share.Getter that a blockservice session should be created. This
 	// functionality is optional and must be supported by the used share.Getter.
 	ctx = getters.WithSession(ctx)
 
 	log.Debugw("starting sampling session", "root", dah.String())
+	batcher, ok := getters.GetterFrom(ctx, la.getter).(share.SampleGetter)
+	if ok && la.proofs == nil && la.params.NMTHasher == nil {
+		return fetchErr(dah, la.fetchBatch(ctx, batcher, header, samples))
+	}
+
 	errs := make(chan error, len(samples))
 	for _, s := range samples {
 		go func(s Sample) {
 			log.Debugw("fetching share", "root", dah.String(), "row", s.Row, "col", s.Col)
-			_, err := la.getter.GetShare(ctx, header, s.Row, s.Col)
+			err := la.fetchSample(ctx, header, s)
 			if err != nil {
 				log.Debugw("error fetching share", "root", dah.String(), "row", s.Row, "col", s.Col)
 			}
-			// we don't really care about Share bodies at this point
-			// it also means we now saved the Share in local storage
 			select {
 			case errs <- err:
 			case <-ctx.Done():
@@ -121,30 +329,114 @@ func (la *ShareAvailability) SharesAvailable(ctx context.Context, header *header
 		}
 
 		if err != nil {
-			if errors.Is(err, context.Canceled) {
-				return err
-			}
-			log.Errorw("availability validation failed", "root", dah.String(), "err", err.Error())
-			if ipldFormat.IsNotFound(err) || errors.Is(err, context.DeadlineExceeded) {
-				return share.ErrNotAvailable
-			}
-			return err
+			return fetchErr(dah, err)
 		}
 	}
+	return nil
+}
 
-	la.dsLk.Lock()
-	err = la.ds.Put(ctx, key, []byte{})
-	la.dsLk.Unlock()
+// fetchBatch fetches shares at the given samples in a single request to the getter and returns the
+// error of the first sample that could not be retrieved, if any.
+func (la *ShareAvailability) fetchBatch(
+	ctx context.Context,
+	batcher share.SampleGetter,
+	header *header.ExtendedHeader,
+	samples []Sample,
+) error {
+	coords := make([]share.SampleCoord, len(samples))
+	for i, s := range samples {
+		coords[i] = share.SampleCoord{Row: s.Row, Col: s.Col}
+	}
+	results, err := batcher.GetSamples(ctx, header, coords)
 	if err != nil {
-		log.Errorw("storing root of successful SharesAvailable request to disk", "err", err)
+		return err
+	}
+	if len(results) != len(coords) {
+		return fmt.Errorf("light availability: got %d results for %d samples", len(results), len(coords))
+	}
+	for i, res := range results {
+		if res.Err != nil {
+			log.Debugw("error fetching share", "root", header.DAH.String(), "row", coords[i].Row, "col", coords[i].Col)
+			return res.Err
+		}
 	}
 	return nil
 }
 
+// fetchErr converts the error of fetching a sample to the error of availability validation.
+func fetchErr(dah *share.Root, err error) error {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return err
+	}
+	log.Errorw("availability validation failed", "root", dah.String(), "err", err.Error())
+	if ipldFormat.IsNotFound(err) || errors.Is(err, context.DeadlineExceeded) {
+		return share.ErrNotAvailable
+	}
+	return err
+}
+
+// fetchSample fetches the share at the sample, together with its proof if proofs are cached or a
+// custom NMTHasher is set. Shares fetched with the custom NMTHasher are verified with it, as getters
+// verify shares with the default one.
+func (la *ShareAvailability) fetchSample(ctx context.Context, header *header.ExtendedHeader, s Sample) error {
+	// the getter carried by the context may not serve proofs, even if the own one does
+	getter := getters.GetterFrom(ctx, la.getter)
+	proofGetter, ok := getter.(ProofGetter)
+	customHasher := la.params.NMTHasher != nil
+	if customHasher && !ok {
+		return errors.New("light availability: getter does not serve proofs to verify with the NMTHasher")
+	}
+	if !ok || (la.proofs == nil && !customHasher) {
+		// we don't really care about Share bodies at this point
+		// it also means we now saved the Share in local storage
+		_, err := getter.GetShare(ctx, header, s.Row, s.Col)
+		return err
+	}
+
+	sh, err := proofGetter.GetShareWithProof(ctx, header, s.Row, s.Col)
+	if err != nil {
+		return err
+	}
+	sp := SampleProof{Sample: s, Share: sh}
+	if customHasher {
+		if err := verifySampleProof(header.DAH, sp, la.params.NMTHasher); err != nil {
+			return fmt.Errorf("light availability: verifying share at row %d, col %d: %w", s.Row, s.Col, err)
+		}
+	}
+	if la.proofs != nil {
+		la.proofs.add(header.DAH, sp)
+	}
+	return nil
+}
+
+// nmtHasher returns the function creating hashers of nmt nodes to verify shares with.
+func (la *ShareAvailability) nmtHasher() func() hash.Hash {
+	if la.params.NMTHasher == nil {
+		return share.DefaultNMTHasher
+	}
+	return la.params.NMTHasher
+}
+
+// sampleCount returns the amount of samples to perform for the extended square of the given width.
+func (la *ShareAvailability) sampleCount(squareWidth int) int {
+	if la.params.SampleCountFunc == nil {
+		return DefaultSampleCount(la.params.SampleAmount, squareWidth)
+	}
+	return max(la.params.SampleCountFunc(squareWidth), 1)
+}
+
 func rootKey(root *share.Root) datastore.Key {
 	return datastore.NewKey(root.String())
 }
 
+// Warmup warms up the getter, if it implements share.Warmer.
+func (la *ShareAvailability) Warmup(ctx context.Context) error {
+	if warmer, ok := la.getter.(share.Warmer); ok {
+		return warmer.Warmup(ctx)
+	}
+	return nil
+}
+
 // Close flushes all queued writes to disk.
 func (la *ShareAvailability) Close(ctx context.Context) error {
 	return la.ds.Flush(ctx)
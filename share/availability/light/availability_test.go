@@ -2,16 +2,26 @@ package light
 
 import (
 	"context"
+	"crypto/sha512"
 	_ "embed"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/ipfs/go-datastore"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	appshares "github.com/celestiaorg/celestia-app/pkg/shares"
+
+	"github.com/celestiaorg/celestia-node/header"
 	"github.com/celestiaorg/celestia-node/header/headertest"
 	"github.com/celestiaorg/celestia-node/share"
 	availability_test "github.com/celestiaorg/celestia-node/share/availability/test"
+	"github.com/celestiaorg/celestia-node/share/eds/byzantine"
+	"github.com/celestiaorg/celestia-node/share/getters"
 	"github.com/celestiaorg/celestia-node/share/ipld"
 	"github.com/celestiaorg/celestia-node/share/sharetest"
 )
@@ -38,6 +48,140 @@ func TestSharesAvailableCaches(t *testing.T) {
 	assert.True(t, has)
 }
 
+func TestSharesAvailableInvalidSamples(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	getter, eh := GetterWithRandSquare(t, 16)
+	avail := TestAvailability(getter)
+	// faulty selector duplicates one of the samples
+	avail.selectSamples = func(width, num int, region SampleRegion) ([]Sample, error) {
+		samples, err := SampleSquareRegion(width, num, region)
+		if err != nil {
+			return nil, err
+		}
+		samples[len(samples)-1] = samples[0]
+		return samples, nil
+	}
+
+	err := avail.SharesAvailable(ctx, eh)
+	require.ErrorIs(t, err, errInvalidSamples)
+
+	// root is not cached as available
+	has, err := avail.ds.Has(ctx, rootKey(eh.DAH))
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestSharesAvailableCoordSampler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	getter, eh := GetterWithRandSquare(t, 16)
+	recorder := &getShareRecorder{Getter: getter}
+	// biased toward the parity region, drawing samples from the diagonal of the bottom right quadrant
+	var selected []Sample
+	sampler := CoordSamplerFunc(func(width, num int, _ SampleRegion) ([]Sample, error) {
+		selected = make([]Sample, num)
+		for i := range selected {
+			selected[i] = Sample{Row: width - 1 - i, Col: width - 1 - i}
+		}
+		return selected, nil
+	})
+	avail := NewShareAvailability(recorder, datastore.NewMapDatastore(), WithCoordSampler(sampler))
+
+	err := avail.SharesAvailable(ctx, eh)
+	require.NoError(t, err)
+	require.NotEmpty(t, selected)
+	assert.ElementsMatch(t, selected, recorder.requested())
+}
+
+func TestSharesAvailableCoordSamplerInvalid(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	getter, eh := GetterWithRandSquare(t, 16)
+	// sampler returning less samples than requested
+	sampler := CoordSamplerFunc(func(width, num int, region SampleRegion) ([]Sample, error) {
+		samples, err := UniformSampler{}.Sample(width, num, region)
+		return samples[1:], err
+	})
+	avail := NewShareAvailability(getter, datastore.NewMapDatastore(), WithCoordSampler(sampler))
+
+	err := avail.SharesAvailable(ctx, eh)
+	require.ErrorIs(t, err, errInvalidSamples)
+}
+
+func TestSharesAvailableHeuristicMode(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	getter, eh := GetterWithRandSquare(t, 16)
+	recorder := &getShareRecorder{Getter: getter}
+	ds := datastore.NewMapDatastore()
+	avail := NewShareAvailability(recorder, ds, WithMode(HeuristicMode))
+
+	verdict, err := avail.Verdict(ctx, eh.DAH)
+	require.NoError(t, err)
+	assert.Equal(t, VerdictUnknown, verdict)
+
+	verdict, err = avail.VerifyAvailable(ctx, eh)
+	require.NoError(t, err)
+	assert.Equal(t, VerdictHeuristicAvailable, verdict)
+	requested := recorder.requested()
+	width := len(eh.DAH.RowRoots)
+	require.Len(t, requested, width)
+	rows := make(map[int]int)
+	for _, s := range requested {
+		rows[s.Row]++
+	}
+	assert.Len(t, rows, width)
+	for row, count := range rows {
+		assert.Equal(t, 1, count, "row %d", row)
+	}
+
+	verdict, err = avail.Verdict(ctx, eh.DAH)
+	require.NoError(t, err)
+	assert.Equal(t, VerdictHeuristicAvailable, verdict)
+
+	// the heuristic verdict is not mistaken for the one of sampling
+	require.NoError(t, avail.Close(ctx))
+	sampling := NewShareAvailability(getter, ds)
+	verdict, err = sampling.Verdict(ctx, eh.DAH)
+	require.NoError(t, err)
+	assert.Equal(t, VerdictHeuristicAvailable, verdict)
+	verdict, err = sampling.VerifyAvailable(ctx, eh)
+	require.NoError(t, err)
+	assert.Equal(t, VerdictAvailable, verdict)
+	verdict, err = sampling.Verdict(ctx, eh.DAH)
+	require.NoError(t, err)
+	assert.Equal(t, VerdictAvailable, verdict)
+}
+
+// getShareRecorder records coordinates of GetShare calls to the wrapped share.Getter.
+type getShareRecorder struct {
+	share.Getter
+	lock   sync.Mutex
+	coords []Sample
+}
+
+func (g *getShareRecorder) GetShare(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+	row, col int,
+) (share.Share, error) {
+	g.lock.Lock()
+	g.coords = append(g.coords, Sample{Row: row, Col: col})
+	g.lock.Unlock()
+	return g.Getter.GetShare(ctx, header, row, col)
+}
+
+func (g *getShareRecorder) requested() []Sample {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return append([]Sample(nil), g.coords...)
+}
+
 func TestSharesAvailableHitsCache(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -78,6 +222,47 @@ func TestSharesAvailableEmptyRoot(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestSharesAvailablePaddingSquare(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// original data of the 8x8 extended square consists of tail padding only, while the parity
+	// quadrants are extended from it
+	padding := appshares.ToBytes(appshares.TailPaddingShares(16))
+	bServ := ipld.NewMemBlockservice()
+	dah := availability_test.FillBS(t, bServ, padding)
+	require.False(t, share.DataHash(dah.Hash()).IsEmptyRoot())
+	eh := headertest.RandExtendedHeaderWithRoot(t, dah)
+
+	t.Run("sampled", func(t *testing.T) {
+		counter := &getShareCounter{Getter: getters.NewIPLDGetter(bServ)}
+		avail := NewShareAvailability(counter, datastore.NewMapDatastore())
+		require.NoError(t, avail.SharesAvailable(ctx, eh))
+		assert.Positive(t, counter.calls.Load())
+
+		verdict, err := avail.Verdict(ctx, dah)
+		require.NoError(t, err)
+		assert.Equal(t, VerdictAvailable, verdict)
+	})
+
+	t.Run("trivial", func(t *testing.T) {
+		// nothing is served, as the data is determined by the width of the square
+		counter := &getShareCounter{Getter: getters.NewIPLDGetter(ipld.NewMemBlockservice())}
+		avail := NewShareAvailability(counter, datastore.NewMapDatastore(), WithPaddingPolicy(PaddingTrivial))
+		require.NoError(t, avail.SharesAvailable(ctx, eh))
+		require.NoError(t, avail.ReverifyAvailable(ctx, eh))
+		assert.Zero(t, counter.calls.Load())
+
+		verdict, err := avail.Verdict(ctx, dah)
+		require.NoError(t, err)
+		assert.Equal(t, VerdictAvailable, verdict)
+
+		// squares with any original data are still sampled
+		_, other := GetterWithRandSquare(t, 4)
+		assert.Error(t, avail.SharesAvailable(ctx, other))
+	})
+}
+
 func TestSharesAvailable(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -88,6 +273,118 @@ func TestSharesAvailable(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestSharesAvailableSampleCount(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sampleShares := func(t *testing.T, odsWidth int, opts ...Option) int {
+		getter, eh := GetterWithRandSquare(t, odsWidth)
+		counter := &getShareCounter{Getter: getter}
+		avail := NewShareAvailability(counter, datastore.NewMapDatastore(), opts...)
+		require.NoError(t, avail.SharesAvailable(ctx, eh))
+		return int(counter.calls.Load())
+	}
+
+	// 16x16 and 64x64 extended squares
+	small, large := sampleShares(t, 8), sampleShares(t, 32)
+	assert.Equal(t, DefaultSampleCount(DefaultSampleAmount, 16), small)
+	assert.Equal(t, DefaultSampleCount(DefaultSampleAmount, 64), large)
+	assert.Greater(t, large, small)
+
+	custom := sampleShares(t, 8, WithSampleCountFunc(func(squareWidth int) int {
+		return squareWidth / 2
+	}))
+	assert.Equal(t, 8, custom)
+}
+
+func TestDefaultSampleCount(t *testing.T) {
+	assert.Equal(t, 16, DefaultSampleCount(16, 4))
+	assert.Equal(t, 16, DefaultSampleCount(16, 16))
+	assert.Equal(t, 20, DefaultSampleCount(16, 32))
+	assert.Equal(t, 24, DefaultSampleCount(16, 64))
+	// bounded by twice the sample amount
+	assert.Equal(t, 32, DefaultSampleCount(16, 1<<20))
+}
+
+// getShareCounter counts GetShare calls to the wrapped share.Getter.
+type getShareCounter struct {
+	share.Getter
+	calls atomic.Int64
+}
+
+func (g *getShareCounter) GetShare(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+	row, col int,
+) (share.Share, error) {
+	g.calls.Add(1)
+	return g.Getter.GetShare(ctx, header, row, col)
+}
+
+func TestReverifyAvailableUsesCachedProofs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	getter, eh := GetterWithRandSquare(t, 16)
+	counter := &proofGetterCounter{getShareCounter: getShareCounter{Getter: getter}}
+	avail := NewShareAvailability(counter, datastore.NewMapDatastore())
+
+	require.NoError(t, avail.SharesAvailable(ctx, eh))
+	initial := counter.calls.Load()
+	require.Positive(t, initial)
+
+	require.NoError(t, avail.ReverifyAvailable(ctx, eh))
+	assert.Less(t, counter.calls.Load()-initial, initial)
+
+	// without cached proofs samples are fetched again
+	counter.calls.Store(0)
+	avail = NewShareAvailability(counter, datastore.NewMapDatastore(), WithProofCacheSize(0))
+	require.NoError(t, avail.ReverifyAvailable(ctx, eh))
+	assert.Equal(t, initial, counter.calls.Load())
+
+	// every share of a square smaller than the sample count is sampled
+	getter, eh = GetterWithRandSquare(t, 1)
+	counter = &proofGetterCounter{getShareCounter: getShareCounter{Getter: getter}}
+	avail = NewShareAvailability(counter, datastore.NewMapDatastore(), WithProofCacheSize(0))
+	require.NoError(t, avail.ReverifyAvailable(ctx, eh))
+	assert.EqualValues(t, 4, counter.calls.Load())
+}
+
+func TestSharesAvailableNMTHasher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	getter, eh := GetterWithRandSquare(t, 16)
+	// samples are verified with the configured hasher, even if proofs are not cached
+	avail := NewShareAvailability(getter, datastore.NewMapDatastore(),
+		WithNMTHasher(share.DefaultNMTHasher), WithProofCacheSize(0))
+	require.NoError(t, avail.SharesAvailable(ctx, eh))
+
+	// roots of the square are computed with the default hasher, so samples don't verify with another
+	avail = NewShareAvailability(getter, datastore.NewMapDatastore(),
+		WithNMTHasher(sha512.New512_256), WithProofCacheSize(0))
+	require.Error(t, avail.SharesAvailable(ctx, eh))
+
+	// samples can't be verified with the configured hasher without proofs
+	avail = NewShareAvailability(&getShareCounter{Getter: getter}, datastore.NewMapDatastore(),
+		WithNMTHasher(share.DefaultNMTHasher))
+	require.Error(t, avail.SharesAvailable(ctx, eh))
+}
+
+// proofGetterCounter counts calls retrieving shares with and without proofs.
+type proofGetterCounter struct {
+	getShareCounter
+}
+
+func (g *proofGetterCounter) GetShareWithProof(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+	row, col int,
+) (*byzantine.ShareWithProof, error) {
+	g.calls.Add(1)
+	return g.Getter.(ProofGetter).GetShareWithProof(ctx, header, row, col)
+}
+
 func TestSharesAvailableFailed(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -208,6 +505,81 @@ func TestGetShares(t *testing.T) {
 	require.True(t, eh.DAH.Equals(gotDAH))
 }
 
+func TestNamespaceAvailable(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const squareSize = 8
+	getter, bServ := EmptyGetter()
+	totalShares := squareSize * squareSize
+	randShares := sharetest.RandShares(t, totalShares)
+	// the namespace spans the end of one row and the beginning of the next one
+	first := totalShares/2 - 2
+	present := share.GetNamespace(randShares[first])
+	for i := first + 1; i < first+4; i++ {
+		copy(share.GetNamespace(randShares[i]), present)
+	}
+	root := availability_test.FillBS(t, bServ, randShares)
+	eh := headertest.RandExtendedHeader(t)
+	eh.DAH = root
+	avail := TestAvailability(getter)
+
+	status, err := avail.NamespaceAvailable(ctx, eh, present)
+	require.NoError(t, err)
+	assert.Equal(t, share.NamespacePresent, status)
+
+	status, err = avail.NamespaceAvailable(ctx, eh, sharetest.RandV0Namespace())
+	require.NoError(t, err)
+	assert.Equal(t, share.NamespaceAbsent, status)
+
+	// rows of the namespace can't be retrieved
+	emptyGetter, _ := EmptyGetter()
+	avail = TestAvailability(emptyGetter)
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer timeoutCancel()
+	_, err = avail.NamespaceAvailable(timeoutCtx, eh, present)
+	require.ErrorIs(t, err, share.ErrNotAvailable)
+}
+
+func TestNamespaceAbsent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const squareSize = 8
+	getter, bServ := EmptyGetter()
+	randShares := sharetest.RandShares(t, squareSize*squareSize)
+	present := share.GetNamespace(randShares[0])
+	root := availability_test.FillBS(t, bServ, randShares)
+	eh := headertest.RandExtendedHeader(t)
+	eh.DAH = root
+	avail := TestAvailability(getter)
+
+	// the square lacks the namespace
+	absentNs := sharetest.RandV0Namespace()
+	absent, proof, err := avail.NamespaceAbsent(ctx, eh, absentNs)
+	require.NoError(t, err)
+	assert.True(t, absent)
+	// the proof verifies against the roots and holds no shares
+	require.NoError(t, proof.Verify(eh.DAH, absentNs))
+	assert.Empty(t, proof.Flatten())
+
+	absent, proof, err = avail.NamespaceAbsent(ctx, eh, present)
+	require.NoError(t, err)
+	assert.False(t, absent)
+	require.NoError(t, proof.Verify(eh.DAH, present))
+	assert.NotEmpty(t, proof.Flatten())
+
+	// the verdict can't be determined without the rows of the namespace
+	emptyGetter, _ := EmptyGetter()
+	avail = TestAvailability(emptyGetter)
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer timeoutCancel()
+	absent, proof, err = avail.NamespaceAbsent(timeoutCtx, eh, present)
+	require.ErrorIs(t, err, share.ErrNotAvailable)
+	assert.False(t, absent)
+	assert.Nil(t, proof)
+}
+
 func TestService_GetSharesByNamespaceNotFound(t *testing.T) {
 	getter, eh := GetterWithRandSquare(t, 1)
 	eh.DAH.RowRoots = nil
@@ -241,3 +613,30 @@ func BenchmarkService_GetSharesByNamespace(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkSharesAvailable_Batched(b *testing.B) {
+	t := &testing.T{}
+	getter, eh := GetterWithRandSquare(t, 16)
+
+	var tests = []struct {
+		name   string
+		getter share.Getter
+	}{
+		{name: "batched", getter: getter},
+		// hiding GetSamples makes availability request shares per coordinate
+		{name: "per-coordinate", getter: struct{ share.Getter }{getter}},
+	}
+
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				// availability caches the results, so it is recreated on every iteration
+				b.StopTimer()
+				avail := NewShareAvailability(tt.getter, datastore.NewMapDatastore(), WithProofCacheSize(0))
+				b.StartTimer()
+				err := avail.SharesAvailable(context.Background(), eh)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
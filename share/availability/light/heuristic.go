@@ -0,0 +1,140 @@
+package light
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+// heuristicPrefix keeps roots verified in HeuristicMode apart from the ones verified by sampling.
+var heuristicPrefix = datastore.NewKey("heuristic")
+
+// Mode defines how availability of the extended square is verified.
+type Mode int
+
+const (
+	// DASMode verifies availability by sampling shares at random coordinates of the extended
+	// square, upholding the DAS security argument. It is the default mode.
+	DASMode Mode = iota
+	// HeuristicMode only checks that every row of the extended square serves a share, by fetching
+	// a fixed set of shares on the diagonal of the square, so every row is sampled exactly once.
+	// The coordinates are predictable, so a withholding attacker can easily serve exactly these
+	// shares. It gives no DAS confidence and is meant only for ultra-light clients accepting
+	// the weaker heuristic. Squares verified this way are reported with VerdictHeuristicAvailable.
+	HeuristicMode
+)
+
+// String returns the name of the Mode.
+func (m Mode) String() string {
+	switch m {
+	case DASMode:
+		return "das"
+	case HeuristicMode:
+		return "heuristic"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(m))
+	}
+}
+
+// Verdict describes the confidence availability of the extended square was verified with.
+type Verdict int
+
+const (
+	// VerdictUnknown means availability of the square was not verified.
+	VerdictUnknown Verdict = iota
+	// VerdictAvailable means availability of the square was verified by data availability sampling.
+	VerdictAvailable
+	// VerdictHeuristicAvailable means every row of the square served a share in HeuristicMode. It
+	// gives no DAS confidence.
+	VerdictHeuristicAvailable
+)
+
+// String returns the name of the Verdict.
+func (v Verdict) String() string {
+	switch v {
+	case VerdictUnknown:
+		return "unknown"
+	case VerdictAvailable:
+		return "available"
+	case VerdictHeuristicAvailable:
+		return "heuristic-available"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(v))
+	}
+}
+
+// Verdict returns the confidence availability of data committed to the root was verified with.
+// Squares verified by sampling are reported with VerdictAvailable, even if they were verified in
+// HeuristicMode as well.
+func (la *ShareAvailability) Verdict(ctx context.Context, root *share.Root) (Verdict, error) {
+	if la.trivialAvailable(root) {
+		return VerdictAvailable, nil
+	}
+
+	la.dsLk.RLock()
+	defer la.dsLk.RUnlock()
+	sampled, err := la.ds.Has(ctx, rootKey(root))
+	if err != nil || sampled {
+		return VerdictAvailable, err
+	}
+	checked, err := la.ds.Has(ctx, heuristicRootKey(root))
+	if err != nil || !checked {
+		return VerdictUnknown, err
+	}
+	return VerdictHeuristicAvailable, nil
+}
+
+// heuristicAvailable verifies availability of the square in HeuristicMode, unless it was verified
+// before, and returns the verdict for the square.
+func (la *ShareAvailability) heuristicAvailable(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+) (Verdict, error) {
+	verdict, err := la.Verdict(ctx, header.DAH)
+	if err != nil || verdict != VerdictUnknown {
+		return verdict, err
+	}
+	if err = la.verifyHeuristic(ctx, header); err != nil {
+		return VerdictUnknown, err
+	}
+	return VerdictHeuristicAvailable, nil
+}
+
+// verifyHeuristic fetches a share of every row of the square.
+func (la *ShareAvailability) verifyHeuristic(ctx context.Context, header *header.ExtendedHeader) error {
+	dah := header.DAH
+	if err := dah.ValidateBasic(); err != nil {
+		return fmt.Errorf("light availability: malformed root: %w", err)
+	}
+
+	log.Debugw("validate availability heuristically", "root", dah.String())
+	if err := la.fetchSamples(ctx, header, heuristicSamples(len(dah.RowRoots))); err != nil {
+		return err
+	}
+
+	la.dsLk.Lock()
+	err := la.ds.Put(ctx, heuristicRootKey(dah), []byte{})
+	la.dsLk.Unlock()
+	if err != nil {
+		log.Errorw("storing root of successful heuristic availability check to disk", "err", err)
+	}
+	return nil
+}
+
+// heuristicSamples returns the samples on the diagonal of the square of the given width, covering
+// every row and column exactly once.
+func heuristicSamples(width int) []Sample {
+	samples := make([]Sample, width)
+	for i := range samples {
+		samples[i] = Sample{Row: i, Col: i}
+	}
+	return samples
+}
+
+func heuristicRootKey(root *share.Root) datastore.Key {
+	return heuristicPrefix.Child(rootKey(root))
+}
@@ -2,6 +2,7 @@ package light
 
 import (
 	"fmt"
+	"hash"
 )
 
 // SampleAmount specifies the minimum required amount of samples a light node must perform
@@ -10,10 +11,89 @@ var (
 	DefaultSampleAmount uint = 16
 )
 
+// DefaultProofCacheSize is the default amount of rows sampled shares with proofs are cached for.
+const DefaultProofCacheSize = 4096
+
+// SampleRegion defines the quadrants of the extended square that sample coordinates are drawn
+// from.
+type SampleRegion int
+
+const (
+	// RegionFull draws coordinates from the whole extended square.
+	RegionFull SampleRegion = iota
+	// RegionParityOnly draws coordinates only from the extended (parity) quadrants.
+	RegionParityOnly
+	// RegionOriginalOnly draws coordinates only from the original data quadrant.
+	RegionOriginalOnly
+)
+
+// String returns the name of the SampleRegion.
+func (r SampleRegion) String() string {
+	switch r {
+	case RegionFull:
+		return "full"
+	case RegionParityOnly:
+		return "parity-only"
+	case RegionOriginalOnly:
+		return "original-only"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(r))
+	}
+}
+
 // Parameters is the set of Parameters that must be configured for the light
 // availability implementation
 type Parameters struct {
 	SampleAmount uint // The minimum required amount of samples to perform
+
+	// Mode defines how availability is verified. Only DASMode upholds the DAS security argument.
+	Mode Mode
+
+	// SampleRegion restricts the quadrants of the extended square samples are drawn from.
+	// Only RegionFull upholds the DAS security argument: a withholding attacker can hide
+	// an unrecoverable portion of the square almost entirely inside the quadrants excluded
+	// by other regions, making detection far less likely for the same amount of samples.
+	SampleRegion SampleRegion
+
+	// SampleCountFunc returns the amount of samples to perform for the extended square of the given
+	// width. If not set, DefaultSampleCount is used.
+	SampleCountFunc func(squareWidth int) int `toml:"-"`
+
+	// ProofCacheSize is the amount of rows sampled shares with their proofs are cached for, so
+	// availability can be verified again without fetching them. Caching requires the share.Getter to
+	// implement ProofGetter. If set to 0, proofs are not cached.
+	ProofCacheSize int
+
+	// NMTHasher creates hashers of nmt nodes used to verify sampled shares and namespaces against
+	// the roots. Hashes must have the size of sha256 hashes. If set, shares are sampled with proofs,
+	// which requires the share.Getter to implement ProofGetter. If not set, share.DefaultNMTHasher
+	// is used.
+	NMTHasher func() hash.Hash `toml:"-"`
+
+	// CoordSampler selects coordinates of samples within the extended square. Selected coordinates
+	// are validated to be distinct and of the expected amount. Only uniformly distributed samples
+	// uphold the DAS security argument. If not set, UniformSampler is used.
+	CoordSampler CoordSampler `toml:"-"`
+
+	// AxisCoverage selects coordinates of samples so every row and column of the extended square is
+	// sampled at least once, if the amount of samples allows it. The remaining samples are picked
+	// uniformly at random. It is ignored if CoordSampler is set.
+	AxisCoverage bool
+
+	// PaddingPolicy defines how squares with the original data consisting of tail padding only are
+	// handled. See PaddingPolicy.
+	PaddingPolicy PaddingPolicy
+}
+
+// DefaultSampleCount scales the amount of samples with the width of the extended square, as larger
+// squares require more samples for the same confidence. Starting from the width of 32, every
+// doubling of the width adds a quarter of sampleAmount, up to twice the sampleAmount.
+func DefaultSampleCount(sampleAmount uint, squareWidth int) int {
+	count, step := int(sampleAmount), max(int(sampleAmount)/4, 1)
+	for width := 32; width <= squareWidth && count < 2*int(sampleAmount); width *= 2 {
+		count += step
+	}
+	return min(count, 2*int(sampleAmount))
 }
 
 // Option is a function that configures light availability Parameters
@@ -23,7 +103,10 @@ type Option func(*Parameters)
 // for the light availability implementation
 func DefaultParameters() Parameters {
 	return Parameters{
-		SampleAmount: DefaultSampleAmount,
+		SampleAmount:   DefaultSampleAmount,
+		Mode:           DASMode,
+		SampleRegion:   RegionFull,
+		ProofCacheSize: DefaultProofCacheSize,
 	}
 }
 
@@ -38,6 +121,48 @@ func (p *Parameters) Validate() error {
 		)
 	}
 
+	switch p.Mode {
+	case DASMode, HeuristicMode:
+	default:
+		return fmt.Errorf(
+			"light availability: invalid option: value %s was %s, where it should be %s",
+			"Mode",
+			p.Mode.String(),
+			"one of das or heuristic",
+		)
+	}
+
+	switch p.SampleRegion {
+	case RegionFull, RegionParityOnly, RegionOriginalOnly:
+	default:
+		return fmt.Errorf(
+			"light availability: invalid option: value %s was %s, where it should be %s",
+			"SampleRegion",
+			p.SampleRegion.String(),
+			"one of full, parity-only or original-only",
+		)
+	}
+
+	switch p.PaddingPolicy {
+	case PaddingSample, PaddingTrivial:
+	default:
+		return fmt.Errorf(
+			"light availability: invalid option: value %s was %s, where it should be %s",
+			"PaddingPolicy",
+			p.PaddingPolicy.String(),
+			"one of sample or trivial",
+		)
+	}
+
+	if p.ProofCacheSize < 0 {
+		return fmt.Errorf(
+			"light availability: invalid option: value %s was %s, where it should be %s",
+			"ProofCacheSize",
+			"< 0",  // current value
+			">= 0", // what the value should be
+		)
+	}
+
 	return nil
 }
 
@@ -48,3 +173,67 @@ func WithSampleAmount(sampleAmount uint) Option {
 		p.SampleAmount = sampleAmount
 	}
 }
+
+// WithMode is a functional option that the Availability interface
+// implementers use to set the Mode configuration param
+func WithMode(mode Mode) Option {
+	return func(p *Parameters) {
+		p.Mode = mode
+	}
+}
+
+// WithSampleRegion is a functional option that the Availability interface
+// implementers use to set the SampleRegion configuration param
+func WithSampleRegion(region SampleRegion) Option {
+	return func(p *Parameters) {
+		p.SampleRegion = region
+	}
+}
+
+// WithSampleCountFunc is a functional option that the Availability interface
+// implementers use to set the SampleCountFunc configuration param
+func WithSampleCountFunc(fn func(squareWidth int) int) Option {
+	return func(p *Parameters) {
+		p.SampleCountFunc = fn
+	}
+}
+
+// WithNMTHasher is a functional option that the Availability interface
+// implementers use to set the NMTHasher configuration param
+func WithNMTHasher(hasher func() hash.Hash) Option {
+	return func(p *Parameters) {
+		p.NMTHasher = hasher
+	}
+}
+
+// WithProofCacheSize is a functional option that the Availability interface
+// implementers use to set the ProofCacheSize configuration param
+func WithProofCacheSize(size int) Option {
+	return func(p *Parameters) {
+		p.ProofCacheSize = size
+	}
+}
+
+// WithCoordSampler is a functional option that the Availability interface
+// implementers use to set the CoordSampler configuration param
+func WithCoordSampler(sampler CoordSampler) Option {
+	return func(p *Parameters) {
+		p.CoordSampler = sampler
+	}
+}
+
+// WithAxisCoverage is a functional option that the Availability interface
+// implementers use to set the AxisCoverage configuration param
+func WithAxisCoverage(enabled bool) Option {
+	return func(p *Parameters) {
+		p.AxisCoverage = enabled
+	}
+}
+
+// WithPaddingPolicy is a functional option that the Availability interface
+// implementers use to set the PaddingPolicy configuration param
+func WithPaddingPolicy(policy PaddingPolicy) Option {
+	return func(p *Parameters) {
+		p.PaddingPolicy = policy
+	}
+}
@@ -0,0 +1,90 @@
+package light
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/celestiaorg/celestia-app/pkg/da"
+	"github.com/celestiaorg/celestia-app/pkg/shares"
+
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+// PaddingPolicy defines how squares are handled whose original data consists of tail padding only,
+// so their parity quadrants are extended from padding as well. The square of the empty block is
+// always considered available, regardless of the policy.
+type PaddingPolicy int
+
+const (
+	// PaddingSample samples padding squares like any other square. It is the default policy.
+	PaddingSample PaddingPolicy = iota
+	// PaddingTrivial considers padding squares available without sampling. Every share of such a
+	// square is determined by its width, so the data committed to its root can always be
+	// reconstructed without fetching anything from the network.
+	PaddingTrivial
+)
+
+// String returns the name of the PaddingPolicy.
+func (p PaddingPolicy) String() string {
+	switch p {
+	case PaddingSample:
+		return "sample"
+	case PaddingTrivial:
+		return "trivial"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(p))
+	}
+}
+
+var (
+	paddingRootsLk sync.Mutex
+	// paddingRoots caches hashes of roots of padding squares by the width of the extended square
+	paddingRoots = make(map[int]share.DataHash)
+)
+
+// trivialAvailable checks whether data committed to the root is available without sampling, i.e.
+// it is the root of the empty block, or of a padding square with PaddingTrivial.
+func (la *ShareAvailability) trivialAvailable(root *share.Root) bool {
+	hash := share.DataHash(root.Hash())
+	if hash.IsEmptyRoot() {
+		return true
+	}
+	return la.params.PaddingPolicy == PaddingTrivial && isPaddingRoot(root)
+}
+
+// isPaddingRoot checks whether the root commits to the extended square of the same width with the
+// original data consisting of tail padding only.
+func isPaddingRoot(root *share.Root) bool {
+	// the width is bound by validation, limiting the size of the square computed for it
+	if err := root.ValidateBasic(); err != nil {
+		return false
+	}
+	expected, err := paddingRoot(len(root.RowRoots))
+	if err != nil {
+		log.Errorw("computing root of padding square", "width", len(root.RowRoots), "err", err)
+		return false
+	}
+	return bytes.Equal(expected, root.Hash())
+}
+
+// paddingRoot returns the hash of the root of the padding square of the given extended width.
+func paddingRoot(width int) (share.DataHash, error) {
+	paddingRootsLk.Lock()
+	defer paddingRootsLk.Unlock()
+	if hash, ok := paddingRoots[width]; ok {
+		return hash, nil
+	}
+
+	odsWidth := width / 2
+	eds, err := da.ExtendShares(shares.ToBytes(shares.TailPaddingShares(odsWidth * odsWidth)))
+	if err != nil {
+		return nil, err
+	}
+	root, err := share.NewRoot(eds)
+	if err != nil {
+		return nil, err
+	}
+	paddingRoots[width] = root.Hash()
+	return paddingRoots[width], nil
+}
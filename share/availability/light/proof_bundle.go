@@ -0,0 +1,121 @@
+package light
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/celestiaorg/celestia-node/share/eds/byzantine"
+	"github.com/celestiaorg/celestia-node/share/ipld"
+)
+
+var (
+	// ErrInvalidProofBundle is returned when any share of the ProofBundle fails verification against
+	// the header roots.
+	ErrInvalidProofBundle = errors.New("light: invalid proof bundle")
+	// ErrInsufficientProofBundle is returned when the ProofBundle does not contain enough valid
+	// shares to consider the data available.
+	ErrInsufficientProofBundle = errors.New("light: insufficient proof bundle")
+)
+
+// ProofBundle is a set of shares of the extended square with Merkle proofs, proactively provided
+// by peers to prove availability of the data.
+type ProofBundle struct {
+	Shares []SampleProof
+}
+
+// SampleProof is a share at the Sample coordinates with its Merkle proof against the row root.
+type SampleProof struct {
+	Sample
+	Share *byzantine.ShareWithProof
+}
+
+// VerifyProofBundle verifies shares of the given ProofBundle against the roots of the given
+// ExtendedHeader and, if the bundle covers every sample drawn locally at random the same way
+// SharesAvailable does, marks the data as available without fetching anything from the network.
+// Subsequent SharesAvailable calls for the same root hit the cache.
+//
+// NOTE: Coordinates chosen by the peer providing the bundle don't count on their own, as the peer
+// could otherwise prove a few shares it kept while withholding the rest of the square. In practice,
+// the bundle has to prove a large part of the square to cover the local samples.
+func (la *ShareAvailability) VerifyProofBundle(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+	bundle ProofBundle,
+) error {
+	if header == nil || header.DAH == nil {
+		return fmt.Errorf("%w: missing root", ErrInvalidProofBundle)
+	}
+	dah := header.DAH
+	if share.DataHash(dah.Hash()).IsEmptyRoot() {
+		return nil
+	}
+
+	key := rootKey(dah)
+	la.dsLk.RLock()
+	exists, err := la.ds.Has(ctx, key)
+	la.dsLk.RUnlock()
+	if err != nil || exists {
+		return err
+	}
+
+	if err := dah.ValidateBasic(); err != nil {
+		return fmt.Errorf("%w: malformed root: %w", ErrInvalidProofBundle, err)
+	}
+
+	width := len(dah.RowRoots)
+	verified := make(map[Sample]struct{}, len(bundle.Shares))
+	for _, sp := range bundle.Shares {
+		if err := verifySampleProof(dah, sp, la.nmtHasher()); err != nil {
+			log.Warnw("invalid proof bundle", "root", dah.String(), "row", sp.Row, "col", sp.Col, "err", err)
+			return fmt.Errorf("%w: row %d, col %d: %w", ErrInvalidProofBundle, sp.Row, sp.Col, err)
+		}
+		verified[sp.Sample] = struct{}{}
+	}
+
+	// only samples drawn locally at random prove availability, exactly as in SharesAvailable
+	samples, err := la.sampleSquare(width, la.sampleCount(width))
+	if err != nil {
+		return err
+	}
+	var missing int
+	for _, s := range samples {
+		if _, ok := verified[s]; !ok {
+			missing++
+		}
+	}
+	if missing > 0 {
+		return fmt.Errorf("%w: %d of %d samples are not covered", ErrInsufficientProofBundle, missing, len(samples))
+	}
+
+	la.dsLk.Lock()
+	err = la.ds.Put(ctx, key, []byte{})
+	la.dsLk.Unlock()
+	if err != nil {
+		log.Errorw("storing root of verified proof bundle to disk", "err", err)
+	}
+	return nil
+}
+
+// verifySampleProof checks that the share is included at the Sample coordinates under the row
+// root, hashing nmt nodes with a hasher created by newHasher.
+func verifySampleProof(dah *share.Root, sp SampleProof, newHasher func() hash.Hash) error {
+	width := len(dah.RowRoots)
+	if sp.Row < 0 || sp.Row >= width || sp.Col < 0 || sp.Col >= width {
+		return errors.New("coordinates are out of square bounds")
+	}
+	if sp.Share == nil || sp.Share.Proof == nil {
+		return errors.New("missing share or proof")
+	}
+	if sp.Share.Proof.Start() != sp.Col || sp.Share.Proof.End() != sp.Col+1 {
+		return errors.New("proof does not match share coordinates")
+	}
+	root := ipld.MustCidFromNamespacedSha256(dah.RowRoots[sp.Row])
+	if !sp.Share.ValidateWithHasher(root, newHasher()) {
+		return errors.New("share is not included under the row root")
+	}
+	return nil
+}
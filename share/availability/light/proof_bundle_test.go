@@ -0,0 +1,197 @@
+package light
+
+import (
+	"context"
+	"crypto/sha512"
+	"testing"
+	"time"
+
+	"github.com/ipfs/boxo/blockservice"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/celestia-app/pkg/wrapper"
+	"github.com/celestiaorg/rsmt2d"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/header/headertest"
+	"github.com/celestiaorg/celestia-node/share"
+	availability_test "github.com/celestiaorg/celestia-node/share/availability/test"
+	"github.com/celestiaorg/celestia-node/share/eds/byzantine"
+	"github.com/celestiaorg/celestia-node/share/ipld"
+	"github.com/celestiaorg/celestia-node/share/sharetest"
+)
+
+func TestVerifyProofBundle(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	bServ := ipld.NewMemBlockservice()
+	dah := availability_test.RandFillBS(t, 16, bServ)
+	eh := headertest.RandExtendedHeaderWithRoot(t, dah)
+
+	width := len(dah.RowRoots)
+	samples, err := SampleSquare(width, DefaultSampleCount(DefaultSampleAmount, width))
+	require.NoError(t, err)
+	bundle := buildProofBundle(ctx, t, bServ, dah, samples)
+
+	all := make([]Sample, 0, width*width)
+	for row := 0; row < width; row++ {
+		for col := 0; col < width; col++ {
+			all = append(all, Sample{Row: row, Col: col})
+		}
+	}
+	full := buildProofBundle(ctx, t, bServ, dah, all)
+
+	t.Run("valid", func(t *testing.T) {
+		// getter has no data, so availability can only come from the bundle
+		getter, _ := EmptyGetter()
+		avail := TestAvailability(getter)
+
+		err := avail.VerifyProofBundle(ctx, eh, full)
+		require.NoError(t, err)
+
+		has, err := avail.ds.Has(ctx, rootKey(dah))
+		require.NoError(t, err)
+		require.True(t, has)
+		require.NoError(t, avail.SharesAvailable(ctx, eh))
+	})
+
+	t.Run("peer chosen coordinates", func(t *testing.T) {
+		getter, _ := EmptyGetter()
+		avail := TestAvailability(getter)
+
+		// enough valid shares, but at coordinates not drawn locally
+		err := avail.VerifyProofBundle(ctx, eh, bundle)
+		require.ErrorIs(t, err, ErrInsufficientProofBundle)
+
+		has, err := avail.ds.Has(ctx, rootKey(dah))
+		require.NoError(t, err)
+		require.False(t, has)
+	})
+
+	t.Run("missing root", func(t *testing.T) {
+		getter, _ := EmptyGetter()
+		avail := TestAvailability(getter)
+
+		err := avail.VerifyProofBundle(ctx, &header.ExtendedHeader{}, full)
+		require.ErrorIs(t, err, ErrInvalidProofBundle)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		getter, _ := EmptyGetter()
+		avail := TestAvailability(getter)
+
+		corrupted := ProofBundle{Shares: make([]SampleProof, len(bundle.Shares))}
+		copy(corrupted.Shares, bundle.Shares)
+		data := make([]byte, len(corrupted.Shares[0].Share.Share))
+		copy(data, corrupted.Shares[0].Share.Share)
+		data[len(data)-1] ^= 0xFF
+		corrupted.Shares[0].Share = &byzantine.ShareWithProof{Share: data, Proof: corrupted.Shares[0].Share.Proof}
+
+		err := avail.VerifyProofBundle(ctx, eh, corrupted)
+		require.ErrorIs(t, err, ErrInvalidProofBundle)
+
+		// share proven for other coordinates
+		misplaced := ProofBundle{Shares: []SampleProof{bundle.Shares[0]}}
+		misplaced.Shares[0].Col = (misplaced.Shares[0].Col + 1) % len(dah.RowRoots)
+		err = avail.VerifyProofBundle(ctx, eh, misplaced)
+		require.ErrorIs(t, err, ErrInvalidProofBundle)
+
+		has, err := avail.ds.Has(ctx, rootKey(dah))
+		require.NoError(t, err)
+		require.False(t, has)
+	})
+
+	t.Run("insufficient", func(t *testing.T) {
+		getter, _ := EmptyGetter()
+		avail := TestAvailability(getter)
+
+		// duplicates are counted once
+		partial := ProofBundle{Shares: []SampleProof{bundle.Shares[0], bundle.Shares[0]}}
+		err := avail.VerifyProofBundle(ctx, eh, partial)
+		require.ErrorIs(t, err, ErrInsufficientProofBundle)
+	})
+}
+
+func TestVerifyProofBundle_NMTHasher(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	const size = 4
+	newHasher := sha512.New512_256
+	shares := sharetest.RandShares(t, size*size)
+	eds, err := rsmt2d.ComputeExtendedDataSquare(shares, share.DefaultRSMT2DCodec(),
+		share.NewNMTConstructor(size, newHasher))
+	require.NoError(t, err)
+	dah, err := share.NewRoot(eds)
+	require.NoError(t, err)
+
+	// roots are recomputed with the same hasher
+	root, err := share.NewRootFromSharesWithHasher(shares, size, newHasher)
+	require.NoError(t, err)
+	require.True(t, root.Equals(dah))
+	root, err = share.NewRootFromShares(shares, size)
+	require.NoError(t, err)
+	require.False(t, root.Equals(dah))
+
+	// prove every share of the extended square
+	var bundle ProofBundle
+	for row := 0; row < 2*size; row++ {
+		tree := share.NewNMTConstructor(size, newHasher)(rsmt2d.Row, uint(row))
+		for _, shr := range eds.Row(uint(row)) {
+			require.NoError(t, tree.Push(shr))
+		}
+		for col := 0; col < 2*size; col++ {
+			proof, err := tree.(*wrapper.ErasuredNamespacedMerkleTree).ProveRange(col, col+1)
+			require.NoError(t, err)
+			// leaves of the original quadrant are prefixed with the namespace of the share, and
+			// parity leaves with the parity namespace
+			shr := eds.GetCell(uint(row), uint(col))
+			ns := share.ParitySharesNamespace
+			if row < size && col < size {
+				ns = share.GetNamespace(shr)
+			}
+			leaf := make([]byte, 0, share.NamespaceSize+len(shr))
+			leaf = append(leaf, ns...)
+			leaf = append(leaf, shr...)
+			bundle.Shares = append(bundle.Shares, SampleProof{
+				Sample: Sample{Row: row, Col: col},
+				Share:  &byzantine.ShareWithProof{Share: leaf, Proof: &proof},
+			})
+		}
+	}
+	eh := headertest.RandExtendedHeaderWithRoot(t, dah)
+
+	getter, _ := EmptyGetter()
+	avail := NewShareAvailability(getter, datastore.NewMapDatastore(), WithNMTHasher(newHasher))
+	require.NoError(t, avail.VerifyProofBundle(ctx, eh, bundle))
+
+	// proofs don't verify with the default hasher
+	avail = TestAvailability(getter)
+	err = avail.VerifyProofBundle(ctx, eh, bundle)
+	require.ErrorIs(t, err, ErrInvalidProofBundle)
+}
+
+func buildProofBundle(
+	ctx context.Context,
+	t *testing.T,
+	bServ blockservice.BlockService,
+	dah *share.Root,
+	samples []Sample,
+) ProofBundle {
+	width := len(dah.RowRoots)
+	bundle := ProofBundle{Shares: make([]SampleProof, 0, len(samples))}
+	for _, s := range samples {
+		root := ipld.MustCidFromNamespacedSha256(dah.RowRoots[s.Row])
+		leaf, err := ipld.GetLeaf(ctx, bServ, root, s.Col, width)
+		require.NoError(t, err)
+		proof, err := ipld.GetProof(ctx, bServ, root, nil, s.Col, width)
+		require.NoError(t, err)
+		bundle.Shares = append(bundle.Shares, SampleProof{
+			Sample: s,
+			Share:  byzantine.NewShareWithProof(s.Col, leaf.RawData(), proof),
+		})
+	}
+	return bundle
+}
@@ -0,0 +1,63 @@
+package light
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/celestiaorg/celestia-node/share/eds/byzantine"
+)
+
+// ProofGetter is implemented by share.Getters able to retrieve shares together with their Merkle
+// proofs against the row root. Samples retrieved with proofs are cached, so availability can be
+// verified again without fetching them. See ShareAvailability.ReverifyAvailable.
+type ProofGetter interface {
+	GetShareWithProof(ctx context.Context, header *header.ExtendedHeader, row, col int) (*byzantine.ShareWithProof, error)
+}
+
+// proofCache keeps sampled shares with proofs keyed by data root and row, evicting the least
+// recently used rows.
+type proofCache struct {
+	// lock serializes updates of cached rows
+	lock  sync.Mutex
+	cache *lru.Cache[string, []SampleProof]
+}
+
+func newProofCache(size int) (*proofCache, error) {
+	cache, err := lru.New[string, []SampleProof](size)
+	if err != nil {
+		return nil, err
+	}
+	return &proofCache{cache: cache}, nil
+}
+
+// add caches the sampled share with proof.
+func (pc *proofCache) add(dah *share.Root, sp SampleProof) {
+	key := proofCacheKey(dah, sp.Row)
+
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
+	cached, _ := pc.cache.Peek(key)
+	row := make([]SampleProof, 0, len(cached)+1)
+	row = append(append(row, cached...), sp)
+	pc.cache.Add(key, row)
+}
+
+// get returns all cached shares with proofs of the square.
+func (pc *proofCache) get(dah *share.Root) []SampleProof {
+	var proofs []SampleProof
+	for row := range dah.RowRoots {
+		if cached, ok := pc.cache.Get(proofCacheKey(dah, row)); ok {
+			proofs = append(proofs, cached...)
+		}
+	}
+	return proofs
+}
+
+func proofCacheKey(dah *share.Root, row int) string {
+	return dah.String() + "/" + strconv.Itoa(row)
+}
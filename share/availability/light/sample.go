@@ -3,18 +3,63 @@ package light
 
 import (
 	crand "crypto/rand"
+	"errors"
+	"fmt"
 	"math/big"
 )
 
+// errInvalidSamples is returned if the selected samples are not a set of distinct points of the
+// expected size within the sampled region, which indicates a bug in sample selection.
+var errInvalidSamples = errors.New("light availability: invalid sample selection")
+
 // Sample is a point in 2D space over square.
 type Sample struct {
 	Row, Col int
 }
 
+// CoordSampler selects coordinates of samples within the extended square, e.g. to experiment with
+// non-uniform distributions of samples.
+type CoordSampler interface {
+	// Sample returns *num* distinct coordinates within the region of the extended square of the
+	// given *width*. If the region is smaller than requested, the amount is limited by the width.
+	Sample(squareWidth int, num int, region SampleRegion) ([]Sample, error)
+}
+
+// CoordSamplerFunc is an adapter to use ordinary functions as CoordSampler.
+type CoordSamplerFunc func(squareWidth int, num int, region SampleRegion) ([]Sample, error)
+
+// Sample calls f(squareWidth, num, region).
+func (f CoordSamplerFunc) Sample(squareWidth int, num int, region SampleRegion) ([]Sample, error) {
+	return f(squareWidth, num, region)
+}
+
+// UniformSampler is the default CoordSampler picking coordinates uniformly at random.
+type UniformSampler struct{}
+
+// Sample randomly picks *num* unique points within the region. See SampleSquareRegion.
+func (UniformSampler) Sample(squareWidth int, num int, region SampleRegion) ([]Sample, error) {
+	return SampleSquareRegion(squareWidth, num, region)
+}
+
+// AxisSampler is a CoordSampler covering every row and column of the square with samples, if the
+// amount of samples allows it, and picking the remaining ones uniformly at random.
+type AxisSampler struct{}
+
+// Sample picks *num* unique points within the region covering its axes. See SampleSquareAxes.
+func (AxisSampler) Sample(squareWidth int, num int, region SampleRegion) ([]Sample, error) {
+	return SampleSquareAxes(squareWidth, num, region)
+}
+
 // SampleSquare randomly picks *num* unique points from the given *width* square
 // and returns them as samples.
 func SampleSquare(squareWidth int, num int) ([]Sample, error) {
-	ss := newSquareSampler(squareWidth, num)
+	return SampleSquareRegion(squareWidth, num, RegionFull)
+}
+
+// SampleSquareRegion randomly picks *num* unique points within the given region of the *width*
+// extended square and returns them as samples.
+func SampleSquareRegion(squareWidth int, num int, region SampleRegion) ([]Sample, error) {
+	ss := newSquareSampler(squareWidth, num, region)
 	err := ss.generateSample(num)
 	if err != nil {
 		return nil, err
@@ -22,23 +67,38 @@ func SampleSquare(squareWidth int, num int) ([]Sample, error) {
 	return ss.samples(), nil
 }
 
+// SampleSquareAxes picks *num* unique points within the given region of the *width* extended
+// square, so that every row and column touching the region is sampled at least once. If *num* is
+// too small to cover all of them, as many as possible are covered. The remaining samples are
+// picked uniformly at random.
+func SampleSquareAxes(squareWidth int, num int, region SampleRegion) ([]Sample, error) {
+	num = sampleAmount(squareWidth, num, region)
+	ss := newSquareSampler(squareWidth, num, region)
+	ss.coverAxes(num)
+	err := ss.generateSample(num - len(ss.smpls))
+	if err != nil {
+		return nil, err
+	}
+	return ss.samples(), nil
+}
+
 type squareSampler struct {
 	squareWidth int
+	region      SampleRegion
 	smpls       map[Sample]struct{}
 }
 
-func newSquareSampler(squareWidth int, expectedSamples int) *squareSampler {
+func newSquareSampler(squareWidth int, expectedSamples int, region SampleRegion) *squareSampler {
 	return &squareSampler{
 		squareWidth: squareWidth,
+		region:      region,
 		smpls:       make(map[Sample]struct{}, expectedSamples),
 	}
 }
 
 // generateSample randomly picks unique point on a 2D spaces.
 func (ss *squareSampler) generateSample(num int) error {
-	if num > ss.squareWidth*ss.squareWidth {
-		num = ss.squareWidth
-	}
+	num = sampleAmount(ss.squareWidth, num, ss.region)
 
 	done := 0
 	for done < num {
@@ -47,6 +107,10 @@ func (ss *squareSampler) generateSample(num int) error {
 			Col: randInt(ss.squareWidth),
 		}
 
+		if !ss.inRegion(s) {
+			continue
+		}
+
 		if _, ok := ss.smpls[s]; ok {
 			continue
 		}
@@ -58,6 +122,124 @@ func (ss *squareSampler) generateSample(num int) error {
 	return nil
 }
 
+// coverAxes picks up to *num* unique points, so every row and column touching the region is
+// sampled. Rows are visited in random order, each taking an uncovered column if the region allows
+// it, and the columns left uncovered then take a random row.
+func (ss *squareSampler) coverAxes(num int) {
+	cols := make(map[int]struct{}, ss.squareWidth)
+	for _, row := range randPerm(ss.squareWidth) {
+		if len(ss.smpls) == num {
+			return
+		}
+		s, ok := ss.pick(randPerm(ss.squareWidth), func(col int) Sample {
+			return Sample{Row: row, Col: col}
+		}, func(s Sample) bool {
+			_, covered := cols[s.Col]
+			return !covered
+		})
+		if ok {
+			ss.smpls[s] = struct{}{}
+			cols[s.Col] = struct{}{}
+		}
+	}
+
+	for _, col := range randPerm(ss.squareWidth) {
+		if len(ss.smpls) == num {
+			return
+		}
+		if _, ok := cols[col]; ok {
+			continue
+		}
+		s, ok := ss.pick(randPerm(ss.squareWidth), func(row int) Sample {
+			return Sample{Row: row, Col: col}
+		}, nil)
+		if ok {
+			ss.smpls[s] = struct{}{}
+			cols[col] = struct{}{}
+		}
+	}
+}
+
+// pick returns the first new point within the region built from the candidates, preferring the
+// ones accepted by *prefer*.
+func (ss *squareSampler) pick(candidates []int, point func(int) Sample, prefer func(Sample) bool) (Sample, bool) {
+	var fallback *Sample
+	for _, c := range candidates {
+		s := point(c)
+		if _, ok := ss.smpls[s]; ok || !ss.inRegion(s) {
+			continue
+		}
+		if prefer == nil || prefer(s) {
+			return s, true
+		}
+		if fallback == nil {
+			fallback = &s
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return Sample{}, false
+}
+
+// sampleAmount returns the amount of samples picked for the requested amount within the region of
+// the square. If the region is smaller than requested, the amount is limited by the square width.
+func sampleAmount(squareWidth int, num int, region SampleRegion) int {
+	ss := squareSampler{squareWidth: squareWidth, region: region}
+	if size := ss.regionSize(); num > size {
+		return min(squareWidth, size)
+	}
+	return num
+}
+
+// validateSamples checks that the samples are distinct points within the region of the square and
+// that their amount matches the requested one.
+func validateSamples(samples []Sample, squareWidth int, num int, region SampleRegion) error {
+	if expected := sampleAmount(squareWidth, num, region); len(samples) != expected {
+		return fmt.Errorf("%w: got %d samples, expected %d", errInvalidSamples, len(samples), expected)
+	}
+
+	ss := squareSampler{squareWidth: squareWidth, region: region}
+	seen := make(map[Sample]struct{}, len(samples))
+	for _, s := range samples {
+		if s.Row < 0 || s.Row >= squareWidth || s.Col < 0 || s.Col >= squareWidth || !ss.inRegion(s) {
+			return fmt.Errorf("%w: sample %v is outside of the region", errInvalidSamples, s)
+		}
+		if _, ok := seen[s]; ok {
+			return fmt.Errorf("%w: duplicate sample %v", errInvalidSamples, s)
+		}
+		seen[s] = struct{}{}
+	}
+	return nil
+}
+
+// regionSize returns the amount of points in the sampled region.
+func (ss *squareSampler) regionSize() int {
+	odsWidth := ss.squareWidth / 2
+	switch ss.region {
+	case RegionParityOnly:
+		return ss.squareWidth*ss.squareWidth - odsWidth*odsWidth
+	case RegionOriginalOnly:
+		return odsWidth * odsWidth
+	default:
+		return ss.squareWidth * ss.squareWidth
+	}
+}
+
+// inRegion checks if the point lies within the sampled region.
+func (ss *squareSampler) inRegion(s Sample) bool {
+	odsWidth := ss.squareWidth / 2
+	original := s.Row < odsWidth && s.Col < odsWidth
+	switch ss.region {
+	case RegionParityOnly:
+		return !original
+	case RegionOriginalOnly:
+		return original
+	default:
+		return true
+	}
+}
+
 func (ss *squareSampler) samples() []Sample {
 	samples := make([]Sample, 0, len(ss.smpls))
 	for s := range ss.smpls {
@@ -66,6 +248,16 @@ func (ss *squareSampler) samples() []Sample {
 	return samples
 }
 
+// randPerm returns a random permutation of [0, n).
+func randPerm(n int) []int {
+	perm := make([]int, n)
+	for i := range perm {
+		j := randInt(i + 1)
+		perm[i], perm[j] = perm[j], i
+	}
+	return perm
+}
+
 func randInt(max int) int {
 	n, err := crand.Int(crand.Reader, big.NewInt(int64(max)))
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSampleSquare(t *testing.T) {
@@ -34,3 +35,76 @@ func TestSampleSquare(t *testing.T) {
 		}
 	}
 }
+
+func TestSampleSquareRegion(t *testing.T) {
+	const width = 16
+	odsWidth := width / 2
+
+	tests := []struct {
+		region   SampleRegion
+		original bool
+	}{
+		{region: RegionParityOnly, original: false},
+		{region: RegionOriginalOnly, original: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region.String(), func(t *testing.T) {
+			ss, err := SampleSquareRegion(width, 32, tt.region)
+			assert.NoError(t, err)
+			assert.Len(t, ss, 32)
+			for _, s := range ss {
+				inOriginal := s.Row < odsWidth && s.Col < odsWidth
+				assert.Equal(t, tt.original, inOriginal, "sample %v is outside of the region", s)
+			}
+		})
+	}
+}
+
+func TestSampleSquareAxes(t *testing.T) {
+	const width = 8
+	odsWidth := width / 2
+
+	tests := []struct {
+		region  SampleRegion
+		samples int
+		axes    int
+	}{
+		{region: RegionFull, samples: width, axes: width},
+		{region: RegionFull, samples: 20, axes: width},
+		// original rows only take parity columns, so covering them may take another ODS width
+		{region: RegionParityOnly, samples: width + odsWidth, axes: width},
+		{region: RegionOriginalOnly, samples: odsWidth, axes: odsWidth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region.String(), func(t *testing.T) {
+			// coordinates are random, so the coverage is checked over several selections
+			for i := 0; i < 50; i++ {
+				ss, err := SampleSquareAxes(width, tt.samples, tt.region)
+				require.NoError(t, err)
+				require.NoError(t, validateSamples(ss, width, tt.samples, tt.region))
+
+				rows, cols := make(map[int]struct{}), make(map[int]struct{})
+				for _, s := range ss {
+					rows[s.Row], cols[s.Col] = struct{}{}, struct{}{}
+				}
+				for axis := 0; axis < tt.axes; axis++ {
+					require.Contains(t, rows, axis, "row %d is not sampled", axis)
+					require.Contains(t, cols, axis, "column %d is not sampled", axis)
+				}
+			}
+		})
+	}
+
+	t.Run("budget below width", func(t *testing.T) {
+		ss, err := SampleSquareAxes(width, 5, RegionFull)
+		require.NoError(t, err)
+		rows, cols := make(map[int]struct{}), make(map[int]struct{})
+		for _, s := range ss {
+			rows[s.Row], cols[s.Col] = struct{}{}, struct{}{}
+		}
+		assert.Len(t, rows, 5)
+		assert.Len(t, cols, 5)
+	})
+}
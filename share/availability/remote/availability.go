@@ -0,0 +1,96 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	logging "github.com/ipfs/go-log/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+var log = logging.Logger("share/remote")
+
+// ShareAvailability implements share.Availability by delegating sampling to a separate
+// availability service over gRPC.
+type ShareAvailability struct {
+	conn   *grpc.ClientConn
+	params Parameters
+}
+
+// NewShareAvailability creates a new remote Availability calling the service at the given target.
+// The connection is established lazily and re-established by the gRPC client on failures.
+func NewShareAvailability(target string, opts ...Option) (*ShareAvailability, error) {
+	params := DefaultParameters()
+	for _, opt := range opts {
+		opt(&params)
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	dialOpts := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, params.DialOptions...)
+	conn, err := grpc.Dial(target, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("remote availability: dialing %s: %w", target, err)
+	}
+
+	return &ShareAvailability{
+		conn:   conn,
+		params: params,
+	}, nil
+}
+
+// SharesAvailable requests the availability service to sample the data committed to the given
+// ExtendedHeader. Unavailable verdict is reported as share.ErrNotAvailable.
+func (ra *ShareAvailability) SharesAvailable(ctx context.Context, header *header.ExtendedHeader) error {
+	dah := header.DAH
+	// short-circuit if the given root is minimum DAH of an empty data square
+	if share.DataHash(dah.Hash()).IsEmptyRoot() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ra.params.CallTimeout)
+	defer cancel()
+
+	req := &SampleRequest{
+		Height:       header.Height(),
+		DataHash:     header.DataHash,
+		Root:         dah,
+		SampleAmount: ra.params.SampleAmount,
+	}
+	resp := new(SampleResponse)
+	err := ra.conn.Invoke(ctx, sharesAvailableMethod, req, resp, grpc.CallContentSubtype(codecName))
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			// expose context errors, so callers could distinguish them
+			return fmt.Errorf("remote availability: %w: %w", ctxErr, err)
+		}
+		return fmt.Errorf("remote availability: calling service: %w", err)
+	}
+
+	if resp.Error != "" {
+		return fmt.Errorf("remote availability: service error: %w", errors.New(resp.Error))
+	}
+
+	switch resp.Verdict {
+	case VerdictAvailable:
+		return nil
+	case VerdictUnavailable:
+		log.Debugw("service reported data as unavailable", "height", header.Height(), "root", dah.String())
+		return share.ErrNotAvailable
+	default:
+		return fmt.Errorf("remote availability: unknown verdict: %q", resp.Verdict)
+	}
+}
+
+// Close closes the connection to the availability service.
+func (ra *ShareAvailability) Close(context.Context) error {
+	return ra.conn.Close()
+}
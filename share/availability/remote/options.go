@@ -0,0 +1,71 @@
+package remote
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// DefaultCallTimeout is the default deadline of a single call to the availability service.
+var DefaultCallTimeout = time.Minute
+
+// Parameters is the set of Parameters that must be configured for the remote availability
+// implementation
+type Parameters struct {
+	// SampleAmount is the amount of samples the service is requested to perform. Zero leaves the
+	// amount up to the service.
+	SampleAmount uint
+
+	// CallTimeout is the deadline of a single call to the availability service.
+	CallTimeout time.Duration
+
+	// DialOptions are passed to the gRPC client. Insecure transport credentials are used unless
+	// overridden.
+	DialOptions []grpc.DialOption
+}
+
+// Option is a function that configures remote availability Parameters
+type Option func(*Parameters)
+
+// DefaultParameters returns the default Parameters' configuration values
+// for the remote availability implementation
+func DefaultParameters() Parameters {
+	return Parameters{
+		CallTimeout: DefaultCallTimeout,
+	}
+}
+
+// Validate validates the values in Parameters
+func (p *Parameters) Validate() error {
+	if p.CallTimeout <= 0 {
+		return fmt.Errorf(
+			"remote availability: invalid option: value %s was %s, where it should be %s",
+			"CallTimeout",
+			"<= 0", // current value
+			"> 0",  // what the value should be
+		)
+	}
+	return nil
+}
+
+// WithSampleAmount is a functional option to set the SampleAmount configuration param
+func WithSampleAmount(sampleAmount uint) Option {
+	return func(p *Parameters) {
+		p.SampleAmount = sampleAmount
+	}
+}
+
+// WithCallTimeout is a functional option to set the CallTimeout configuration param
+func WithCallTimeout(timeout time.Duration) Option {
+	return func(p *Parameters) {
+		p.CallTimeout = timeout
+	}
+}
+
+// WithDialOptions is a functional option to add gRPC dial options, e.g. transport credentials
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(p *Parameters) {
+		p.DialOptions = append(p.DialOptions, opts...)
+	}
+}
@@ -0,0 +1,119 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+const (
+	serviceName           = "celestia.share.availability.v1.Availability"
+	sharesAvailableMethod = "/" + serviceName + "/SharesAvailable"
+
+	// codecName is the gRPC content-subtype messages of the service are encoded with
+	codecName = "json"
+)
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// Verdict is the result of sampling reported by the availability service.
+type Verdict string
+
+const (
+	// VerdictAvailable reports that the data is available.
+	VerdictAvailable Verdict = "available"
+	// VerdictUnavailable reports that the data is not available.
+	VerdictUnavailable Verdict = "unavailable"
+)
+
+// SampleRequest requests the availability service to sample the data committed to the Root.
+type SampleRequest struct {
+	Height   uint64      `json:"height"`
+	DataHash []byte      `json:"data_hash"`
+	Root     *share.Root `json:"root"`
+	// SampleAmount is the amount of samples to perform. Zero leaves the amount up to the service.
+	SampleAmount uint `json:"sample_amount,omitempty"`
+}
+
+// SampleResponse carries the verdict of the availability service. If sampling could not be
+// performed, Error is set instead.
+type SampleResponse struct {
+	Verdict Verdict `json:"verdict,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Server is the availability service called by ShareAvailability.
+type Server interface {
+	SharesAvailable(context.Context, *SampleRequest) (*SampleResponse, error)
+}
+
+// ServerFunc is an adapter to allow the use of ordinary functions as availability service.
+type ServerFunc func(context.Context, *SampleRequest) (*SampleResponse, error)
+
+// SharesAvailable calls f(ctx, req).
+func (f ServerFunc) SharesAvailable(ctx context.Context, req *SampleRequest) (*SampleResponse, error) {
+	return f(ctx, req)
+}
+
+// RegisterServer registers the availability service on the gRPC server.
+func RegisterServer(s *grpc.Server, srv Server) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*Server)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SharesAvailable",
+			Handler:    sharesAvailableHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+func sharesAvailableHandler(
+	srv any,
+	ctx context.Context,
+	dec func(any) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (any, error) {
+	req := new(SampleRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Server).SharesAvailable(ctx, req)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: sharesAvailableMethod,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(Server).SharesAvailable(ctx, req.(*SampleRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
+
+// jsonCodec encodes messages of the service as JSON, so the service can be implemented without
+// generated code.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return codecName
+}
@@ -0,0 +1,34 @@
+package remote
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// TestAvailability starts an in-process gRPC server with the given availability service and
+// returns a remote Availability connected to it.
+func TestAvailability(t *testing.T, srv Server, opts ...Option) *ShareAvailability {
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	RegisterServer(server, srv)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	dialer := func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}
+	opts = append(opts, WithDialOptions(grpc.WithContextDialer(dialer)))
+	avail, err := NewShareAvailability("passthrough:///bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = avail.Close(context.Background())
+	})
+	return avail
+}
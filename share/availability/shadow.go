@@ -0,0 +1,143 @@
+// Package availability provides share.Availability wrappers independent of the sampling technique.
+package availability
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+var log = logging.Logger("share/availability")
+
+const (
+	// defaultShadowTimeout limits a single validation by the shadow Availability.
+	defaultShadowTimeout = time.Minute
+	// defaultShadowConcurrency limits the amount of shadow validations running in parallel.
+	defaultShadowConcurrency = 16
+)
+
+// Disagreement describes a header the shadow Availability reached a different verdict for than
+// the primary one.
+type Disagreement struct {
+	Height   uint64
+	DataHash share.DataHash
+	// PrimaryErr is the verdict of the primary Availability, nil if data is available
+	PrimaryErr error
+	// ShadowErr is the verdict of the shadow Availability, nil if data is available
+	ShadowErr error
+}
+
+// ShadowOption configures the Shadow.
+type ShadowOption func(*Shadow)
+
+// WithDisagreementCallback sets the function called on every Disagreement. It is called from
+// a separate goroutine and must not block for long.
+func WithDisagreementCallback(fn func(Disagreement)) ShadowOption {
+	return func(s *Shadow) {
+		s.onDisagreement = fn
+	}
+}
+
+// WithShadowTimeout sets the timeout of a single validation by the shadow Availability.
+func WithShadowTimeout(timeout time.Duration) ShadowOption {
+	return func(s *Shadow) {
+		s.timeout = timeout
+	}
+}
+
+// Shadow is a share.Availability returning verdicts of the primary Availability, while
+// asynchronously validating the same headers with the shadow Availability and recording
+// disagreements between them. It allows evaluating a new Availability implementation without
+// affecting the real verdicts. Shadow validations are skipped, if too many of them are in flight.
+type Shadow struct {
+	primary, shadow share.Availability
+	onDisagreement  func(Disagreement)
+	timeout         time.Duration
+	// slots bounds the amount of shadow validations in flight
+	slots chan struct{}
+
+	disagreements atomic.Uint64
+	skipped       atomic.Uint64
+}
+
+// NewShadow creates a new Shadow using verdicts of the primary Availability and evaluating the
+// shadow one.
+func NewShadow(primary, shadow share.Availability, opts ...ShadowOption) *Shadow {
+	s := &Shadow{
+		primary: primary,
+		shadow:  shadow,
+		timeout: defaultShadowTimeout,
+		slots:   make(chan struct{}, defaultShadowConcurrency),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SharesAvailable returns the verdict of the primary Availability. The shadow Availability
+// validates the header in the background.
+func (s *Shadow) SharesAvailable(ctx context.Context, h *header.ExtendedHeader) error {
+	err := s.primary.SharesAvailable(ctx, h)
+	if errors.Is(err, context.Canceled) || errors.Is(err, share.ErrBackpressure) {
+		// not a verdict to compare with
+		return err
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		go s.compare(h, err)
+	default:
+		s.skipped.Add(1)
+	}
+	return err
+}
+
+// Disagreements returns the amount of headers the shadow Availability disagreed on.
+func (s *Shadow) Disagreements() uint64 {
+	return s.disagreements.Load()
+}
+
+// Skipped returns the amount of headers not validated by the shadow Availability, because too many
+// shadow validations were in flight.
+func (s *Shadow) Skipped() uint64 {
+	return s.skipped.Load()
+}
+
+// compare validates the header with the shadow Availability and records a disagreement with the
+// primary verdict.
+func (s *Shadow) compare(h *header.ExtendedHeader, primaryErr error) {
+	defer func() { <-s.slots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	shadowErr := s.shadow.SharesAvailable(ctx, h)
+	if errors.Is(shadowErr, share.ErrBackpressure) {
+		return
+	}
+	if (primaryErr == nil) == (shadowErr == nil) {
+		return
+	}
+
+	s.disagreements.Add(1)
+	log.Warnw("shadow availability disagrees with primary",
+		"height", h.Height(),
+		"root", h.DAH.String(),
+		"primary_err", primaryErr,
+		"shadow_err", shadowErr,
+	)
+	if s.onDisagreement != nil {
+		s.onDisagreement(Disagreement{
+			Height:     h.Height(),
+			DataHash:   share.DataHash(h.DataHash),
+			PrimaryErr: primaryErr,
+			ShadowErr:  shadowErr,
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package availability
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/header/headertest"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/celestiaorg/celestia-node/share/availability/availabilitytest"
+	"github.com/celestiaorg/celestia-node/share/eds/edstest"
+)
+
+func TestShadow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the fakes are keyed by root, so every header commits to distinct data
+	headers := make([]*header.ExtendedHeader, 6)
+	for i := range headers {
+		headers[i] = headertest.ExtendedHeaderFromEDS(t, uint64(i+1), edstest.RandEDS(t, 4))
+	}
+	root := func(height int) share.DataHash {
+		return share.DataHash(headers[height-1].DataHash)
+	}
+
+	primary, shadowed := availabilitytest.NewFake(), availabilitytest.NewFake()
+	primary.SetUnavailable(root(2))
+	// agrees on the height 2 and disagrees on heights 3 and 5
+	shadowed.SetUnavailable(root(2))
+	shadowed.SetUnavailable(root(3))
+	shadowed.SetUnavailable(root(5))
+
+	var (
+		lk        sync.Mutex
+		disagreed []uint64
+	)
+	s := NewShadow(primary, shadowed, WithDisagreementCallback(func(d Disagreement) {
+		lk.Lock()
+		defer lk.Unlock()
+		disagreed = append(disagreed, d.Height)
+		assert.NoError(t, d.PrimaryErr)
+		assert.ErrorIs(t, d.ShadowErr, share.ErrNotAvailable)
+	}))
+
+	for _, h := range headers {
+		err := s.SharesAvailable(ctx, h)
+		if h.Height() == 2 {
+			assert.ErrorIs(t, err, share.ErrNotAvailable)
+			continue
+		}
+		// primary verdict is used regardless of the shadow
+		assert.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		return s.Disagreements() == 2
+	}, time.Second, 10*time.Millisecond)
+	for height := 1; height <= len(headers); height++ {
+		require.Eventually(t, func() bool {
+			return shadowed.Calls(root(height)) == 1
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	lk.Lock()
+	defer lk.Unlock()
+	assert.ElementsMatch(t, []uint64{3, 5}, disagreed)
+	assert.Zero(t, s.Skipped())
+}
@@ -0,0 +1,30 @@
+package share_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/celestia-node/share"
+	availability_test "github.com/celestiaorg/celestia-node/share/availability/test"
+	"github.com/celestiaorg/celestia-node/share/ipld"
+	"github.com/celestiaorg/celestia-node/share/sharetest"
+)
+
+func TestNewRootFromShares(t *testing.T) {
+	const size = 8
+	shares := sharetest.RandShares(t, size*size)
+
+	root, err := share.NewRootFromShares(shares, size)
+	require.NoError(t, err)
+	expected := availability_test.FillBS(t, ipld.NewMemBlockservice(), shares)
+	assert.True(t, root.Equals(expected))
+
+	_, err = share.NewRootFromShares(shares[1:], size)
+	require.Error(t, err)
+	_, err = share.NewRootFromShares(shares[:size*size/2], size/2)
+	require.Error(t, err)
+	_, err = share.NewRootFromShares(shares, 0)
+	require.Error(t, err)
+}
@@ -108,12 +108,51 @@ var (
 	errIncorrectAmountOfShares = errors.New("incorrect amount of shares")
 	errIncorrectShare          = errors.New("incorrect share received")
 	errNMTTreeRootsMatch       = errors.New("recomputed root matches the DAH root")
+	errMissingProof            = errors.New("share without proof")
+	errIncorrectProofIndex     = errors.New("share proof does not prove the index of the row/col")
 )
 
 var (
 	invalidProofPrefix = fmt.Sprintf("invalid %s proof", BadEncoding)
 )
 
+// ValidateBasic checks that the proof is consistent in itself, without the header it was created
+// for: the shares span a whole row or column of a valid square width, enough of them are provided
+// to reconstruct it and every provided share has an inclusion proof of the proof's index along the
+// orthogonal axis. It does not verify the shares against the data root, which is done by Validate.
+func (p *BadEncodingProof) ValidateBasic() error {
+	width := len(p.Shares)
+	if width == 0 || width&(width-1) != 0 {
+		return fmt.Errorf("%s: %w: width %d is not a power of two", invalidProofPrefix, errIncorrectAmountOfShares, width)
+	}
+	if int(p.Index) >= width {
+		return fmt.Errorf("%s: %w (%d >= %d)", invalidProofPrefix, errIncorrectIndex, p.Index, width)
+	}
+
+	amount := 0
+	for index, shr := range p.Shares {
+		if shr == nil {
+			continue
+		}
+		amount++
+		if shr.Proof == nil {
+			return fmt.Errorf("%s: %w at index %d", invalidProofPrefix, errMissingProof, index)
+		}
+		// shares are proven against roots of the orthogonal axis, where they are at the proof's index
+		if shr.Proof.Start() != int(p.Index) || shr.Proof.End() != int(p.Index)+1 {
+			return fmt.Errorf("%s: %w at index %d", invalidProofPrefix, errIncorrectProofIndex, index)
+		}
+		if len(shr.Share) != share.Size {
+			return fmt.Errorf("%s: %w at index %d", invalidProofPrefix, errIncorrectShare, index)
+		}
+	}
+	if amount < width/2 {
+		return fmt.Errorf("%s: %w: not enough shares provided to reconstruct row/col",
+			invalidProofPrefix, errIncorrectAmountOfShares)
+	}
+	return nil
+}
+
 // Validate ensures that fraud proof is correct.
 // Validate checks that provided Merkle Proofs correspond to the shares,
 // rebuilds bad row or col from received shares, computes Merkle Root
@@ -159,6 +159,67 @@ func TestBEFP_Validate(t *testing.T) {
 }
 
 // TestIncorrectBadEncodingFraudProof asserts that BEFP is not generated for the correct data
+func TestBEFP_ValidateBasic(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer t.Cleanup(cancel)
+	bServ := ipld.NewMemBlockservice()
+
+	square := edstest.RandByzantineEDS(t, 16)
+	dah, err := da.NewDataAvailabilityHeader(square)
+	require.NoError(t, err)
+	err = ipld.ImportEDS(ctx, square, bServ)
+	require.NoError(t, err)
+
+	var errRsmt2d *rsmt2d.ErrByzantineData
+	err = square.Repair(dah.RowRoots, dah.ColumnRoots)
+	require.ErrorAs(t, err, &errRsmt2d)
+
+	var errByz *ErrByzantine
+	require.ErrorAs(t, NewErrByzantine(ctx, bServ, &dah, errRsmt2d), &errByz)
+	befp, ok := CreateBadEncodingProof([]byte("hash"), 0, errByz).(*BadEncodingProof)
+	require.True(t, ok)
+	// the proof is consistent in itself, regardless of the header
+	require.NoError(t, befp.ValidateBasic())
+
+	tests := []struct {
+		name   string
+		modify func(befp *BadEncodingProof)
+		err    error
+	}{
+		{
+			name:   "width is not a power of two",
+			modify: func(befp *BadEncodingProof) { befp.Shares = befp.Shares[:len(befp.Shares)-1] },
+			err:    errIncorrectAmountOfShares,
+		},
+		{
+			name:   "index out of bounds",
+			modify: func(befp *BadEncodingProof) { befp.Index = uint32(len(befp.Shares)) },
+			err:    errIncorrectIndex,
+		},
+		{
+			name: "not enough shares",
+			modify: func(befp *BadEncodingProof) {
+				befp.Shares = make([]*ShareWithProof, len(befp.Shares))
+			},
+			err: errIncorrectAmountOfShares,
+		},
+		{
+			name:   "proof of another index",
+			modify: func(befp *BadEncodingProof) { befp.Index = (befp.Index + 1) % uint32(len(befp.Shares)) },
+			err:    errIncorrectProofIndex,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modified := *befp
+			modified.Shares = append([]*ShareWithProof(nil), befp.Shares...)
+			tt.modify(&modified)
+			require.ErrorIs(t, modified.ValidateBasic(), tt.err)
+		})
+	}
+}
+
 func TestIncorrectBadEncodingFraudProof(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
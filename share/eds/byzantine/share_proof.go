@@ -2,7 +2,7 @@ package byzantine
 
 import (
 	"context"
-	"crypto/sha256"
+	"hash"
 
 	"github.com/ipfs/boxo/blockservice"
 	"github.com/ipfs/go-cid"
@@ -44,8 +44,14 @@ func NewShareWithProof(index int, share share.Share, pathToLeaf []cid.Cid) *Shar
 
 // Validate validates inclusion of the share under the given root CID.
 func (s *ShareWithProof) Validate(root cid.Cid) bool {
+	return s.ValidateWithHasher(root, share.DefaultNMTHasher())
+}
+
+// ValidateWithHasher validates inclusion of the share under the given root CID, hashing nmt nodes
+// with the given hasher.
+func (s *ShareWithProof) ValidateWithHasher(root cid.Cid, hasher hash.Hash) bool {
 	return s.Proof.VerifyInclusion(
-		sha256.New(), // TODO(@Wondertan): This should be defined somewhere globally
+		hasher,
 		share.GetNamespace(s.Share).ToNMT(),
 		[][]byte{share.GetData(s.Share)},
 		ipld.NamespacedSha256FromCID(root),
@@ -90,6 +96,18 @@ func GetProofsForShares(
 	return proofs, nil
 }
 
+// GetShareWithProof fetches the share at the given index of the tree under the root together with
+// its Merkle proof.
+func GetShareWithProof(
+	ctx context.Context,
+	bGetter blockservice.BlockGetter,
+	root cid.Cid,
+	index,
+	total int,
+) (*ShareWithProof, error) {
+	return getProofsAt(ctx, bGetter, root, index, total)
+}
+
 func getProofsAt(
 	ctx context.Context,
 	bGetter blockservice.BlockGetter,
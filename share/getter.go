@@ -2,9 +2,9 @@ package share
 
 import (
 	"context"
-	"crypto/sha256"
 	"errors"
 	"fmt"
+	"hash"
 
 	"github.com/celestiaorg/nmt"
 	"github.com/celestiaorg/rsmt2d"
@@ -39,6 +39,26 @@ type Getter interface {
 	GetSharesByNamespace(context.Context, *header.ExtendedHeader, Namespace) (NamespacedShares, error)
 }
 
+// SampleCoord is the coordinates of a share in the EDS.
+type SampleCoord struct {
+	Row, Col int
+}
+
+// SampleResult is the outcome of getting the share at a SampleCoord.
+type SampleResult struct {
+	Share Share
+	Err   error
+}
+
+// SampleGetter is implemented by Getters able to get multiple shares of an EDS in a single request,
+// saving the overhead of a request per share.
+type SampleGetter interface {
+	// GetSamples gets shares at the given coordinates in EDS. Results are returned in the order of
+	// coordinates, each with the error of getting its share, if any. The returned error is reserved
+	// for failures of the request as a whole.
+	GetSamples(context.Context, *header.ExtendedHeader, []SampleCoord) ([]SampleResult, error)
+}
+
 // NamespacedShares represents all shares with proofs within a specific namespace of an EDS.
 type NamespacedShares []NamespacedRow
 
@@ -59,6 +79,12 @@ type NamespacedRow struct {
 
 // Verify validates NamespacedShares by checking every row with nmt inclusion proof.
 func (ns NamespacedShares) Verify(root *Root, namespace Namespace) error {
+	return ns.VerifyWithHasher(root, namespace, DefaultNMTHasher)
+}
+
+// VerifyWithHasher validates NamespacedShares like Verify, hashing nmt nodes with hashers created by
+// the given function.
+func (ns NamespacedShares) VerifyWithHasher(root *Root, namespace Namespace, newHasher func() hash.Hash) error {
 	var originalRoots [][]byte
 	for _, row := range root.RowRoots {
 		if !namespace.IsOutsideRange(row, row) {
@@ -73,15 +99,63 @@ func (ns NamespacedShares) Verify(root *Root, namespace Namespace) error {
 
 	for i, row := range ns {
 		// verify row data against row hash from original root
-		if !row.verify(originalRoots[i], namespace) {
+		if !row.verify(originalRoots[i], namespace, newHasher()) {
 			return fmt.Errorf("row verification failed: row %d doesn't match original root: %s", i, root.String())
 		}
 	}
 	return nil
 }
 
+// NamespaceStatus classifies presence of a namespace in the square, as proven by NamespacedShares.
+type NamespaceStatus int
+
+const (
+	// NamespacePresent means the namespace has shares in the square and all of them are proven to be
+	// returned.
+	NamespacePresent NamespaceStatus = iota + 1
+	// NamespaceAbsent means the namespace is provably absent from the square, either because it is
+	// outside the namespace range of every row root, or by absence proofs of the rows covering it.
+	NamespaceAbsent
+)
+
+// String returns the name of the NamespaceStatus.
+func (s NamespaceStatus) String() string {
+	switch s {
+	case NamespacePresent:
+		return "present"
+	case NamespaceAbsent:
+		return "absent"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// Status verifies NamespacedShares against the root like Verify and classifies presence of the
+// namespace in the square.
+func (ns NamespacedShares) Status(root *Root, namespace Namespace) (NamespaceStatus, error) {
+	return ns.StatusWithHasher(root, namespace, DefaultNMTHasher)
+}
+
+// StatusWithHasher classifies presence of the namespace like Status, verifying NamespacedShares with
+// VerifyWithHasher.
+func (ns NamespacedShares) StatusWithHasher(
+	root *Root,
+	namespace Namespace,
+	newHasher func() hash.Hash,
+) (NamespaceStatus, error) {
+	if err := ns.VerifyWithHasher(root, namespace, newHasher); err != nil {
+		return 0, err
+	}
+	for _, row := range ns {
+		if len(row.Shares) > 0 {
+			return NamespacePresent, nil
+		}
+	}
+	return NamespaceAbsent, nil
+}
+
 // verify validates the row using nmt inclusion proof.
-func (row *NamespacedRow) verify(rowRoot []byte, namespace Namespace) bool {
+func (row *NamespacedRow) verify(rowRoot []byte, namespace Namespace, hasher hash.Hash) bool {
 	// construct nmt leaves from shares by prepending namespace
 	leaves := make([][]byte, 0, len(row.Shares))
 	for _, shr := range row.Shares {
@@ -90,7 +164,7 @@ func (row *NamespacedRow) verify(rowRoot []byte, namespace Namespace) bool {
 
 	// verify namespace
 	return row.Proof.VerifyNamespace(
-		sha256.New(),
+		hasher,
 		namespace.ToNMT(),
 		leaves,
 		rowRoot,
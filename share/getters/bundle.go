@@ -0,0 +1,333 @@
+package getters
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/celestiaorg/rsmt2d"
+
+	libhead "github.com/celestiaorg/go-header"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/celestiaorg/celestia-node/share/eds"
+	"github.com/celestiaorg/celestia-node/share/ipld"
+)
+
+// bundleMagic starts every bundle file.
+var bundleMagic = []byte("celestia/bundle\x00")
+
+// bundleCacheSize is the number of decoded squares kept in memory, so sampling a square does not
+// decode it again for every share.
+const bundleCacheSize = 4
+
+var (
+	_ share.Getter                           = (*BundleGetter)(nil)
+	_ libhead.Getter[*header.ExtendedHeader] = (*BundleGetter)(nil)
+)
+
+// BundleEntry is a header together with the extended data square committed to it.
+type BundleEntry struct {
+	Header *header.ExtendedHeader
+	EDS    *rsmt2d.ExtendedDataSquare
+}
+
+// WriteBundle writes the entries to w in the format read by NewBundleGetter. Each entry is written
+// as the length-prefixed binary encoded header followed by the length-prefixed CAR encoded square.
+func WriteBundle(ctx context.Context, w io.Writer, entries []BundleEntry) error {
+	if _, err := w.Write(bundleMagic); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		hdr, err := entry.Header.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("getter/bundle: marshaling header at height %d: %w", entry.Header.Height(), err)
+		}
+		var square bytes.Buffer
+		if err = eds.WriteEDS(ctx, entry.EDS, &square); err != nil {
+			return fmt.Errorf("getter/bundle: writing square at height %d: %w", entry.Header.Height(), err)
+		}
+		if err = writeBundleField(w, hdr); err != nil {
+			return err
+		}
+		if err = writeBundleField(w, square.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeBundleField(w io.Writer, data []byte) error {
+	size := binary.AppendUvarint(nil, uint64(len(data)))
+	if _, err := w.Write(size); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// bundleSquare is the location of a CAR encoded square within the bundle file.
+type bundleSquare struct {
+	offset, size int64
+}
+
+// BundleGetter serves headers and extended data squares from a local bundle file written with
+// WriteBundle, so availability could be verified against an offline snapshot, e.g. in air-gapped
+// environments. Headers are validated against their DAH and kept in memory, while squares are read
+// from the file on request, verified against the data root and cached.
+type BundleGetter struct {
+	file    *os.File
+	headers map[uint64]*header.ExtendedHeader
+	hashes  map[string]*header.ExtendedHeader
+	squares map[string]bundleSquare
+	head    *header.ExtendedHeader
+
+	// readLk serializes reading of squares, so concurrent requests for the same square decode it
+	// only once.
+	readLk sync.Mutex
+	cache  *lru.Cache[string, *rsmt2d.ExtendedDataSquare]
+}
+
+// NewBundleGetter opens the bundle file at the given path and indexes its entries. The bundle
+// file is kept open until Close is called.
+func NewBundleGetter(path string) (*BundleGetter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("getter/bundle: opening bundle: %w", err)
+	}
+
+	cache, err := lru.New[string, *rsmt2d.ExtendedDataSquare](bundleCacheSize)
+	if err != nil {
+		f.Close() //nolint:errcheck
+		return nil, fmt.Errorf("getter/bundle: creating cache: %w", err)
+	}
+
+	bg := &BundleGetter{
+		file:    f,
+		headers: make(map[uint64]*header.ExtendedHeader),
+		hashes:  make(map[string]*header.ExtendedHeader),
+		squares: make(map[string]bundleSquare),
+		cache:   cache,
+	}
+	if err = bg.index(); err != nil {
+		f.Close() //nolint:errcheck
+		return nil, fmt.Errorf("getter/bundle: reading bundle: %w", err)
+	}
+	return bg, nil
+}
+
+// index reads headers of all entries and remembers the location of their squares. Each header must
+// commit to its DAH, as squares are verified against the DAH only.
+func (bg *BundleGetter) index() error {
+	r := &countingReader{r: bufio.NewReader(bg.file)}
+	magic := make([]byte, len(bundleMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, bundleMagic) {
+		return errors.New("not a bundle file")
+	}
+
+	for {
+		size, err := binary.ReadUvarint(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		data := make([]byte, size)
+		if _, err = io.ReadFull(r, data); err != nil {
+			return err
+		}
+		h := new(header.ExtendedHeader)
+		if err = h.UnmarshalBinary(data); err != nil {
+			return fmt.Errorf("unmarshaling header: %w", err)
+		}
+		if err = validateBundleHeader(h); err != nil {
+			return fmt.Errorf("invalid header at height %d: %w", h.Height(), err)
+		}
+
+		size, err = binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		square := bundleSquare{offset: r.n, size: int64(size)}
+		if _, err = io.CopyN(io.Discard, r, square.size); err != nil {
+			return err
+		}
+
+		bg.headers[h.Height()] = h
+		bg.hashes[h.Hash().String()] = h
+		bg.squares[h.DAH.String()] = square
+		if bg.head == nil || h.Height() > bg.head.Height() {
+			bg.head = h
+		}
+	}
+}
+
+// validateBundleHeader checks that the DAH of the header is well-formed and committed to by the
+// data hash of the header.
+func validateBundleHeader(h *header.ExtendedHeader) error {
+	if h.DAH == nil {
+		return errors.New("missing DAH")
+	}
+	if err := h.DAH.ValidateBasic(); err != nil {
+		return fmt.Errorf("invalid DAH: %w", err)
+	}
+	if !bytes.Equal(h.DAH.Hash(), h.DataHash) {
+		return fmt.Errorf("data hash %X does not match DAH hash %X", h.DataHash, h.DAH.Hash())
+	}
+	return nil
+}
+
+// Close closes the bundle file.
+func (bg *BundleGetter) Close() error {
+	return bg.file.Close()
+}
+
+// Head returns the highest header of the bundle.
+func (bg *BundleGetter) Head(
+	context.Context,
+	...libhead.HeadOption[*header.ExtendedHeader],
+) (*header.ExtendedHeader, error) {
+	if bg.head == nil {
+		return nil, libhead.ErrNoHead
+	}
+	return bg.head, nil
+}
+
+// Get returns the header of the bundle with the given hash.
+func (bg *BundleGetter) Get(_ context.Context, hash libhead.Hash) (*header.ExtendedHeader, error) {
+	h, ok := bg.hashes[hash.String()]
+	if !ok {
+		return nil, libhead.ErrNotFound
+	}
+	return h, nil
+}
+
+// GetByHeight returns the header of the bundle at the given height.
+func (bg *BundleGetter) GetByHeight(_ context.Context, height uint64) (*header.ExtendedHeader, error) {
+	h, ok := bg.headers[height]
+	if !ok {
+		return nil, libhead.ErrNotFound
+	}
+	return h, nil
+}
+
+// GetRangeByHeight returns headers of the bundle in range [from.Height()+1:to). All headers of the
+// range must be included in the bundle.
+func (bg *BundleGetter) GetRangeByHeight(
+	ctx context.Context,
+	from *header.ExtendedHeader,
+	to uint64,
+) ([]*header.ExtendedHeader, error) {
+	headers := make([]*header.ExtendedHeader, 0, to-from.Height()-1)
+	for height := from.Height() + 1; height < to; height++ {
+		h, err := bg.GetByHeight(ctx, height)
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, h)
+	}
+	return headers, nil
+}
+
+// GetShare gets the share at the given coordinates from the square of the bundle.
+func (bg *BundleGetter) GetShare(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+	row, col int,
+) (share.Share, error) {
+	upperBound := len(header.DAH.RowRoots)
+	if row >= upperBound || col >= upperBound {
+		return nil, share.ErrOutOfBounds
+	}
+	square, err := bg.GetEDS(ctx, header)
+	if err != nil {
+		return nil, err
+	}
+	return square.GetCell(uint(row), uint(col)), nil
+}
+
+// GetEDS reads the square committed to the header from the bundle and verifies it against the
+// data root. Recently read squares are served from memory.
+func (bg *BundleGetter) GetEDS(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+) (*rsmt2d.ExtendedDataSquare, error) {
+	if header.DAH.Equals(share.EmptyRoot()) {
+		return share.EmptyExtendedDataSquare(), nil
+	}
+	key := header.DAH.String()
+	loc, ok := bg.squares[key]
+	if !ok {
+		return nil, share.ErrNotFound
+	}
+
+	bg.readLk.Lock()
+	defer bg.readLk.Unlock()
+	if square, ok := bg.cache.Get(key); ok {
+		return square, nil
+	}
+
+	r := io.NewSectionReader(bg.file, loc.offset, loc.size)
+	square, err := eds.ReadEDS(ctx, r, header.DAH.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("getter/bundle: reading square: %w", err)
+	}
+	bg.cache.Add(key, square)
+	return square, nil
+}
+
+// GetSharesByNamespace gets shares of the namespace from the square of the bundle together with
+// their proofs.
+func (bg *BundleGetter) GetSharesByNamespace(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+	namespace share.Namespace,
+) (share.NamespacedShares, error) {
+	if err := namespace.ValidateForData(); err != nil {
+		return nil, err
+	}
+	square, err := bg.GetEDS(ctx, header)
+	if err != nil {
+		return nil, err
+	}
+
+	bServ := ipld.NewMemBlockservice()
+	if err = ipld.ImportEDS(ctx, square, bServ); err != nil {
+		return nil, fmt.Errorf("getter/bundle: importing square: %w", err)
+	}
+	shares, err := eds.CollectSharesByNamespace(ctx, bServ, header.DAH, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("getter/bundle: retrieving shares by namespace: %w", err)
+	}
+	return shares, nil
+}
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
@@ -0,0 +1,116 @@
+package getters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+func TestBundleGetter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	square, eh := randomEDS(t)
+	eh.RawHeader.Height = 1
+	bg := openBundle(t, []BundleEntry{{Header: eh, EDS: square}})
+
+	t.Run("GetByHeight", func(t *testing.T) {
+		h, err := bg.GetByHeight(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, eh.DAH.Hash(), h.DAH.Hash())
+
+		head, err := bg.Head(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), head.Height())
+	})
+
+	t.Run("GetShare", func(t *testing.T) {
+		width := int(square.Width())
+		for i := 0; i < width; i++ {
+			for j := 0; j < width; j++ {
+				sh, err := bg.GetShare(ctx, eh, i, j)
+				require.NoError(t, err)
+				assert.Equal(t, square.GetCell(uint(i), uint(j)), sh)
+			}
+		}
+
+		_, err := bg.GetShare(ctx, eh, width, 0)
+		require.ErrorIs(t, err, share.ErrOutOfBounds)
+	})
+
+	t.Run("GetEDS", func(t *testing.T) {
+		got, err := bg.GetEDS(ctx, eh)
+		require.NoError(t, err)
+		assert.True(t, got.Equals(square))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, other := randomEDS(t)
+		_, err := bg.GetEDS(ctx, other)
+		require.ErrorIs(t, err, share.ErrNotFound)
+		_, err = bg.GetShare(ctx, other, 0, 0)
+		require.ErrorIs(t, err, share.ErrNotFound)
+	})
+}
+
+// TestBundleGetter_CachesSquares ensures the square is decoded once for all shares of it, by
+// closing the bundle file after the first share is read.
+func TestBundleGetter_CachesSquares(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	square, eh := randomEDS(t)
+	bg := openBundle(t, []BundleEntry{{Header: eh, EDS: square}})
+
+	_, err := bg.GetShare(ctx, eh, 0, 0)
+	require.NoError(t, err)
+	require.NoError(t, bg.Close())
+
+	width := int(square.Width())
+	for i := 0; i < width; i++ {
+		sh, err := bg.GetShare(ctx, eh, i, width-1-i)
+		require.NoError(t, err)
+		assert.Equal(t, square.GetCell(uint(i), uint(width-1-i)), sh)
+	}
+}
+
+func TestBundleGetter_InvalidHeader(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	square, eh := randomEDS(t)
+	_, other := randomEDS(t)
+	// the header commits to another square than the one of its DAH
+	eh.DataHash = other.DAH.Hash()
+
+	path := filepath.Join(t.TempDir(), "bundle")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, WriteBundle(ctx, f, []BundleEntry{{Header: eh, EDS: square}}))
+	require.NoError(t, f.Close())
+
+	_, err = NewBundleGetter(path)
+	require.ErrorContains(t, err, "does not match DAH hash")
+}
+
+func openBundle(t *testing.T, entries []BundleEntry) *BundleGetter {
+	path := filepath.Join(t.TempDir(), "bundle")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, WriteBundle(context.Background(), f, entries))
+	require.NoError(t, f.Close())
+
+	bg, err := NewBundleGetter(path)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		bg.Close() //nolint:errcheck
+	})
+	return bg
+}
@@ -15,7 +15,11 @@ import (
 	"github.com/celestiaorg/celestia-node/share/eds/byzantine"
 )
 
-var _ share.Getter = (*CascadeGetter)(nil)
+var (
+	_ share.Getter       = (*CascadeGetter)(nil)
+	_ share.SampleGetter = (*CascadeGetter)(nil)
+	_ share.Warmer       = (*CascadeGetter)(nil)
+)
 
 // CascadeGetter implements custom share.Getter that composes multiple Getter implementations in
 // "cascading" order.
@@ -32,6 +36,17 @@ func NewCascadeGetter(getters []share.Getter) *CascadeGetter {
 	}
 }
 
+// Warmup warms up all registered share.Getters implementing share.Warmer.
+func (cg *CascadeGetter) Warmup(ctx context.Context) error {
+	var errs error
+	for _, getter := range cg.getters {
+		if warmer, ok := getter.(share.Warmer); ok {
+			errs = errors.Join(errs, warmer.Warmup(ctx))
+		}
+	}
+	return errs
+}
+
 // GetShare gets a share from any of registered share.Getters in cascading order.
 func (cg *CascadeGetter) GetShare(
 	ctx context.Context, header *header.ExtendedHeader, row, col int,
@@ -55,6 +70,65 @@ func (cg *CascadeGetter) GetShare(
 	return cascadeGetters(ctx, cg.getters, get)
 }
 
+// GetSamples gets shares at the given coordinates from registered share.Getters in cascading order.
+// Only coordinates the previous getters failed to get a share for are requested from the next
+// getter. Getters not implementing share.SampleGetter are requested per coordinate.
+func (cg *CascadeGetter) GetSamples(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+	coords []share.SampleCoord,
+) ([]share.SampleResult, error) {
+	ctx, span := tracer.Start(ctx, "cascade/get-samples", trace.WithAttributes(
+		attribute.Int("amount", len(coords)),
+	))
+	defer span.End()
+
+	if len(cg.getters) == 0 {
+		return nil, errors.New("no getters provided")
+	}
+
+	results := make([]share.SampleResult, len(coords))
+	// pending are indexes of coordinates without a share yet
+	pending := make([]int, len(coords))
+	for i := range pending {
+		pending[i] = i
+	}
+	for i, getter := range cg.getters {
+		if len(pending) == 0 || ctx.Err() != nil {
+			break
+		}
+
+		batch := make([]share.SampleCoord, len(pending))
+		for j, idx := range pending {
+			batch[j] = coords[idx]
+		}
+		// we split the timeout between left getters, like cascadeGetters does
+		getCtx, cancel := ctxWithSplitTimeout(ctx, len(cg.getters)-i, 0)
+		got := getSamples(getCtx, getter, header, batch)
+		cancel()
+
+		left := pending[:0]
+		for j, idx := range pending {
+			res := got[j]
+			var byzantineErr *byzantine.ErrByzantine
+			switch {
+			case res.Err == nil:
+				results[idx] = res
+			case errors.As(res.Err, &byzantineErr):
+				// don't retry byzantine data, so the BEFP could be created
+				results[idx].Err = byzantineErr
+			case errors.Is(res.Err, errOperationNotSupported):
+				left = append(left, idx)
+			default:
+				results[idx].Err = errors.Join(results[idx].Err, res.Err)
+				left = append(left, idx)
+			}
+		}
+		pending = left
+	}
+	return results, nil
+}
+
 // GetEDS gets a full EDS from any of registered share.Getters in cascading order.
 func (cg *CascadeGetter) GetEDS(
 	ctx context.Context, header *header.ExtendedHeader,
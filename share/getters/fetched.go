@@ -0,0 +1,47 @@
+package getters
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/celestiaorg/rsmt2d"
+
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+type fetchedBytesKey struct{}
+
+// WithFetchedBytes returns a copy of the context carrying the counter. Getters fetching data from
+// the network add the size of the fetched shares to the counter, allowing the caller to account
+// the bandwidth spent on the request. Proofs and the namespace prefixes of tree leaves fetched
+// along with the shares are not accounted.
+func WithFetchedBytes(ctx context.Context, counter *atomic.Uint64) context.Context {
+	return context.WithValue(ctx, fetchedBytesKey{}, counter)
+}
+
+// addFetchedBytes adds the size of fetched data to the counter carried by the context, if any.
+func addFetchedBytes(ctx context.Context, size int) {
+	counter, ok := ctx.Value(fetchedBytesKey{}).(*atomic.Uint64)
+	if !ok || size <= 0 {
+		return
+	}
+	counter.Add(uint64(size))
+}
+
+// edsSize returns the size of the original shares of the square, which is the data needed to
+// reconstruct it.
+func edsSize(eds *rsmt2d.ExtendedDataSquare) int {
+	width := int(eds.Width()) / 2
+	return width * width * share.Size
+}
+
+// namespacedSharesSize returns the size of all shares of the namespace.
+func namespacedSharesSize(shares share.NamespacedShares) int {
+	var size int
+	for _, row := range shares {
+		for _, sh := range row.Shares {
+			size += len(sh)
+		}
+	}
+	return size
+}
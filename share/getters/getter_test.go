@@ -21,6 +21,7 @@ import (
 	"github.com/celestiaorg/celestia-node/header"
 	"github.com/celestiaorg/celestia-node/header/headertest"
 	"github.com/celestiaorg/celestia-node/share"
+	availability_test "github.com/celestiaorg/celestia-node/share/availability/test"
 	"github.com/celestiaorg/celestia-node/share/eds"
 	"github.com/celestiaorg/celestia-node/share/eds/edstest"
 	"github.com/celestiaorg/celestia-node/share/ipld"
@@ -211,6 +212,32 @@ func TestIPLDGetter(t *testing.T) {
 		assert.True(t, has)
 	})
 
+	t.Run("GetEDS matches roots", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		t.Cleanup(cancel)
+
+		bServ := ipld.NewMemBlockservice()
+		dah := availability_test.RandFillBS(t, 8, bServ)
+		eh := headertest.RandExtendedHeaderWithRoot(t, dah)
+
+		retrievedEDS, err := NewIPLDGetter(bServ).GetEDS(ctx, eh)
+		require.NoError(t, err)
+		retrievedDAH, err := share.NewRoot(retrievedEDS)
+		require.NoError(t, err)
+		assert.True(t, dah.Equals(retrievedDAH))
+	})
+
+	t.Run("GetEDS unavailable", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		t.Cleanup(cancel)
+
+		// square is not stored
+		_, eh := randomEDS(t)
+		_, err := NewIPLDGetter(ipld.NewMemBlockservice()).GetEDS(ctx, eh)
+		require.ErrorIs(t, err, share.ErrNotAvailable)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
 	t.Run("GetSharesByNamespace", func(t *testing.T) {
 		ctx, cancel := context.WithTimeout(ctx, time.Second)
 		t.Cleanup(cancel)
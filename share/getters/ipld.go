@@ -21,7 +21,10 @@ import (
 	"github.com/celestiaorg/celestia-node/share/ipld"
 )
 
-var _ share.Getter = (*IPLDGetter)(nil)
+var (
+	_ share.Getter       = (*IPLDGetter)(nil)
+	_ share.SampleGetter = (*IPLDGetter)(nil)
+)
 
 // IPLDGetter is a share.Getter that retrieves shares from the bitswap network. Result caching is
 // handled by the provided blockservice. A blockservice session will be created for retrieval if the
@@ -70,9 +73,72 @@ func (ig *IPLDGetter) GetShare(ctx context.Context, header *header.ExtendedHeade
 		return nil, fmt.Errorf("getter/ipld: failed to retrieve share: %w", err)
 	}
 
+	addFetchedBytes(ctx, len(s))
 	return s, nil
 }
 
+// GetSamples gets shares at the given EDS coordinates from the bitswap network in parallel within
+// a single blockservice session.
+func (ig *IPLDGetter) GetSamples(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+	coords []share.SampleCoord,
+) ([]share.SampleResult, error) {
+	ctx, span := tracer.Start(ctx, "ipld/get-samples", trace.WithAttributes(
+		attribute.Int("amount", len(coords)),
+	))
+	defer span.End()
+
+	// share a single session between all coordinates, unless the caller has already signaled one
+	if _, ok := ctx.Value(sessionKey).(*session); !ok {
+		ctx = WithSession(ctx)
+	}
+
+	return getSharesParallel(ctx, ig, header, coords), nil
+}
+
+// GetShareWithProof gets a single share at the given EDS coordinates together with its Merkle
+// proof against the row root from the bitswap network.
+func (ig *IPLDGetter) GetShareWithProof(
+	ctx context.Context,
+	header *header.ExtendedHeader,
+	row, col int,
+) (*byzantine.ShareWithProof, error) {
+	var err error
+	ctx, span := tracer.Start(ctx, "ipld/get-share-with-proof", trace.WithAttributes(
+		attribute.Int("row", row),
+		attribute.Int("col", col),
+	))
+	defer func() {
+		utils.SetStatusAndEnd(span, err)
+	}()
+
+	dah := header.DAH
+	upperBound := len(dah.RowRoots)
+	if row >= upperBound || col >= upperBound {
+		err = share.ErrOutOfBounds
+		return nil, err
+	}
+	root := ipld.MustCidFromNamespacedSha256(dah.RowRoots[row])
+
+	blockGetter := getGetter(ctx, ig.bServ)
+	sh, err := byzantine.GetShareWithProof(ctx, blockGetter, root, col, upperBound)
+	if errors.Is(err, ipld.ErrNodeNotFound) {
+		// convert error to satisfy getter interface contract
+		err = share.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getter/ipld: failed to retrieve share with proof: %w", err)
+	}
+
+	// the leaf is prefixed with the namespace of the share, but only the share itself is accounted
+	addFetchedBytes(ctx, share.Size)
+	return sh, nil
+}
+
+// GetEDS retrieves enough shares to reconstruct the full extended data square for the given
+// header. The reconstructed square is verified against the header roots. If the square could not
+// be retrieved before the context deadline, the returned error wraps share.ErrNotAvailable.
 func (ig *IPLDGetter) GetEDS(
 	ctx context.Context,
 	header *header.ExtendedHeader,
@@ -92,9 +158,13 @@ func (ig *IPLDGetter) GetEDS(
 	if errors.As(err, &errByz) {
 		return nil, err
 	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("getter/ipld: failed to retrieve eds: %w: %w", share.ErrNotAvailable, err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("getter/ipld: failed to retrieve eds: %w", err)
 	}
+	addFetchedBytes(ctx, edsSize(eds))
 	return eds, nil
 }
 
@@ -124,6 +194,7 @@ func (ig *IPLDGetter) GetSharesByNamespace(
 	if err != nil {
 		return nil, fmt.Errorf("getter/ipld: failed to retrieve shares by namespace: %w", err)
 	}
+	addFetchedBytes(ctx, namespacedSharesSize(shares))
 	return shares, nil
 }
 
@@ -0,0 +1,24 @@
+package getters
+
+import (
+	"context"
+
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+type getterKey struct{}
+
+// WithGetter returns a copy of the context carrying the share.Getter. Availability implementations
+// retrieve shares with it instead of their own share.Getter for requests made with the context,
+// allowing the caller to route requests to different getters, e.g. by their priority.
+func WithGetter(ctx context.Context, getter share.Getter) context.Context {
+	return context.WithValue(ctx, getterKey{}, getter)
+}
+
+// GetterFrom returns the share.Getter carried by the context, or the fallback if there is none.
+func GetterFrom(ctx context.Context, fallback share.Getter) share.Getter {
+	if getter, ok := ctx.Value(getterKey{}).(share.Getter); ok && getter != nil {
+		return getter
+	}
+	return fallback
+}
@@ -0,0 +1,53 @@
+package getters
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// latencyEWMAAlpha is the weight of the most recent observation in the exponentially weighted
+// moving average of peer latency.
+const latencyEWMAAlpha = 0.2
+
+// peerLatency tracks exponentially weighted moving average of request latency per peer.
+type peerLatency struct {
+	lock sync.Mutex
+	ewma map[peer.ID]time.Duration
+}
+
+func newPeerLatency() *peerLatency {
+	return &peerLatency{ewma: make(map[peer.ID]time.Duration)}
+}
+
+// observe accounts the latency of a single request served by the peer.
+func (pl *peerLatency) observe(id peer.ID, latency time.Duration) {
+	pl.lock.Lock()
+	defer pl.lock.Unlock()
+
+	prev, ok := pl.ewma[id]
+	if !ok {
+		pl.ewma[id] = latency
+		return
+	}
+	pl.ewma[id] = time.Duration(latencyEWMAAlpha*float64(latency) + (1-latencyEWMAAlpha)*float64(prev))
+}
+
+// slow returns peers with average latency exceeding the threshold, the slowest first.
+func (pl *peerLatency) slow(threshold time.Duration) []peer.ID {
+	pl.lock.Lock()
+	defer pl.lock.Unlock()
+
+	var slow []peer.ID
+	for id, latency := range pl.ewma {
+		if latency > threshold {
+			slow = append(slow, id)
+		}
+	}
+	sort.Slice(slow, func(i, j int) bool {
+		return pl.ewma[slow[i]] > pl.ewma[slow[j]]
+	})
+	return slow
+}
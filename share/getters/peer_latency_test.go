@@ -0,0 +1,35 @@
+package getters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShrexGetter_SlowPeers(t *testing.T) {
+	sg := NewShrexGetter(nil, nil, nil)
+
+	fast, slow, slowest, flaky := peer.ID("fast"), peer.ID("slow"), peer.ID("slowest"), peer.ID("flaky")
+	for i := 0; i < 10; i++ {
+		sg.observeLatency(fast, 10*time.Millisecond, nil)
+		sg.observeLatency(slow, 500*time.Millisecond, nil)
+		// timed out requests count towards latency
+		sg.observeLatency(slowest, time.Second, context.DeadlineExceeded)
+		// requests canceled by the caller say nothing about the peer
+		sg.observeLatency(flaky, time.Second, context.Canceled)
+		// other failures are not accounted
+		sg.observeLatency(flaky, time.Second, errors.New("stream reset"))
+	}
+
+	assert.Equal(t, []peer.ID{slowest, slow}, sg.SlowPeers(100*time.Millisecond))
+	assert.Equal(t, []peer.ID{slowest}, sg.SlowPeers(800*time.Millisecond))
+	assert.Empty(t, sg.SlowPeers(time.Minute))
+
+	// a single fast request doesn't make slow peer fast
+	sg.observeLatency(slow, time.Millisecond, nil)
+	assert.Contains(t, sg.SlowPeers(100*time.Millisecond), slow)
+}
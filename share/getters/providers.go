@@ -0,0 +1,55 @@
+package getters
+
+import (
+	"context"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+type providersKey struct{}
+
+// Providers collects the peers that served data for requests.
+type Providers struct {
+	lock  sync.Mutex
+	peers map[peer.ID]struct{}
+}
+
+// WithProviders returns a copy of the context carrying the Providers. Getters fetching data from
+// remote peers add the peers that successfully served the data, allowing the caller to track the
+// provenance of the data.
+func WithProviders(ctx context.Context, providers *Providers) context.Context {
+	return context.WithValue(ctx, providersKey{}, providers)
+}
+
+// Peers returns the collected peers.
+func (p *Providers) Peers() []peer.ID {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(p.peers) == 0 {
+		return nil
+	}
+	peers := make([]peer.ID, 0, len(p.peers))
+	for id := range p.peers {
+		peers = append(peers, id)
+	}
+	return peers
+}
+
+func (p *Providers) add(id peer.ID) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.peers == nil {
+		p.peers = make(map[peer.ID]struct{})
+	}
+	p.peers[id] = struct{}{}
+}
+
+// addProvider adds the peer that served the data to the Providers carried by the context, if any.
+func addProvider(ctx context.Context, id peer.ID) {
+	providers, ok := ctx.Value(providersKey{}).(*Providers)
+	if !ok {
+		return
+	}
+	providers.add(id)
+}
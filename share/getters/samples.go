@@ -0,0 +1,58 @@
+package getters
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+// getSamples gets shares at the given coordinates with a single request if the getter implements
+// share.SampleGetter, and with a request per coordinate otherwise. Failures of the request as a
+// whole are reported for every coordinate.
+func getSamples(
+	ctx context.Context,
+	getter share.Getter,
+	header *header.ExtendedHeader,
+	coords []share.SampleCoord,
+) []share.SampleResult {
+	batcher, ok := getter.(share.SampleGetter)
+	if !ok {
+		return getSharesParallel(ctx, getter, header, coords)
+	}
+
+	results, err := batcher.GetSamples(ctx, header, coords)
+	if err == nil && len(results) != len(coords) {
+		err = fmt.Errorf("got %d results for %d coordinates", len(results), len(coords))
+	}
+	if err != nil {
+		results = make([]share.SampleResult, len(coords))
+		for i := range results {
+			results[i].Err = err
+		}
+	}
+	return results
+}
+
+// getSharesParallel gets shares at the given coordinates with parallel requests per coordinate.
+func getSharesParallel(
+	ctx context.Context,
+	getter share.Getter,
+	header *header.ExtendedHeader,
+	coords []share.SampleCoord,
+) []share.SampleResult {
+	results := make([]share.SampleResult, len(coords))
+	var wg sync.WaitGroup
+	for i, coord := range coords {
+		wg.Add(1)
+		go func(i int, coord share.SampleCoord) {
+			defer wg.Done()
+			sh, err := getter.GetShare(ctx, header, coord.Row, coord.Col)
+			results[i] = share.SampleResult{Share: sh, Err: err}
+		}(i, coord)
+	}
+	wg.Wait()
+	return results
+}
@@ -11,6 +11,8 @@ import (
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/libp2p/go-libp2p/core/peer"
+
 	"github.com/celestiaorg/rsmt2d"
 
 	"github.com/celestiaorg/celestia-node/header"
@@ -23,7 +25,10 @@ import (
 	"github.com/celestiaorg/celestia-node/share/p2p/shrexnd"
 )
 
-var _ share.Getter = (*ShrexGetter)(nil)
+var (
+	_ share.Getter = (*ShrexGetter)(nil)
+	_ share.Warmer = (*ShrexGetter)(nil)
+)
 
 const (
 	// defaultMinRequestTimeout value is set according to observed time taken by healthy peer to
@@ -100,6 +105,9 @@ type ShrexGetter struct {
 	// attempt multiple peers in scope of one request before context timeout is reached
 	minAttemptsCount int
 
+	// latency tracks average request latency of peers served the requests
+	latency *peerLatency
+
 	metrics *metrics
 }
 
@@ -110,6 +118,7 @@ func NewShrexGetter(edsClient *shrexeds.Client, ndClient *shrexnd.Client, peerMa
 		peerManager:       peerManager,
 		minRequestTimeout: defaultMinRequestTimeout,
 		minAttemptsCount:  defaultMinAttemptsCount,
+		latency:           newPeerLatency(),
 	}
 }
 
@@ -121,6 +130,27 @@ func (sg *ShrexGetter) Stop(ctx context.Context) error {
 	return sg.peerManager.Stop(ctx)
 }
 
+// Warmup connects to the known full nodes before the first request.
+func (sg *ShrexGetter) Warmup(ctx context.Context) error {
+	return sg.peerManager.Warmup(ctx)
+}
+
+// SlowPeers returns peers whose average request latency exceeds the threshold, the slowest first.
+// Latency is tracked as exponentially weighted moving average over requests served by each peer,
+// including requests that timed out.
+func (sg *ShrexGetter) SlowPeers(threshold time.Duration) []peer.ID {
+	return sg.latency.slow(threshold)
+}
+
+// observeLatency accounts the latency of the request to the peer, unless the request was canceled
+// by the caller and thus says nothing about the peer.
+func (sg *ShrexGetter) observeLatency(id peer.ID, latency time.Duration, err error) {
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	sg.latency.observe(id, latency)
+}
+
 func (sg *ShrexGetter) GetShare(context.Context, *header.ExtendedHeader, int, int) (share.Share, error) {
 	return nil, fmt.Errorf("getter/shrex: GetShare %w", errOperationNotSupported)
 }
@@ -160,10 +190,13 @@ func (sg *ShrexGetter) GetEDS(ctx context.Context, header *header.ExtendedHeader
 		reqCtx, cancel := ctxWithSplitTimeout(ctx, sg.minAttemptsCount-attempt+1, sg.minRequestTimeout)
 		eds, getErr := sg.edsClient.RequestEDS(reqCtx, header.DAH.Hash(), peer)
 		cancel()
+		sg.observeLatency(peer, time.Since(reqStart), getErr)
 		switch {
 		case getErr == nil:
 			setStatus(peers.ResultNoop)
 			sg.metrics.recordEDSAttempt(ctx, attempt, true)
+			addFetchedBytes(ctx, edsSize(eds))
+			addProvider(ctx, peer)
 			return eds, nil
 		case errors.Is(getErr, context.DeadlineExceeded),
 			errors.Is(getErr, context.Canceled):
@@ -237,6 +270,7 @@ func (sg *ShrexGetter) GetSharesByNamespace(
 		reqCtx, cancel := ctxWithSplitTimeout(ctx, sg.minAttemptsCount-attempt+1, sg.minRequestTimeout)
 		nd, getErr := sg.ndClient.RequestND(reqCtx, dah, namespace, peer)
 		cancel()
+		sg.observeLatency(peer, time.Since(reqStart), getErr)
 		switch {
 		case getErr == nil:
 			// both inclusion and non-inclusion cases needs verification
@@ -247,6 +281,8 @@ func (sg *ShrexGetter) GetSharesByNamespace(
 			}
 			setStatus(peers.ResultNoop)
 			sg.metrics.recordNDAttempt(ctx, attempt, true)
+			addFetchedBytes(ctx, namespacedSharesSize(nd))
+			addProvider(ctx, peer)
 			return nd, nil
 		case errors.Is(getErr, context.DeadlineExceeded),
 			errors.Is(getErr, context.Canceled):
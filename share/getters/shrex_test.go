@@ -10,6 +10,7 @@ import (
 	"github.com/ipfs/go-datastore"
 	ds_sync "github.com/ipfs/go-datastore/sync"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/p2p/net/conngater"
 	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
 	"github.com/stretchr/testify/require"
@@ -159,6 +160,25 @@ func TestShrexGetter(t *testing.T) {
 		require.Equal(t, randEDS.Flattened(), got.Flattened())
 	})
 
+	t.Run("EDS_providers", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		t.Cleanup(cancel)
+
+		// generate test data
+		randEDS, dah, _ := generateTestEDS(t)
+		eh := headertest.RandExtendedHeaderWithRoot(t, dah)
+		require.NoError(t, edsStore.Put(ctx, dah.Hash(), randEDS))
+		peerManager.Validate(ctx, srvHost.ID(), shrexsub.Notification{
+			DataHash: dah.Hash(),
+			Height:   1,
+		})
+
+		providers := &Providers{}
+		_, err := getter.GetEDS(WithProviders(ctx, providers), eh)
+		require.NoError(t, err)
+		require.Equal(t, []peer.ID{srvHost.ID()}, providers.Peers())
+	})
+
 	t.Run("EDS_ctx_deadline", func(t *testing.T) {
 		ctx, cancel := context.WithTimeout(ctx, time.Second)
 
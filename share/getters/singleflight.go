@@ -0,0 +1,78 @@
+package getters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"golang.org/x/sync/singleflight"
+
+	libhead "github.com/celestiaorg/go-header"
+
+	"github.com/celestiaorg/celestia-node/header"
+)
+
+var _ libhead.Getter[*header.ExtendedHeader] = (*SingleFlightHeaderGetter)(nil)
+
+// SingleFlightHeaderGetter is a libhead.Getter that deduplicates concurrent requests of headers by
+// height, so they share a single fetch from the underlying getter and its result. Other requests
+// are passed through.
+type SingleFlightHeaderGetter struct {
+	libhead.Getter[*header.ExtendedHeader]
+	flights
+}
+
+// NewSingleFlightHeaderGetter wraps the given header getter with deduplication of requests by
+// height.
+func NewSingleFlightHeaderGetter(getter libhead.Getter[*header.ExtendedHeader]) *SingleFlightHeaderGetter {
+	return &SingleFlightHeaderGetter{Getter: getter}
+}
+
+// GetByHeight gets the header at the given height, sharing the fetch with concurrent requests of
+// the same height.
+func (hg *SingleFlightHeaderGetter) GetByHeight(ctx context.Context, height uint64) (*header.ExtendedHeader, error) {
+	key := fmt.Sprintf("header/%d", height)
+	v, err := hg.do(ctx, key, func(ctx context.Context) (any, error) {
+		return hg.Getter.GetByHeight(ctx, height)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*header.ExtendedHeader), nil
+}
+
+// Deduped returns the amount of requests that were served by a fetch of a concurrent request of
+// the same height.
+func (hg *SingleFlightHeaderGetter) Deduped() uint64 {
+	return hg.deduped.Load()
+}
+
+// flights deduplicates concurrent requests with the same key and counts deduplicated ones.
+type flights struct {
+	group   singleflight.Group
+	deduped atomic.Uint64
+}
+
+func (f *flights) do(
+	ctx context.Context,
+	key string,
+	fetch func(context.Context) (any, error),
+) (any, error) {
+	var executed bool
+	v, err, _ := f.group.Do(key, func() (any, error) {
+		executed = true
+		return fetch(ctx)
+	})
+	if executed {
+		return v, err
+	}
+
+	f.deduped.Add(1)
+	// shared fetch runs with the context of the request that initiated it, so it could be canceled
+	// while the context of this request is still alive
+	if (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) && ctx.Err() == nil {
+		return fetch(ctx)
+	}
+	return v, err
+}
@@ -0,0 +1,63 @@
+package getters
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	libhead "github.com/celestiaorg/go-header"
+
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/header/headertest"
+)
+
+func TestSingleFlightHeaderGetter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	expected := headertest.RandExtendedHeader(t)
+	hg := &blockingHeaderGetter{header: expected, release: make(chan struct{})}
+	getter := NewSingleFlightHeaderGetter(hg)
+
+	const requests = 10
+	var wg sync.WaitGroup
+	wg.Add(requests)
+	for i := 0; i < requests; i++ {
+		go func() {
+			defer wg.Done()
+			h, err := getter.GetByHeight(ctx, expected.Height())
+			assert.NoError(t, err)
+			assert.Equal(t, expected, h)
+		}()
+	}
+
+	// let all requests join the in-flight fetch before releasing it
+	time.Sleep(100 * time.Millisecond)
+	close(hg.release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, hg.calls.Load())
+	assert.EqualValues(t, requests-1, getter.Deduped())
+}
+
+// blockingHeaderGetter counts requests of headers by height and blocks them until released.
+type blockingHeaderGetter struct {
+	libhead.Getter[*header.ExtendedHeader]
+	header  *header.ExtendedHeader
+	release chan struct{}
+	calls   atomic.Int64
+}
+
+func (g *blockingHeaderGetter) GetByHeight(ctx context.Context, _ uint64) (*header.ExtendedHeader, error) {
+	g.calls.Add(1)
+	select {
+	case <-g.release:
+		return g.header, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
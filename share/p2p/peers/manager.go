@@ -16,6 +16,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/p2p/host/eventbus"
 	"github.com/libp2p/go-libp2p/p2p/net/conngater"
+	"golang.org/x/sync/errgroup"
 
 	libhead "github.com/celestiaorg/go-header"
 
@@ -40,6 +41,9 @@ const (
 
 	// storedPoolsAmount is the amount of pools for recent headers that will be stored in the peer manager
 	storedPoolsAmount = 10
+
+	// warmupDialConcurrency limits the amount of full nodes dialed in parallel by Warmup
+	warmupDialConcurrency = 8
 )
 
 type result string
@@ -186,6 +190,30 @@ func (m *Manager) Stop(ctx context.Context) error {
 	return nil
 }
 
+// Warmup connects to the full nodes found via discovery the host is not connected to, so the first
+// requests don't wait for the connection to be established. Up to warmupDialConcurrency peers are
+// dialed in parallel. Peers failing to connect are left for the pool to handle.
+func (m *Manager) Warmup(ctx context.Context) error {
+	errGroup, ctx := errgroup.WithContext(ctx)
+	errGroup.SetLimit(warmupDialConcurrency)
+	for _, peerID := range m.fullNodes.peers() {
+		if m.host.Network().Connectedness(peerID) == network.Connected {
+			continue
+		}
+		peerID := peerID
+		errGroup.Go(func() error {
+			if err := m.host.Connect(ctx, peer.AddrInfo{ID: peerID}); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				log.Debugw("warmup: connecting to full node", "peer", peerID.String(), "err", err)
+			}
+			return nil
+		})
+	}
+	return errGroup.Wait()
+}
+
 // Peer returns peer collected from shrex.Sub for given datahash if any available.
 // If there is none, it will look for full nodes collected from discovery. If there is no discovered
 // full nodes, it will wait until any peer appear in either source or timeout happen.
@@ -11,7 +11,9 @@ import (
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
 	routingdisc "github.com/libp2p/go-libp2p/p2p/discovery/routing"
 	"github.com/libp2p/go-libp2p/p2p/net/conngater"
 	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
@@ -569,3 +571,28 @@ func newShrexSubMsg(h *header.ExtendedHeader) shrexsub.Notification {
 		Height:   h.Height(),
 	}
 }
+
+func TestManager_Warmup(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	const fullNodes = 2*warmupDialConcurrency + 1
+	nw, err := mocknet.FullMeshLinked(fullNodes + 1)
+	require.NoError(t, err)
+
+	manager, err := testManager(ctx, newSubLock())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		stopManager(t, manager)
+	})
+	manager.host = nw.Hosts()[0]
+	for _, fn := range nw.Hosts()[1:] {
+		manager.host.Peerstore().AddAddrs(fn.ID(), fn.Addrs(), peerstore.PermanentAddrTTL)
+		manager.fullNodes.add(fn.ID())
+	}
+
+	require.NoError(t, manager.Warmup(ctx))
+	for _, fn := range nw.Hosts()[1:] {
+		require.Equal(t, network.Connected, manager.host.Network().Connectedness(fn.ID()))
+	}
+}
@@ -2,8 +2,10 @@ package share
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"hash"
 
 	"github.com/celestiaorg/celestia-app/pkg/appconsts"
 )
@@ -13,6 +15,12 @@ var (
 	DefaultRSMT2DCodec = appconsts.DefaultCodec
 )
 
+// DefaultNMTHasher returns a new hasher of nmt nodes using the default hash function, i.e. sha256.
+// Custom hashers are passed to the verifying components through their options instead.
+func DefaultNMTHasher() hash.Hash {
+	return sha256.New()
+}
+
 const (
 	// Size is a system-wide size of a share, including both data and namespace GetNamespace
 	Size = appconsts.ShareSize